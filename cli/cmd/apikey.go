@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bcessa/echo-service/internal/auth"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	viperUtils "go.bryk.io/pkg/cli/viper"
+	"go.bryk.io/pkg/errors"
+)
+
+var apiKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage the API keys accepted by the server",
+	Long: `Manage the API keys accepted by the server.
+
+Keys are registered on a local store file; only a hash of each key is
+saved. By default the store used is the one set on the "rpc.apikey.store"
+configuration setting. A running server applies the changes on the store
+without restarting.`,
+}
+
+func init() {
+	rootCmd.AddCommand(apiKeyCmd)
+}
+
+// setup and bind the provided parameters for the "apikey" subcommand
+// `name`; the "store" parameter is added automatically.
+func apiKeyParams(cmd *cobra.Command, name string, params []cli.Param) {
+	params = append(params, cli.Param{
+		Name:      "store",
+		Usage:     "API keys store file; by default the one set on the configuration file",
+		FlagKey:   fmt.Sprintf("apikey.%s.store", name),
+		ByDefault: "",
+	})
+	if err := cli.SetupCommandParams(cmd, params); err != nil {
+		panic(err)
+	}
+	if err := viperUtils.BindFlags(cmd, params, viper.GetViper()); err != nil {
+		panic(err)
+	}
+	apiKeyCmd.AddCommand(cmd)
+}
+
+// open the API keys store selected for the "apikey" subcommand `name`.
+func apiKeyStore(name string) (*auth.APIKeyStore, error) {
+	file := viper.GetString(fmt.Sprintf("apikey.%s.store", name))
+	if file == "" {
+		file = viper.GetString("rpc.apikey.store")
+	}
+	if file == "" {
+		return nil, errors.New("no API keys store; use the 'store' flag or the 'rpc.apikey.store' setting")
+	}
+	return auth.OpenAPIKeyStore(file, "", 0)
+}
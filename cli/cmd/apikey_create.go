@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+)
+
+var apiKeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Register a new API key",
+	Long: `Register a new API key.
+
+The key is printed to standard output and can't be recovered later;
+only a hash of it is saved on the store. Use "scope" to restrict the
+permissions granted to the key, and "ttl" to set an expiration date.`,
+	Example: "echoctl apikey create --label ci --scope echo:read --ttl 720h",
+	RunE:    runAPIKeyCreate,
+}
+
+func init() {
+	params := []cli.Param{
+		{
+			Name:      "label",
+			Usage:     "descriptive name for the key",
+			FlagKey:   "apikey.create.label",
+			ByDefault: "",
+			Short:     "l",
+		},
+		{
+			Name:      "scope",
+			Usage:     "permission granted to the key; can be provided multiple times",
+			FlagKey:   "apikey.create.scope",
+			ByDefault: []string{},
+		},
+		{
+			Name:      "ttl",
+			Usage:     "period of time the key is valid for; 0 for keys that don't expire",
+			FlagKey:   "apikey.create.ttl",
+			ByDefault: "0s",
+		},
+	}
+	apiKeyParams(apiKeyCreateCmd, "create", params)
+}
+
+func runAPIKeyCreate(_ *cobra.Command, _ []string) error {
+	store, err := apiKeyStore("create")
+	if err != nil {
+		return err
+	}
+	label, scopes := viper.GetString("apikey.create.label"), viper.GetStringSlice("apikey.create.scope")
+	key, info, err := store.Create(label, scopes, viper.GetDuration("apikey.create.ttl"))
+	if err != nil {
+		return err
+	}
+	log.WithField("id", info.ID).Info("API key created")
+	fmt.Println(key)
+	return nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+)
+
+var apiKeyListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the registered API keys",
+	Long: `List the registered API keys.
+
+Revoked and expired keys are omitted unless the "all" flag is used.`,
+	RunE: runAPIKeyList,
+}
+
+func init() {
+	params := []cli.Param{
+		{
+			Name:      "all",
+			Usage:     "include revoked and expired keys",
+			FlagKey:   "apikey.list.all",
+			ByDefault: false,
+			Short:     "a",
+		},
+	}
+	apiKeyParams(apiKeyListCmd, "list", params)
+}
+
+func runAPIKeyList(_ *cobra.Command, _ []string) error {
+	store, err := apiKeyStore("list")
+	if err != nil {
+		return err
+	}
+	all := viper.GetBool("apikey.list.all")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tLABEL\tSCOPES\tCREATED\tEXPIRES\tSTATUS")
+	for _, k := range store.List() {
+		status := "active"
+		switch {
+		case k.RevokedAt != nil:
+			status = "revoked"
+		case !k.Active():
+			status = "expired"
+		}
+		if status != "active" && !all {
+			continue
+		}
+		expires := "never"
+		if k.ExpiresAt != nil {
+			expires = k.ExpiresAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			k.ID, k.Label, strings.Join(k.Scopes, ","), k.CreatedAt.Format(time.RFC3339), expires, status)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.bryk.io/pkg/cli"
+)
+
+var apiKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke [id]",
+	Short: "Revoke an API key",
+	Long: `Revoke an API key.
+
+Revoked keys are rejected by the server as soon as the store is
+reloaded, and are retained on the store for auditing purposes.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAPIKeyRevoke,
+}
+
+func init() {
+	apiKeyParams(apiKeyRevokeCmd, "revoke", []cli.Param{})
+}
+
+func runAPIKeyRevoke(_ *cobra.Command, args []string) error {
+	store, err := apiKeyStore("revoke")
+	if err != nil {
+		return err
+	}
+	if err = store.Revoke(args[0]); err != nil {
+		return err
+	}
+	log.WithField("id", args[0]).Info("API key revoked")
+	return nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+
+	dxTLS "github.com/bcessa/echo-service/internal/dx/modules/tls"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	viperUtils "go.bryk.io/pkg/cli/viper"
+	"go.bryk.io/pkg/errors"
+)
+
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Generate TLS certificates for development environments",
+	Long: `Generate TLS certificates for development environments.
+
+Create a local certificate authority using the "ca" command, and use it
+to issue server and client certificates. Generated files can be used
+directly on the "tls" configuration sections; alternatively, use the
+"base64" format to print values that can be used in place of file paths.`,
+}
+
+func init() {
+	rootCmd.AddCommand(certsCmd)
+}
+
+// setup and bind the provided parameters for a "certs" subcommand.
+func certsParams(cmd *cobra.Command, params []cli.Param) {
+	if err := cli.SetupCommandParams(cmd, params); err != nil {
+		panic(err)
+	}
+	if err := viperUtils.BindFlags(cmd, params, viper.GetViper()); err != nil {
+		panic(err)
+	}
+	certsCmd.AddCommand(cmd)
+}
+
+// output parameters for the "certs" subcommand `name`.
+func certsOutputParams(name, defaultFile string) []cli.Param {
+	return []cli.Param{
+		{
+			Name:      "output",
+			Usage:     "directory to save the generated files",
+			FlagKey:   fmt.Sprintf("certs.%s.output", name),
+			ByDefault: ".",
+			Short:     "o",
+		},
+		{
+			Name:      "file",
+			Usage:     "base name for the generated files; '.crt' and '.key' extensions are added",
+			FlagKey:   fmt.Sprintf("certs.%s.file", name),
+			ByDefault: defaultFile,
+		},
+		{
+			Name:      "format",
+			Usage:     "output format: 'pem' files or 'base64' values printed to standard output",
+			FlagKey:   fmt.Sprintf("certs.%s.format", name),
+			ByDefault: "pem",
+			Short:     "f",
+		},
+		{
+			Name:      "force",
+			Usage:     "overwrite existing files",
+			FlagKey:   fmt.Sprintf("certs.%s.force", name),
+			ByDefault: false,
+		},
+	}
+}
+
+// save or print the key pair generated by the "certs" subcommand `name`.
+func certsOutput(name string, kp *dxTLS.KeyPair) error {
+	key := func(setting string) string {
+		return fmt.Sprintf("certs.%s.%s", name, setting)
+	}
+	switch format := viper.GetString(key("format")); format {
+	case "base64":
+		fmt.Printf("cert: %s\n", base64.StdEncoding.EncodeToString(kp.Certificate))
+		fmt.Printf("key: %s\n", base64.StdEncoding.EncodeToString(kp.PrivateKey))
+		return nil
+	case "pem":
+		base := filepath.Join(filepath.Clean(viper.GetString(key("output"))), viper.GetString(key("file")))
+		cert, pk := base+".crt", base+".key"
+		if err := kp.Save(cert, pk, viper.GetBool(key("force"))); err != nil {
+			return err
+		}
+		log.WithFields(map[string]any{"cert": cert, "key": pk}).Info("certificate created")
+		return nil
+	default:
+		return errors.Errorf("invalid output format: %s", format)
+	}
+}
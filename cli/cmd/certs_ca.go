@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+
+	dxTLS "github.com/bcessa/echo-service/internal/dx/modules/tls"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+)
+
+var certsCaCmd = &cobra.Command{
+	Use:   "ca",
+	Short: "Create a local certificate authority",
+	Long: `Create a local certificate authority.
+
+The CA is only meant to be used on development environments; use it
+to issue server and client certificates, and to verify client
+certificates using the "auth_ca" TLS setting.`,
+	RunE: runCertsCa,
+}
+
+func init() {
+	params := append([]cli.Param{
+		{
+			Name:      "name",
+			Usage:     "common name for the certificate authority",
+			FlagKey:   "certs.ca.name",
+			ByDefault: fmt.Sprintf("%s development CA", appName),
+		},
+		{
+			Name:      "lifetime",
+			Usage:     "period of time the certificate authority is valid for",
+			FlagKey:   "certs.ca.lifetime",
+			ByDefault: "8760h",
+		},
+	}, certsOutputParams("ca", "ca")...)
+	certsParams(certsCaCmd, params)
+}
+
+func runCertsCa(_ *cobra.Command, _ []string) error {
+	ca, err := dxTLS.NewCA(viper.GetString("certs.ca.name"), viper.GetDuration("certs.ca.lifetime"))
+	if err != nil {
+		return err
+	}
+	return certsOutput("ca", ca)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+
+	dxTLS "github.com/bcessa/echo-service/internal/dx/modules/tls"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+)
+
+var certsServerCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Issue a server certificate using a local certificate authority",
+	Long: `Issue a server certificate using a local certificate authority.
+
+Use the "san" flag to set the DNS names, IP addresses and URIs the
+certificate is valid for.`,
+	Example: "certs server --san localhost --san 127.0.0.1",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return runCertsIssue("server", false)
+	},
+}
+
+var certsClientCmd = &cobra.Command{
+	Use:   "client",
+	Short: "Issue a client certificate using a local certificate authority",
+	Long: `Issue a client certificate using a local certificate authority.
+
+Client certificates are used to authenticate against servers with the
+"client_auth" TLS setting set to "verify". Use the "san" flag to set
+additional identifiers; for example a SPIFFE ID.`,
+	Example: "certs client --name my-client --san spiffe://example.org/my-client",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return runCertsIssue("client", true)
+	},
+}
+
+func init() {
+	certsParams(certsServerCmd, append(certsIssueParams("server", "localhost"), certsOutputParams("server", "tls")...))
+	certsParams(certsClientCmd, append(certsIssueParams("client", "client"), certsOutputParams("client", "client")...))
+}
+
+// certificate parameters for the "certs" subcommand `name`.
+func certsIssueParams(name, commonName string) []cli.Param {
+	return []cli.Param{
+		{
+			Name:      "name",
+			Usage:     "common name for the certificate",
+			FlagKey:   fmt.Sprintf("certs.%s.name", name),
+			ByDefault: commonName,
+		},
+		{
+			Name:      "san",
+			Usage:     "subject alternative names: DNS names, IP addresses or URIs",
+			FlagKey:   fmt.Sprintf("certs.%s.san", name),
+			ByDefault: []string{},
+		},
+		{
+			Name:      "lifetime",
+			Usage:     "period of time the certificate is valid for",
+			FlagKey:   fmt.Sprintf("certs.%s.lifetime", name),
+			ByDefault: "720h",
+		},
+		{
+			Name:      "ca-cert",
+			Usage:     "certificate authority certificate (path to PEM file or base64-encoded PEM)",
+			FlagKey:   fmt.Sprintf("certs.%s.ca_cert", name),
+			ByDefault: "ca.crt",
+		},
+		{
+			Name:      "ca-key",
+			Usage:     "certificate authority private key (path to PEM file or base64-encoded PEM)",
+			FlagKey:   fmt.Sprintf("certs.%s.ca_key", name),
+			ByDefault: "ca.key",
+		},
+	}
+}
+
+func runCertsIssue(name string, client bool) error {
+	key := func(setting string) string {
+		return fmt.Sprintf("certs.%s.%s", name, setting)
+	}
+	ca, err := dxTLS.LoadCA(viper.GetString(key("ca_cert")), viper.GetString(key("ca_key")))
+	if err != nil {
+		return err
+	}
+	opts := dxTLS.CertificateOptions{
+		CommonName: viper.GetString(key("name")),
+		Lifetime:   viper.GetDuration(key("lifetime")),
+		Client:     client,
+	}
+	if err = opts.AddSAN(viper.GetStringSlice(key("san"))...); err != nil {
+		return err
+	}
+	if !client && len(opts.DNSNames)+len(opts.IPs)+len(opts.URIs) == 0 {
+		// clients verify server certificates based on SANs
+		_ = opts.AddSAN(opts.CommonName)
+	}
+	kp, err := ca.Issue(opts)
+	if err != nil {
+		return err
+	}
+	return certsOutput(name, kp)
+}
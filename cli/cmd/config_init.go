@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	viperUtils "go.bryk.io/pkg/cli/viper"
+	"go.bryk.io/pkg/errors"
+)
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate a configuration file with default values",
+	Long: `Generate a configuration file with default values.
+
+Every setting is annotated with its description. By default the file is
+printed to standard output; use the "output" flag to save it instead.`,
+	RunE: runConfigInit,
+}
+
+func init() {
+	params := []cli.Param{
+		{
+			Name:      "output",
+			Usage:     "save the configuration file to the provided path",
+			FlagKey:   "config.init.output",
+			ByDefault: "",
+			Short:     "o",
+		},
+		{
+			Name:      "force",
+			Usage:     "overwrite the output file if it already exists",
+			FlagKey:   "config.init.force",
+			ByDefault: false,
+		},
+	}
+	if err := cli.SetupCommandParams(configInitCmd, params); err != nil {
+		panic(err)
+	}
+	if err := viperUtils.BindFlags(configInitCmd, params, viper.GetViper()); err != nil {
+		panic(err)
+	}
+	configCmd.AddCommand(configInitCmd)
+}
+
+func runConfigInit(_ *cobra.Command, _ []string) error {
+	conf, err := reg.DefaultConfig()
+	if err != nil {
+		return err
+	}
+
+	// print to stdout
+	output := viper.GetString("config.init.output")
+	if output == "" {
+		fmt.Printf("%s", conf)
+		return nil
+	}
+
+	// save to file
+	output = filepath.Clean(output)
+	if _, err = os.Stat(output); err == nil && !viper.GetBool("config.init.force") {
+		return errors.Errorf("file already exists: %s", output)
+	}
+	if err = os.WriteFile(output, conf, 0600); err != nil {
+		return err
+	}
+	log.WithField("file", output).Info("configuration file created")
+	return nil
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for configuration files",
+	Long: `Print the JSON Schema for configuration files.
+
+The schema is generated from the settings of all registered modules and
+can be used by editors to validate and auto-complete configuration files.`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		sc, err := reg.Schema()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", sc)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSchemaCmd)
+}
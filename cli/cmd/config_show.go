@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	viperUtils "go.bryk.io/pkg/cli/viper"
+)
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration",
+	Long: `Print the effective configuration.
+
+Settings are merged from all available sources, each value is annotated
+with its origin: default, file, env or flag. Secret values, like private
+keys and DSNs, are redacted. Module flags supported by the "server"
+command are also accepted to preview their effect.`,
+	RunE: runConfigShow,
+}
+
+// flags supported by all registered modules.
+var moduleParams []cli.Param
+
+func init() {
+	names, err := reg.Order()
+	if err != nil {
+		panic(err)
+	}
+	for _, name := range names {
+		moduleParams = append(moduleParams, reg.Get(name).Flags(appName)...)
+	}
+	if err := cli.SetupCommandParams(configShowCmd, moduleParams); err != nil {
+		panic(err)
+	}
+	configCmd.AddCommand(configShowCmd)
+}
+
+func runConfigShow(cmd *cobra.Command, _ []string) error {
+	// flags for this command are bound to a dedicated viper instance to
+	// avoid replacing the ones used by the "server" command
+	v := viper.New()
+	setupEnv(v)
+	if err := viperUtils.BindFlags(cmd, moduleParams, v); err != nil {
+		return err
+	}
+
+	// configuration file
+	fv := viper.New()
+	if cf := viper.ConfigFileUsed(); cf != "" {
+		v.SetConfigFile(cf)
+		fv.SetConfigFile(cf)
+		if err := v.ReadInConfig(); err != nil {
+			return err
+		}
+		_ = fv.ReadInConfig()
+	}
+
+	conf, err := reg.EffectiveConfig(v, func(key string) string {
+		for _, p := range moduleParams {
+			if p.FlagKey == key && cmd.Flags().Changed(p.Name) {
+				return "flag"
+			}
+		}
+		if _, ok := os.LookupEnv(envName(key)); ok {
+			return "env"
+		}
+		if fv.IsSet(key) {
+			return "file"
+		}
+		return "default"
+	})
+	if err != nil {
+		return err
+	}
+	if cf := viper.ConfigFileUsed(); cf != "" {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "# file: %s\n", cf)
+	}
+	_, err = cmd.OutOrStdout().Write(conf)
+	return err
+}
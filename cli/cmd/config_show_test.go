@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestConfigShow(t *testing.T) {
+	// ENV variables only replace settings available on the file
+	config := `
+tls:
+  cert: tls.crt
+  key: file-key-value
+  pkcs12: file-bundle-value
+  passphrase: file-passphrase-value
+rpc:
+  http:
+    gateway_client:
+      key: file-client-key-value
+      passphrase: file-client-passphrase-value
+otel:
+  service_name: file-service
+  sentry:
+    dsn: file-dsn-value
+    environment: testing
+`
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(file)
+	t.Cleanup(viper.Reset)
+	t.Setenv(envName("tls.passphrase"), "env-passphrase-value")
+	t.Setenv(envName("otel.service_name"), "env-service")
+	if err := configShowCmd.Flags().Set("port", "9191"); err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(bytes.Buffer)
+	configShowCmd.SetOut(out)
+	defer configShowCmd.SetOut(nil)
+	if err := runConfigShow(configShowCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// secret values are never printed
+	for _, secret := range []string{
+		"file-key-value",
+		"file-bundle-value",
+		"file-passphrase-value",
+		"file-client-key-value",
+		"file-client-passphrase-value",
+		"file-dsn-value",
+		"env-passphrase-value",
+	} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("secret value printed: %s", secret)
+		}
+	}
+
+	// every value is annotated with its origin
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{key: "port", value: "9191 # flag"},
+		{key: "service_name", value: "env-service # env"},
+		{key: "passphrase", value: "'[redacted]' # env"},
+		{key: "cert", value: "tls.crt # file"},
+		{key: "key", value: "'[redacted]' # file"},
+		{key: "dsn", value: "'[redacted]' # file"},
+		{key: "environment", value: "testing # file"},
+		{key: "level", value: "info # default"},
+	}
+	for _, tt := range tests {
+		if !containsSetting(out.String(), tt.key, tt.value) {
+			t.Errorf("expected '%s: %s' on output:\n%s", tt.key, tt.value, out)
+		}
+	}
+}
+
+// containsSetting reports whether any line on the YAML document sets `key`
+// with a value starting with `value`.
+func containsSetting(doc, key, value string) bool {
+	for _, line := range strings.Split(doc, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), key+": "+value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bcessa/echo-service/internal/dx"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/errors"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration for errors",
+	Long: `Check the configuration for errors.
+
+All problems found are reported at once, including unknown settings,
+values that can't be loaded and invalid values, along with their position on the configuration
+file when available. Settings using a deprecated location are reported
+as warnings.`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		// problems loading the settings don't prevent the remaining checks
+		issues := []dx.Issue{}
+		err := reg.Load(viper.GetViper())
+		if ve := new(dx.ValidationError); errors.As(err, &ve) {
+			issues = append(issues, ve.Issues...)
+		} else if err != nil {
+			return err
+		}
+		for _, issue := range reg.Deprecated(viper.GetViper()) {
+			log.WithField("issue", issue.String()).Warning("deprecated setting")
+		}
+		err = reg.Validate(viper.GetViper())
+		if ve := new(dx.ValidationError); errors.As(err, &ve) {
+			issues = append(issues, ve.Issues...)
+		} else if err != nil {
+			return err
+		}
+		if len(issues) > 0 {
+			for _, issue := range issues {
+				fmt.Println(issue)
+			}
+			return errors.Errorf("%d configuration problem(s) found", len(issues))
+		}
+		log.Info("configuration is valid")
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
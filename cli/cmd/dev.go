@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Utilities for local development and testing",
+}
+
+func init() {
+	rootCmd.AddCommand(devCmd)
+}
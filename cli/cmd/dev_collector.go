@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bcessa/echo-service/internal/otlptest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	viperUtils "go.bryk.io/pkg/cli/viper"
+)
+
+var devCollectorCmd = &cobra.Command{
+	Use:   "collector",
+	Short: "Run a fake OTLP collector that prints the data received",
+	Long: `Run a fake OTLP collector that prints the data received.
+
+The collector accepts OTLP exports over gRPC and HTTP, and prints a
+summary of every batch received, including the service that produced
+it. Use it to verify the "otel.collector" settings without running the
+full collector and tracing backend. Set an address to an empty value to
+disable the corresponding receiver.`,
+	Example: `echoctl dev collector
+echoctl dev collector --http "" --verbose`,
+	RunE: runDevCollector,
+}
+
+func init() {
+	params := []cli.Param{
+		{
+			Name:      "grpc",
+			Usage:     "address used by the gRPC receiver",
+			FlagKey:   "dev.collector.grpc",
+			ByDefault: "localhost:4317",
+		},
+		{
+			Name:      "http",
+			Usage:     "address used by the HTTP receiver",
+			FlagKey:   "dev.collector.http",
+			ByDefault: "localhost:4318",
+		},
+		{
+			Name:      "verbose",
+			Usage:     "print the spans, metrics and log records received",
+			FlagKey:   "dev.collector.verbose",
+			ByDefault: false,
+			Short:     "v",
+		},
+	}
+	if err := cli.SetupCommandParams(devCollectorCmd, params); err != nil {
+		panic(err)
+	}
+	if err := viperUtils.BindFlags(devCollectorCmd, params, viper.GetViper()); err != nil {
+		panic(err)
+	}
+	devCmd.AddCommand(devCollectorCmd)
+}
+
+func runDevCollector(_ *cobra.Command, _ []string) error {
+	verbose := viper.GetBool("dev.collector.verbose")
+	col, err := otlptest.Start(
+		otlptest.WithGRPC(viper.GetString("dev.collector.grpc")),
+		otlptest.WithHTTP(viper.GetString("dev.collector.http")),
+		otlptest.OnReceive(func(b otlptest.Batch) {
+			printBatch(b, verbose)
+		}),
+	)
+	if err != nil {
+		return err
+	}
+	for _, protocol := range []string{"grpc", "http"} {
+		if endpoint := col.Endpoint(protocol); endpoint != "" {
+			log.WithFields(map[string]any{"protocol": protocol, "endpoint": endpoint}).Info("receiver ready")
+		}
+	}
+
+	// wait for "close" signals
+	<-cli.SignalsHandler([]os.Signal{
+		syscall.SIGINT,
+		syscall.SIGQUIT,
+		syscall.SIGTERM,
+	})
+	stats := col.Stats()
+	log.WithFields(map[string]any{
+		"requests": stats.Requests,
+		"spans":    stats.Spans,
+		"metrics":  stats.Metrics,
+		"logs":     stats.Logs,
+	}).Info("closing collector")
+	return col.Close()
+}
+
+// printBatch displays a summary of the batch received and, if `verbose`
+// is set, its contents.
+func printBatch(b otlptest.Batch, verbose bool) {
+	services := []string{}
+	for _, res := range b.Resources() {
+		name, ok := otlptest.Attribute(res.GetAttributes(), "service.name")
+		if ok && !slices.Contains(services, name) {
+			services = append(services, name)
+		}
+	}
+	if len(services) == 0 {
+		services = append(services, "unknown service")
+	}
+	items := map[string]string{
+		otlptest.SignalTraces:  "spans",
+		otlptest.SignalMetrics: "metrics",
+		otlptest.SignalLogs:    "records",
+	}
+	fmt.Printf("%s %-7s %-4s %d %s from %s\n",
+		b.Received.Format(time.TimeOnly), b.Signal, b.Protocol, b.Size(), items[b.Signal], strings.Join(services, ", "))
+	if !verbose {
+		return
+	}
+	for _, rs := range b.Traces {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, s := range ss.GetSpans() {
+				elapsed := time.Duration(s.GetEndTimeUnixNano() - s.GetStartTimeUnixNano())
+				fmt.Printf("  %s %s trace=%x\n", s.GetName(), duration(elapsed), s.GetTraceId())
+			}
+		}
+	}
+	for _, rm := range b.Metrics {
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, m := range sm.GetMetrics() {
+				fmt.Printf("  %s (%s)\n", m.GetName(), sm.GetScope().GetName())
+			}
+		}
+	}
+	for _, rl := range b.Logs {
+		for _, sl := range rl.GetScopeLogs() {
+			for _, rec := range sl.GetLogRecords() {
+				fmt.Printf("  %s %s\n", strings.ToUpper(rec.GetSeverityText()), otlptest.Value(rec.GetBody()))
+			}
+		}
+	}
+}
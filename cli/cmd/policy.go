@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Inspect the authorization policies",
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bcessa/echo-service/internal/auth"
+	dxPolicy "github.com/bcessa/echo-service/internal/dx/modules/policy"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	viperUtils "go.bryk.io/pkg/cli/viper"
+	"go.bryk.io/pkg/errors"
+)
+
+var policyCheckCmd = &cobra.Command{
+	Use:   "check [target]",
+	Short: "Evaluate the authorization policy for a sample identity",
+	Long: `Evaluate the authorization policy for a sample identity.
+
+The target is a full gRPC method name, or an HTTP route including the
+request method when using the "gateway" flag. The sample identity is
+described using flags, or loaded from a JSON file using "identity".
+The policy used is the one set on the configuration file, even when
+disabled; the command fails if the request is denied.`,
+	Example: `echoctl policy check /sample.v1.ServiceAPI/Echo --role admin
+echoctl policy check "POST /v1/echo" --gateway --scope echo:write --claim tenant=acme`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPolicyCheck,
+}
+
+func init() {
+	params := []cli.Param{
+		{
+			Name:      "gateway",
+			Usage:     "use the policy of the HTTP gateway instead of the gRPC server",
+			FlagKey:   "policy.check.gateway",
+			ByDefault: false,
+		},
+		{
+			Name:      "identity",
+			Usage:     "JSON file with the sample identity",
+			FlagKey:   "policy.check.identity",
+			ByDefault: "",
+		},
+		{
+			Name:      "subject",
+			Usage:     "subject of the sample identity",
+			FlagKey:   "policy.check.subject",
+			ByDefault: "sample",
+		},
+		{
+			Name:      "role",
+			Usage:     "role assigned to the sample identity; can be provided multiple times",
+			FlagKey:   "policy.check.role",
+			ByDefault: []string{},
+		},
+		{
+			Name:      "scope",
+			Usage:     "scope granted to the sample identity; can be provided multiple times",
+			FlagKey:   "policy.check.scope",
+			ByDefault: []string{},
+		},
+		{
+			Name:      "claim",
+			Usage:     "claim of the sample identity as a 'name=value' pair; can be provided multiple times",
+			FlagKey:   "policy.check.claim",
+			ByDefault: []string{},
+		},
+		{
+			Name:      "anonymous",
+			Usage:     "evaluate the policy for an unauthenticated caller",
+			FlagKey:   "policy.check.anonymous",
+			ByDefault: false,
+		},
+	}
+	if err := cli.SetupCommandParams(policyCheckCmd, params); err != nil {
+		panic(err)
+	}
+	if err := viperUtils.BindFlags(policyCheckCmd, params, viper.GetViper()); err != nil {
+		panic(err)
+	}
+	policyCmd.AddCommand(policyCheckCmd)
+}
+
+func runPolicyCheck(_ *cobra.Command, args []string) error {
+	// load policy settings
+	key := "rpc.policy"
+	if viper.GetBool("policy.check.gateway") {
+		key = "middleware.policy"
+	}
+	mod := &dxPolicy.Module{Default: auth.PolicyDeny}
+	if err := viper.UnmarshalKey(key, mod); err != nil {
+		return err
+	}
+	policy := mod.Policy(nil)
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	// sample identity
+	ids := []*auth.Identity{}
+	if !viper.GetBool("policy.check.anonymous") {
+		id, err := policySampleIdentity()
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	// evaluate
+	decision := policy.Evaluate(args[0], ids)
+	out, _ := json.MarshalIndent(decision, "", "  ")
+	fmt.Printf("%s\n", out)
+	if !mod.Enabled {
+		log.WithField("key", key).Warning("policy is not enabled")
+	}
+	if !decision.Allowed {
+		return errors.New("request denied")
+	}
+	return nil
+}
+
+// identity described by the "policy check" parameters.
+func policySampleIdentity() (*auth.Identity, error) {
+	if file := viper.GetString("policy.check.identity"); file != "" {
+		data, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, err
+		}
+		id := new(auth.Identity)
+		if err = json.Unmarshal(data, id); err != nil {
+			return nil, errors.Wrap(err, "invalid identity file")
+		}
+		return id, nil
+	}
+	id := &auth.Identity{
+		Method:  "sample",
+		Subject: viper.GetString("policy.check.subject"),
+		Roles:   viper.GetStringSlice("policy.check.role"),
+		Scopes:  viper.GetStringSlice("policy.check.scope"),
+		Claims:  map[string]any{},
+	}
+	for _, claim := range viper.GetStringSlice("policy.check.claim") {
+		name, value, ok := strings.Cut(claim, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid claim: %s", claim)
+		}
+		id.Claims[name] = value
+	}
+	return id, nil
+}
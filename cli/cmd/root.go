@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	dxLog "github.com/bcessa/echo-service/internal/dx/modules/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.bryk.io/pkg/errors"
@@ -13,10 +14,11 @@ import (
 )
 
 var (
-	log     xlog.Logger // app main logger
-	cfgFile = ""        // configuration file used
-	silent  = false     // suppress log output
-	appName = "echoctl" // used for ENV variables prefix (uppercase) and home directories
+	log     xlog.Logger         // app main logger
+	logMod  = new(dxLog.Module) // manage the app main logger based on the "log" settings
+	cfgFile = ""                // configuration file used
+	silent  = false             // suppress log output
+	appName = "echoctl"         // used for ENV variables prefix (uppercase) and home directories
 )
 
 var rootCmdDesc = `
@@ -66,11 +68,21 @@ func exit(err error) {
 	os.Exit(1)
 }
 
+// setup the ENV variables supported by the provided viper instance.
+func setupEnv(v *viper.Viper) {
+	v.SetEnvPrefix(appName)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+}
+
+// name of the ENV variable for the provided configuration key.
+func envName(key string) string {
+	return strings.ToUpper(fmt.Sprintf("%s_%s", appName, strings.ReplaceAll(key, ".", "_")))
+}
+
 func initConfig() {
 	// ENV
-	viper.SetEnvPrefix(appName)
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	viper.AutomaticEnv()
+	setupEnv(viper.GetViper())
 
 	// Set configuration file
 	if cfgFile == "" {
@@ -93,6 +105,7 @@ func initConfig() {
 	// Setup main logger
 	if silent {
 		log = xlog.Discard()
+		viper.Set("log.output", "discard") // applies to all component loggers
 	} else {
 		log = xlog.WithCharm(xlog.CharmOptions{
 			WithColor: true,
@@ -103,6 +116,7 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err != nil && viper.ConfigFileUsed() != "" {
 		log.WithField("file", viper.ConfigFileUsed()).Error("failed to load configuration file")
 	} else {
+		setupLogger()
 		if cf := viper.ConfigFileUsed(); cf != "" {
 			log.WithField("file", cf).Debug("configuration loaded")
 			log.Debug("start watching for configuration updates")
@@ -110,3 +124,25 @@ func initConfig() {
 		}
 	}
 }
+
+// replace the main logger with one based on the "log" settings; on
+// invalid settings the default logger is retained.
+func setupLogger() {
+	if silent {
+		return
+	}
+	if err := logMod.Load(viper.GetViper()); err != nil {
+		log.WithField("error", err.Error()).Warning("invalid log settings")
+		return
+	}
+	if issues := logMod.Validate(); len(issues) > 0 {
+		log.WithField("error", issues[0].String()).Warning("invalid log settings")
+		return
+	}
+	log = logMod.Logger()
+}
+
+// logger for a specific application component.
+func componentLogger(name string) xlog.Logger {
+	return logMod.Component(name)
+}
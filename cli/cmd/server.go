@@ -1,23 +1,19 @@
 package cmd
 
 import (
-	"context"
 	"os"
-	"sync"
 	"syscall"
 
-	"github.com/bcessa/echo-service/handler"
 	"github.com/bcessa/echo-service/internal"
 	"github.com/bcessa/echo-service/internal/dx"
-	dxOtel "github.com/bcessa/echo-service/internal/dx/modules/otel"
-	dxRpc "github.com/bcessa/echo-service/internal/dx/modules/rpc"
+	dxHandler "github.com/bcessa/echo-service/internal/dx/modules/handler"
+	dxLog "github.com/bcessa/echo-service/internal/dx/modules/log"
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.bryk.io/pkg/cli"
 	viperUtils "go.bryk.io/pkg/cli/viper"
-	"go.bryk.io/pkg/net/rpc"
-	otelSdk "go.bryk.io/pkg/otel/sdk"
+	"go.bryk.io/pkg/errors"
 )
 
 var serverCmd = &cobra.Command{
@@ -26,15 +22,14 @@ var serverCmd = &cobra.Command{
 	RunE:  runServer,
 }
 
-// module registry.
-var reg *dx.Registry
+// manage the service handler based on the "handler" settings.
+var handlerMod = new(dxHandler.Module)
+
+// module registry; includes all required dependencies.
+var reg = dx.NewRegistry(appName, internal.ServerModules(logMod, handlerMod)...)
 
 func init() {
-	// register required dependencies
-	reg = dx.NewRegistry(appName)
-	reg.Add(new(dxRpc.Module))
-	reg.Add(new(dxOtel.Module))
-	params := reg.Get("rpc").Flags(appName)
+	params := append(reg.Get("rpc").Flags(appName), reg.Get("tls").Flags(appName)...)
 	if err := cli.SetupCommandParams(serverCmd, params); err != nil {
 		panic(err)
 	}
@@ -44,15 +39,7 @@ func init() {
 	rootCmd.AddCommand(serverCmd)
 }
 
-// nolint: funlen
 func runServer(_ *cobra.Command, _ []string) (err error) {
-	var (
-		wg         = sync.WaitGroup{}       // background tasks handler
-		telemetry  *otelSdk.Instrumentation // telemetry implementation
-		server     *rpc.Server              // rpc server
-		svcHandler *handler.ServiceOperator // service handler
-	)
-
 	// wait for "start" signals
 	startSig := make(chan struct{}, 1)
 
@@ -77,66 +64,22 @@ signals:
 	for {
 		select {
 		case <-startSig:
-			// load application settings
-			if err := reg.Load(viper.GetViper()); err != nil {
-				return err
-			}
-
-			// telemetry instrumentation
-			log.WithField("module", "otel").Debug("loading module")
-			obOpts := []otelSdk.Option{}
-			_ = reg.Get("otel").Customize(&obOpts)
-			if len(obOpts) > 0 {
-				obOpts = append(obOpts, otelSdk.WithBaseLogger(log))
-				telemetry, err = otelSdk.Setup(obOpts...)
-				if err != nil {
-					return err
-				}
-			}
-
-			// rpc server settings
-			log.WithField("module", "rpc").Debug("loading module")
-			serverOptions := []rpc.ServerOption{}
-			if err := reg.Get("rpc").Customize(&serverOptions); err != nil {
-				return err
-			}
-
-			// add build information as server middleware
-			buildMW := rpc.WithGatewayMiddleware(internal.BuildDetails().Middleware())
-			serverOptions = append(serverOptions, rpc.WithHTTPGatewayOptions(buildMW))
-
-			// service handler
-			log.Info("starting service handler")
-			svcHandler, err = handler.New()
-			if err != nil {
-				return err
-			}
-
-			// start server
-			log.Info("starting server")
-			serverOptions = append(serverOptions, rpc.WithServiceProvider(svcHandler.RPC()))
-			server, err = rpc.NewServer(serverOptions...)
-			if err != nil {
-				return err
+			if err = startServer(); err != nil {
+				// continue to regular shutdown process
+				break signals
 			}
-			ready := make(chan bool)
-			wg.Add(1)
-			go func() {
-				_ = server.Start(ready)
-				wg.Done()
-			}()
-			<-ready
-			log.Info("server is ready and waiting for requests")
 		case <-reloadSig:
 			log.Info("reloading server")
-			_ = server.Stop(true) // gracefully stop server
-			if telemetry != nil {
-				// drain telemetry operator
-				telemetry.Flush(context.Background())
+			if err := viper.ReadInConfig(); err != nil && viper.ConfigFileUsed() != "" {
+				log.WithField("error", err.Error()).Warning("failed to read configuration file")
 			}
-			wg.Wait()               // wait for background tasks
-			_ = svcHandler.Reload() // reload service handler
-			startSig <- struct{}{}  // signal server to start again
+			if err := checkConfig(viper.GetViper()); err != nil {
+				reportIssues(err, "invalid configuration; the server keeps running with the current settings")
+				continue
+			}
+			logMod.Export(nil)     // stop exporting logs before telemetry is drained
+			_ = reg.Stop()         // stop modules in reverse dependency order
+			startSig <- struct{}{} // signal server to start again; reloads the service handler
 		case <-closeSig:
 			log.Info("closing server")
 			// stop signal processing and continue to regular shutdown process
@@ -145,17 +88,64 @@ signals:
 	}
 
 	// shutdown process
-	if err = svcHandler.Close(); err != nil {
-		log.WithField("error", err.Error()).Error("service handler close")
+	logMod.Export(nil) // stop exporting logs before telemetry is drained
+
+	// stop modules in reverse dependency order; a failed start is reported
+	// over any error found while stopping
+	if sErr := reg.Stop(); sErr != nil && err == nil {
+		err = sErr
+	}
+	if hErr := handlerMod.Close(); hErr != nil {
+		log.WithField("error", hErr.Error()).Error("service handler close")
+	}
+	_ = logMod.Close() // flush and close the log file, if any
+	close(startSig)    // clean up "start" signals channel
+	close(reloadSig)   // clean up "reload" signals channel
+	close(closeSig)    // clean up "close" signals channel
+	return err         // return final result
+}
+
+// startServer loads the application settings and starts all modules in
+// dependency order.
+func startServer() error {
+	// load application settings
+	if err := reg.Load(viper.GetViper()); err != nil {
+		return err
+	}
+	if err := reg.Validate(viper.GetViper()); err != nil {
+		return err
+	}
+	for _, issue := range reg.Deprecated(viper.GetViper()) {
+		log.WithField("issue", issue.String()).Warning("deprecated setting")
+	}
+
+	// start modules in dependency order
+	log.Info("starting server")
+	if err := internal.StartServer(reg, componentLogger); err != nil {
+		return err
+	}
+	log.Info("server is ready and waiting for requests")
+	return nil
+}
+
+// checkConfig loads and validates the settings on a separate registry,
+// without affecting the running modules.
+func checkConfig(v *viper.Viper) error {
+	check := dx.NewRegistry(appName, internal.ServerModules(new(dxLog.Module), new(dxHandler.Module))...)
+	if err := check.Load(v); err != nil {
+		return err
+	}
+	return check.Validate(v)
+}
+
+// reportIssues logs every configuration problem included on `err`.
+func reportIssues(err error, msg string) {
+	ve := new(dx.ValidationError)
+	if !errors.As(err, &ve) {
+		log.WithField("error", err.Error()).Error(msg)
+		return
 	}
-	err = server.Stop(true) // gracefully stop server
-	if telemetry != nil {
-		// drain telemetry operator
-		telemetry.Flush(context.Background())
+	for _, issue := range ve.Issues {
+		log.WithField("issue", issue.String()).Error(msg)
 	}
-	wg.Wait()        // wait for background tasks
-	close(startSig)  // clean up "start" signals channel
-	close(reloadSig) // clean up "reload" signals channel
-	close(closeSig)  // clean up "close" signals channel
-	return err       // return final result
 }
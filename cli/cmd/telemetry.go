@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Inspect telemetry data captured locally",
+}
+
+func init() {
+	rootCmd.AddCommand(telemetryCmd)
+}
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	viperUtils "go.bryk.io/pkg/cli/viper"
+	"go.bryk.io/pkg/errors"
+)
+
+var telemetryViewCmd = &cobra.Command{
+	Use:   "view [file]",
+	Short: "Display a telemetry capture file as trace trees",
+	Long: `Display a telemetry capture file as trace trees.
+
+The file is the JSON lines output produced when the "otel.export" setting
+is used. Spans are grouped by trace and displayed as a tree, including
+the log messages produced while handling each span. Use "-" to read the
+data from standard input.`,
+	Example: `echoctl telemetry view telemetry.jsonl
+echoctl telemetry view telemetry.jsonl --trace 4bf92f3577b34da6a3ce929d0e0e4736 --attributes`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTelemetryView,
+}
+
+func init() {
+	params := []cli.Param{
+		{
+			Name:      "trace",
+			Usage:     "only display the trace with the provided identifier",
+			FlagKey:   "telemetry.view.trace",
+			ByDefault: "",
+		},
+		{
+			Name:      "attributes",
+			Usage:     "include span and log attributes",
+			FlagKey:   "telemetry.view.attributes",
+			ByDefault: false,
+			Short:     "a",
+		},
+		{
+			Name:      "logs",
+			Usage:     "include log messages",
+			FlagKey:   "telemetry.view.logs",
+			ByDefault: true,
+		},
+		{
+			Name:      "errors",
+			Usage:     "only display traces including failed spans",
+			FlagKey:   "telemetry.view.errors",
+			ByDefault: false,
+		},
+	}
+	if err := cli.SetupCommandParams(telemetryViewCmd, params); err != nil {
+		panic(err)
+	}
+	if err := viperUtils.BindFlags(telemetryViewCmd, params, viper.GetViper()); err != nil {
+		panic(err)
+	}
+	telemetryCmd.AddCommand(telemetryViewCmd)
+}
+
+func runTelemetryView(_ *cobra.Command, args []string) error {
+	var src io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(filepath.Clean(args[0]))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		src = f
+	}
+	capture, err := readCapture(src)
+	if err != nil {
+		return err
+	}
+
+	opts := viewOptions{
+		attributes: viper.GetBool("telemetry.view.attributes"),
+		logs:       viper.GetBool("telemetry.view.logs"),
+	}
+	filter := viper.GetString("telemetry.view.trace")
+	onlyErrors := viper.GetBool("telemetry.view.errors")
+	for _, t := range capture.traces() {
+		if filter != "" && t.id != filter {
+			continue
+		}
+		if onlyErrors && !t.failed {
+			continue
+		}
+		t.print(os.Stdout, opts)
+	}
+	if opts.logs && filter == "" && !onlyErrors && len(capture.orphans) > 0 {
+		fmt.Println("logs without trace")
+		for _, l := range capture.orphans {
+			l.print(os.Stdout, "", opts)
+		}
+		fmt.Println()
+	}
+	if capture.metrics > 0 {
+		fmt.Printf("%d metric exports not displayed\n", capture.metrics)
+	}
+	return nil
+}
+
+type viewOptions struct {
+	attributes bool
+	logs       bool
+}
+
+// zero span identifier, used on spans without a parent.
+const noSpan = "0000000000000000"
+
+// span kinds, as encoded by the stdout trace exporter.
+var spanKinds = []string{"", "internal", "server", "client", "producer", "consumer"}
+
+type viewAttribute struct {
+	Key   string
+	Value struct {
+		Type  string
+		Value any
+	}
+}
+
+type viewSpanContext struct {
+	TraceID string
+	SpanID  string
+	Remote  bool
+}
+
+type viewSpan struct {
+	Name        string
+	SpanContext viewSpanContext
+	Parent      viewSpanContext
+	SpanKind    int
+	StartTime   time.Time
+	EndTime     time.Time
+	Attributes  []viewAttribute
+	Status      struct {
+		Code        string
+		Description string
+	}
+
+	children []*viewSpan
+	logs     []*viewLog
+}
+
+type viewLog struct {
+	Timestamp    *time.Time
+	SeverityText string
+	Body         struct {
+		Type  string
+		Value any
+	}
+	Attributes []viewAttribute
+	TraceID    string
+	SpanID     string
+}
+
+type viewTrace struct {
+	id     string
+	roots  []*viewSpan
+	spans  int
+	failed bool
+	start  time.Time
+	end    time.Time
+}
+
+type viewCapture struct {
+	spans   []*viewSpan
+	logs    []*viewLog
+	orphans []*viewLog
+	metrics int
+}
+
+// readCapture decodes the spans and log records on a capture file;
+// metric exports are only counted.
+func readCapture(src io.Reader) (*viewCapture, error) {
+	capture := new(viewCapture)
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := scanner.Bytes()
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+		keys := map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return nil, errors.Errorf("invalid content on line %d: %s", line, err)
+		}
+		switch {
+		case keys["SpanContext"] != nil:
+			span := new(viewSpan)
+			if err := json.Unmarshal(data, span); err != nil {
+				return nil, errors.Errorf("invalid span on line %d: %s", line, err)
+			}
+			capture.spans = append(capture.spans, span)
+		case keys["SeverityText"] != nil:
+			record := new(viewLog)
+			if err := json.Unmarshal(data, record); err != nil {
+				return nil, errors.Errorf("invalid log record on line %d: %s", line, err)
+			}
+			capture.logs = append(capture.logs, record)
+		case keys["ScopeMetrics"] != nil:
+			capture.metrics++
+		}
+	}
+	return capture, scanner.Err()
+}
+
+// traces groups the captured spans by trace, sorted by start time, and
+// attaches every log record to the span it was produced on.
+func (c *viewCapture) traces() []*viewTrace {
+	byID := map[string]*viewSpan{}
+	for _, s := range c.spans {
+		byID[s.SpanContext.SpanID] = s
+	}
+	for _, l := range c.logs {
+		if s, ok := byID[l.SpanID]; ok && l.TraceID == s.SpanContext.TraceID {
+			s.logs = append(s.logs, l)
+			continue
+		}
+		c.orphans = append(c.orphans, l)
+	}
+
+	traces := map[string]*viewTrace{}
+	list := []*viewTrace{}
+	for _, s := range c.spans {
+		t, ok := traces[s.SpanContext.TraceID]
+		if !ok {
+			t = &viewTrace{id: s.SpanContext.TraceID, start: s.StartTime, end: s.EndTime}
+			traces[t.id] = t
+			list = append(list, t)
+		}
+		t.spans++
+		t.failed = t.failed || s.Status.Code == "Error"
+		if s.StartTime.Before(t.start) {
+			t.start = s.StartTime
+		}
+		if s.EndTime.After(t.end) {
+			t.end = s.EndTime
+		}
+		if parent, ok := byID[s.Parent.SpanID]; ok && s.Parent.SpanID != noSpan {
+			parent.children = append(parent.children, s)
+			continue
+		}
+		t.roots = append(t.roots, s)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].start.Before(list[j].start)
+	})
+	return list
+}
+
+func (t *viewTrace) print(w io.Writer, opts viewOptions) {
+	_, _ = fmt.Fprintf(w, "trace %s (%d spans, %s)\n", t.id, t.spans, duration(t.end.Sub(t.start)))
+	sortSpans(t.roots)
+	for i, s := range t.roots {
+		s.print(w, "", i == len(t.roots)-1, opts)
+	}
+	_, _ = fmt.Fprintln(w)
+}
+
+func (s *viewSpan) print(w io.Writer, prefix string, last bool, opts viewOptions) {
+	branch, indent := "├─ ", "│  "
+	if last {
+		branch, indent = "└─ ", "   "
+	}
+	line := fmt.Sprintf("%s%s%s %s", prefix, branch, s.Name, duration(s.EndTime.Sub(s.StartTime)))
+	if s.SpanKind > 0 && s.SpanKind < len(spanKinds) {
+		line += fmt.Sprintf(" [%s]", spanKinds[s.SpanKind])
+	}
+	if s.Status.Code == "Error" {
+		line += " ERROR"
+		if s.Status.Description != "" {
+			line += ": " + s.Status.Description
+		}
+	}
+	_, _ = fmt.Fprintln(w, line)
+
+	prefix += indent
+	if opts.attributes {
+		for _, attr := range s.Attributes {
+			_, _ = fmt.Fprintf(w, "%s  %s=%v\n", prefix, attr.Key, attr.Value.Value)
+		}
+	}
+	if opts.logs {
+		for _, l := range s.logs {
+			l.print(w, prefix, opts)
+		}
+	}
+	sortSpans(s.children)
+	for i, child := range s.children {
+		child.print(w, prefix, i == len(s.children)-1, opts)
+	}
+}
+
+func (l *viewLog) print(w io.Writer, prefix string, opts viewOptions) {
+	ts := ""
+	if l.Timestamp != nil {
+		ts = l.Timestamp.Format(time.TimeOnly) + " "
+	}
+	_, _ = fmt.Fprintf(w, "%s· %s%s %v\n", prefix, ts, strings.ToUpper(l.SeverityText), l.Body.Value)
+	if opts.attributes {
+		for _, attr := range l.Attributes {
+			if attr.Key == "trace_id" || attr.Key == "span_id" {
+				continue // already used to place the message
+			}
+			_, _ = fmt.Fprintf(w, "%s    %s=%v\n", prefix, attr.Key, attr.Value.Value)
+		}
+	}
+}
+
+func sortSpans(list []*viewSpan) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].StartTime.Before(list[j].StartTime)
+	})
+}
+
+// duration rounds the value for display.
+func duration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
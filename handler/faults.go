@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"go.bryk.io/pkg/errors"
+)
+
+// Latency distributions supported by the `Slow` method.
+const (
+	// LatencyFixed always uses the `Mean` value.
+	LatencyFixed = "fixed"
+
+	// LatencyUniform selects values evenly between `Min` and `Max`.
+	LatencyUniform = "uniform"
+
+	// LatencyNormal selects values around `Mean`, using `StdDev`.
+	LatencyNormal = "normal"
+
+	// LatencyPareto selects values starting at `Min` with a long tail,
+	// using `Shape`; lower shape values produce longer tails.
+	LatencyPareto = "pareto"
+)
+
+// Latency describes the distribution of delays introduced by the `Slow`
+// method. For the "normal" distribution values are always kept between
+// `Min` and `Max`, when set; "pareto" values are always capped at `Max`.
+type Latency struct {
+	// Distribution used: fixed, uniform, normal or pareto.
+	Distribution string
+
+	// Lower bound for all distributions; scale for "pareto".
+	Min time.Duration
+
+	// Upper bound for all distributions; ignored if 0 except for
+	// "uniform" and "pareto".
+	Max time.Duration
+
+	// Value used for "fixed" and mean for "normal".
+	Mean time.Duration
+
+	// Standard deviation for "normal".
+	StdDev time.Duration
+
+	// Shape (alpha) for "pareto".
+	Shape float64
+}
+
+// DefaultLatency used by the `Slow` method: between 20 and 200ms.
+var DefaultLatency = Latency{
+	Distribution: LatencyUniform,
+	Min:          20 * time.Millisecond,
+	Max:          200 * time.Millisecond,
+}
+
+// Default error probabilities for the `Slow` and `Faulty` methods.
+const (
+	DefaultSlowErrorRate   = 0.0
+	DefaultFaultyErrorRate = 0.5
+)
+
+// Validate the latency settings.
+func (l Latency) Validate() error {
+	if !slices.Contains([]string{LatencyFixed, LatencyUniform, LatencyNormal, LatencyPareto}, l.Distribution) {
+		return errors.Errorf("invalid distribution: %s", l.Distribution)
+	}
+	if l.Min < 0 || l.Max < 0 || l.Mean < 0 || l.StdDev < 0 {
+		return errors.New("durations can't be negative")
+	}
+	if (l.Max > 0 || l.Distribution == LatencyUniform) && l.Max < l.Min {
+		return errors.New("max can't be lower than min")
+	}
+	if l.Distribution == LatencyPareto && (l.Min == 0 || l.Max == 0 || l.Shape <= 0) {
+		return errors.New("pareto distribution requires min and max values and a positive shape")
+	}
+	return nil
+}
+
+// sample a delay value using the provided random source.
+func (l Latency) sample(rng *rand.Rand) time.Duration {
+	var d time.Duration
+	switch l.Distribution {
+	case LatencyFixed:
+		d = l.Mean
+	case LatencyUniform:
+		d = l.Min + time.Duration(rng.Int63n(int64(l.Max-l.Min)+1))
+	case LatencyNormal:
+		d = l.Mean + time.Duration(rng.NormFloat64()*float64(l.StdDev))
+	case LatencyPareto:
+		// the tail is unbounded; cap it before converting the value
+		v := float64(l.Min) / math.Pow(1-rng.Float64(), 1/l.Shape)
+		d = time.Duration(min(v, float64(l.Max)))
+	}
+	if d < l.Min {
+		d = l.Min
+	}
+	if l.Max > 0 && d > l.Max {
+		d = l.Max
+	}
+	return d
+}
+
+// WithSeed sets the seed of the random source used by the `Slow` and
+// `Faulty` methods; the same seed produces the same sequence of delays
+// and errors. By default, a random seed is used.
+func WithSeed(seed int64) Option {
+	return func(so *ServiceOperator) {
+		so.seed = seed
+	}
+}
+
+// WithLatency sets the distribution of delays introduced by the `Slow`
+// method. By default, `DefaultLatency` is used.
+func WithLatency(l Latency) Option {
+	return func(so *ServiceOperator) {
+		so.latency = l
+	}
+}
+
+// WithErrorRate sets the probability, between 0 and 1, of the `Slow` and
+// `Faulty` methods returning an error. By default, `Slow` never fails and
+// `Faulty` fails half the time.
+func WithErrorRate(slow, faulty float64) Option {
+	return func(so *ServiceOperator) {
+		so.slowErrors, so.faultyErrors = slow, faulty
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestLatencySample(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name     string
+		latency  Latency
+		min, max time.Duration
+	}{
+		{
+			name:    "fixed",
+			latency: Latency{Distribution: LatencyFixed, Mean: 50 * ms},
+			min:     50 * ms,
+			max:     50 * ms,
+		},
+		{
+			name:    "fixed within bounds",
+			latency: Latency{Distribution: LatencyFixed, Mean: 50 * ms, Max: 20 * ms},
+			min:     20 * ms,
+			max:     20 * ms,
+		},
+		{
+			name:    "uniform",
+			latency: Latency{Distribution: LatencyUniform, Min: 20 * ms, Max: 200 * ms},
+			min:     20 * ms,
+			max:     200 * ms,
+		},
+		{
+			name:    "uniform single value",
+			latency: Latency{Distribution: LatencyUniform, Min: 20 * ms, Max: 20 * ms},
+			min:     20 * ms,
+			max:     20 * ms,
+		},
+		{
+			name:    "normal",
+			latency: Latency{Distribution: LatencyNormal, Mean: 100 * ms, StdDev: 50 * ms, Min: 10 * ms, Max: 150 * ms},
+			min:     10 * ms,
+			max:     150 * ms,
+		},
+		{
+			name:    "normal without bounds",
+			latency: Latency{Distribution: LatencyNormal, Mean: 10 * ms, StdDev: 50 * ms},
+			min:     0,
+			max:     time.Duration(1<<63 - 1),
+		},
+		{
+			name:    "pareto",
+			latency: Latency{Distribution: LatencyPareto, Min: 20 * ms, Max: time.Second, Shape: 1.5},
+			min:     20 * ms,
+			max:     time.Second,
+		},
+		{
+			name:    "pareto long tail",
+			latency: Latency{Distribution: LatencyPareto, Min: 20 * ms, Max: time.Second, Shape: 0.001},
+			min:     20 * ms,
+			max:     time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.latency.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			rng := rand.New(rand.NewSource(1)) // nolint: gosec
+			for range 1000 {
+				if d := tt.latency.sample(rng); d < tt.min || d > tt.max {
+					t.Fatalf("value out of range: %s", d)
+				}
+			}
+		})
+	}
+}
+
+func TestLatencyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency Latency
+		valid   bool
+	}{
+		{name: "default", latency: DefaultLatency, valid: true},
+		{name: "unknown distribution", latency: Latency{Distribution: "poisson"}},
+		{name: "negative duration", latency: Latency{Distribution: LatencyFixed, Mean: -1}},
+		{name: "max lower than min", latency: Latency{Distribution: LatencyUniform, Min: 2, Max: 1}},
+		{name: "uniform without max", latency: Latency{Distribution: LatencyUniform, Min: 1}},
+		{name: "pareto", latency: Latency{Distribution: LatencyPareto, Min: 1, Max: 2, Shape: 1}, valid: true},
+		{name: "pareto without min", latency: Latency{Distribution: LatencyPareto, Max: 2, Shape: 1}},
+		{name: "pareto without max", latency: Latency{Distribution: LatencyPareto, Min: 1, Shape: 1}},
+		{name: "pareto without shape", latency: Latency{Distribution: LatencyPareto, Min: 1, Max: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.latency.Validate(); (err == nil) != tt.valid {
+				t.Errorf("expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestDraw(t *testing.T) {
+	// outcomes produced by alternating `Slow` and `Faulty` requests
+	outcomes := func(t *testing.T, opts ...Option) []outcome {
+		t.Helper()
+		so, err := New(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list := make([]outcome, 20)
+		for i := range list {
+			list[i] = so.draw(i%2 == 0)
+			list[i].log = nil
+		}
+		return list
+	}
+	equal := func(a, b []outcome) bool {
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	t.Run("same seed", func(t *testing.T) {
+		if !equal(outcomes(t, WithSeed(42)), outcomes(t, WithSeed(42))) {
+			t.Error("expected the same outcomes")
+		}
+	})
+
+	t.Run("different seed", func(t *testing.T) {
+		if equal(outcomes(t, WithSeed(42)), outcomes(t, WithSeed(7))) {
+			t.Error("expected different outcomes")
+		}
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		so, err := New(WithSeed(42))
+		if err != nil {
+			t.Fatal(err)
+		}
+		first := so.draw(true)
+		_ = so.draw(false)
+		if err = so.Reload(WithSeed(42)); err != nil {
+			t.Fatal(err)
+		}
+		if next := so.draw(true); next.delay != first.delay || next.value != first.value {
+			t.Error("expected the sequence to restart")
+		}
+	})
+
+	t.Run("error rate", func(t *testing.T) {
+		for _, res := range outcomes(t, WithSeed(42), WithErrorRate(1, 0)) {
+			slow := res.delay > 0
+			if res.fail != slow {
+				t.Fatalf("unexpected outcome: %+v", res)
+			}
+		}
+	})
+}
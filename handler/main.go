@@ -4,20 +4,54 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
 	otelApi "go.bryk.io/pkg/otel/api"
 )
 
 // ServiceOperator provides an implementation for all functional
 // requirements (i.e., business logic) required to cover the service
 // scope.
-type ServiceOperator struct{}
+type ServiceOperator struct {
+	log          xlog.Logger
+	seed         int64
+	latency      Latency
+	slowErrors   float64
+	faultyErrors float64
+	rng          *rand.Rand
+	mu           sync.Mutex
+}
+
+// Option adjusts the settings of a service operator instance.
+type Option func(so *ServiceOperator)
+
+// ContextLogger is implemented by loggers able to correlate messages with
+// the request trace, like the ones provided by the "log" module.
+type ContextLogger interface {
+	// WithContext returns a logger including the trace and span identifiers
+	// available on `ctx`, if any.
+	WithContext(ctx context.Context) xlog.Logger
+}
+
+// WithLogger sets the logger used by the service operator. By default
+// log messages are discarded. If the logger implements `ContextLogger`,
+// messages produced while handling a request include its trace details.
+func WithLogger(log xlog.Logger) Option {
+	return func(so *ServiceOperator) {
+		so.log = log
+	}
+}
 
 // New service operator instance.
-func New() (*ServiceOperator, error) {
-	return &ServiceOperator{}, nil
+func New(opts ...Option) (*ServiceOperator, error) {
+	so := &ServiceOperator{log: xlog.Discard()}
+	if err := so.configure(opts); err != nil {
+		return nil, err
+	}
+	return so, nil
 }
 
 // Ping provides a basic reachability test.
@@ -39,46 +73,67 @@ func (so *ServiceOperator) Echo(ctx context.Context, msg string) (string, error)
 	return fmt.Sprintf("you said: %s", msg), nil
 }
 
-// Slow is a method that exhibit a random latency between 10 and 200ms.
+// Slow is a method that exhibit a random latency, between 20 and 200ms
+// by default. It can also fail, based on the configured error rate.
 func (so *ServiceOperator) Slow(ctx context.Context) error {
 	span := otelApi.Start(ctx, "slow handler")
 	defer span.End(nil)
 
-	delay := rand.Intn(180) + 20 // nolint:gosec
+	res := so.draw(true)
+	delay := res.delay.Milliseconds()
 	attrs := otelApi.AsWarning()
 	attrs.Set("app.delay", delay)
 	span.Event("waiting for slow operation", attrs)
-	<-time.After(time.Duration(delay) * time.Millisecond)
+	withContext(span.Context(), res.log).WithField("app.delay", delay).Debug("waiting for slow operation")
+	<-time.After(res.delay)
 
+	if res.fail {
+		err := errors.New("slow operation failed")
+		span.End(err)
+		return err
+	}
 	return nil
 }
 
 // Faulty is a method that returns an error roughly
-// 50% of the time.
+// 50% of the time, or based on the configured error rate.
 func (so *ServiceOperator) Faulty(ctx context.Context) error {
 	span := otelApi.Start(ctx, "faulty handler")
 	defer span.End(nil)
 
-	check := rand.Intn(20) + 1 // nolint:gosec
-	if check%2 == 0 {
+	res := so.draw(false)
+	if res.fail {
 		err := errors.New("random error")
 		attrs := otelApi.AsWarning()
-		attrs.Set("random.value", check)
+		attrs.Set("random.value", res.value)
 		span.Event("bad luck", attrs)
+		withContext(span.Context(), res.log).WithField("random.value", res.value).Debug("bad luck")
 		span.End(err)
 		return err
 	}
 
 	attrs := otelApi.AsInfo()
-	attrs.Set("random.value", check)
+	attrs.Set("random.value", res.value)
 	span.Event("good luck", attrs)
 	return nil
 }
 
+// withContext returns a logger for messages produced while handling the
+// request on `ctx`.
+func withContext(ctx context.Context, log xlog.Logger) xlog.Logger {
+	if cl, ok := log.(ContextLogger); ok {
+		return cl.WithContext(ctx)
+	}
+	return log
+}
+
 // Reload the operator instance by refreshing or re-establishing
-// any internal resources or dependencies.
-func (so *ServiceOperator) Reload() error {
-	return nil
+// any internal resources or dependencies. Settings are reset to their
+// defaults and adjusted using the provided options, and the random
+// source is seeded again; the current logger is kept unless a new
+// one is provided.
+func (so *ServiceOperator) Reload(opts ...Option) error {
+	return so.configure(opts)
 }
 
 // Close the service operator gracefully and free any used/locked
@@ -86,3 +141,59 @@ func (so *ServiceOperator) Reload() error {
 func (so *ServiceOperator) Close() error {
 	return nil
 }
+
+// configure the operator using the default settings adjusted by `opts`.
+// Settings are only applied if valid.
+func (so *ServiceOperator) configure(opts []Option) error {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	next := &ServiceOperator{
+		log:          so.log,
+		latency:      DefaultLatency,
+		slowErrors:   DefaultSlowErrorRate,
+		faultyErrors: DefaultFaultyErrorRate,
+	}
+	for _, opt := range opts {
+		opt(next)
+	}
+	if err := next.latency.Validate(); err != nil {
+		return errors.Wrap(err, "invalid latency")
+	}
+	for _, rate := range []float64{next.slowErrors, next.faultyErrors} {
+		if rate < 0 || rate > 1 {
+			return errors.Errorf("invalid error rate: %v", rate)
+		}
+	}
+	seed := next.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	so.log, so.seed, so.latency = next.log, next.seed, next.latency
+	so.slowErrors, so.faultyErrors = next.slowErrors, next.faultyErrors
+	so.rng = rand.New(rand.NewSource(seed)) // nolint: gosec
+	return nil
+}
+
+// outcome of a `Slow` or `Faulty` request.
+type outcome struct {
+	log   xlog.Logger   // logger to use for the request
+	delay time.Duration // latency, only for `Slow`
+	value float64       // random value between 0 and 1
+	fail  bool          // whether the request must fail
+}
+
+// draw the outcome of a `Slow` or `Faulty` request from the random source,
+// based on the current settings.
+func (so *ServiceOperator) draw(slow bool) outcome {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	res := outcome{log: so.log}
+	rate := so.faultyErrors
+	if slow {
+		res.delay = so.latency.sample(so.rng)
+		rate = so.slowErrors
+	}
+	res.value = so.rng.Float64()
+	res.fail = res.value < rate
+	return res
+}
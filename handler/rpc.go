@@ -3,8 +3,8 @@ package handler
 import (
 	"context"
 
+	"github.com/bcessa/echo-service/internal/rpc"
 	protov1 "github.com/bcessa/echo-service/proto/sample/v1"
-	"go.bryk.io/pkg/net/rpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
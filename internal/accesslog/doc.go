@@ -0,0 +1,25 @@
+/*
+Package accesslog provides structured access logs for gRPC and HTTP requests.
+
+An entry is produced for every request, including the method or route, status
+code, latency, peer address, request and response sizes, trace identifier and
+the identity of the authenticated caller.
+
+	al, _ := accesslog.New(accesslog.Options{
+		Format: accesslog.FormatLogfmt,
+		Sample: 0.1,
+		Omit:   []string{"Ping", "/v1/ready"},
+		Skip:   rpc.FromGateway,
+	})
+	grpc.ChainUnaryInterceptor(al.UnaryServerInterceptor())
+	handler = al.Handler()(handler)
+
+Sampling only applies to successful requests; failed requests are always
+logged. To report the caller identity the access logger must be installed
+before the authentication layers.
+
+When both gRPC and HTTP requests are logged, use `Skip` to exclude the gRPC
+requests forwarded by an in-process HTTP gateway; otherwise these requests
+are logged twice.
+*/
+package accesslog
@@ -0,0 +1,95 @@
+package accesslog
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryServerInterceptor logs every unary request; it must be installed
+// before authentication interceptors to report the caller identity.
+func (l *Logger) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if l.omit(info.FullMethod) || l.skip(ctx) {
+			return handler(ctx, req)
+		}
+		ctx, entry, ids := l.start(ctx, "grpc")
+		res, err := handler(ctx, req)
+		entry.RequestSize, entry.ResponseSize = msgSize(req), msgSize(res)
+		l.finish(ctx, rpcEntry(ctx, entry, info.FullMethod, err), ids)
+		return res, err
+	}
+}
+
+// StreamServerInterceptor logs every stream once closed; it must be
+// installed before authentication interceptors to report the caller
+// identity.
+func (l *Logger) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if l.omit(info.FullMethod) || l.skip(ss.Context()) {
+			return handler(srv, ss)
+		}
+		ctx, entry, ids := l.start(ss.Context(), "grpc")
+		ws := &sizedStream{ServerStream: ss, ctx: ctx}
+		err := handler(srv, ws)
+		entry.RequestSize, entry.ResponseSize = ws.received, ws.sent
+		l.finish(ctx, rpcEntry(ctx, entry, info.FullMethod, err), ids)
+		return err
+	}
+}
+
+// rpcEntry completes the entry details for a gRPC request.
+func rpcEntry(ctx context.Context, e *Entry, method string, err error) *Entry {
+	code := status.Code(err)
+	e.Method = method
+	e.Status = code.String()
+	e.Code = int(code)
+	e.Failed = code != codes.OK
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.Peer = p.Addr.String()
+	}
+	return e
+}
+
+// msgSize returns the encoded size of a protobuf message.
+func msgSize(msg any) int64 {
+	if pm, ok := msg.(proto.Message); ok {
+		return int64(proto.Size(pm))
+	}
+	return 0
+}
+
+// sizedStream accumulates the size of the messages on a stream.
+type sizedStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received int64
+	sent     int64
+}
+
+// Context returns the adjusted stream context.
+func (ss *sizedStream) Context() context.Context {
+	return ss.ctx
+}
+
+// RecvMsg records the size of every message received.
+func (ss *sizedStream) RecvMsg(m any) error {
+	err := ss.ServerStream.RecvMsg(m)
+	if err == nil {
+		ss.received += msgSize(m)
+	}
+	return err
+}
+
+// SendMsg records the size of every message sent.
+func (ss *sizedStream) SendMsg(m any) error {
+	err := ss.ServerStream.SendMsg(m)
+	if err == nil {
+		ss.sent += msgSize(m)
+	}
+	return err
+}
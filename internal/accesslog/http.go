@@ -0,0 +1,32 @@
+package accesslog
+
+import (
+	"net/http"
+
+	"github.com/bcessa/echo-service/internal/request"
+)
+
+// Handler logs every HTTP request; it must be installed before the
+// authentication middleware to report the caller identity.
+func (l *Logger) Handler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if l.omit(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, entry, ids := l.start(r.Context(), "http")
+			rw := request.NewRecorder(w)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+			entry.Method = r.Method
+			entry.Route = r.URL.Path
+			entry.Status = http.StatusText(rw.Code())
+			entry.Code = rw.Code()
+			entry.Failed = rw.Code() >= http.StatusBadRequest
+			entry.Peer = r.RemoteAddr
+			entry.RequestSize = max(r.ContentLength, 0)
+			entry.ResponseSize = rw.Size()
+			l.finish(ctx, entry, ids)
+		})
+	}
+}
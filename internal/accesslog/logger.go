@@ -0,0 +1,241 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bcessa/echo-service/internal/auth"
+	"github.com/bcessa/echo-service/internal/request"
+	"go.bryk.io/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Supported output formats.
+const (
+	// FormatJSON produces a JSON object per request.
+	FormatJSON = "json"
+
+	// FormatLogfmt produces a line of `key=value` pairs per request.
+	FormatLogfmt = "logfmt"
+)
+
+// Options available when creating an access logger.
+type Options struct {
+	// Output format: `FormatJSON` or `FormatLogfmt`; defaults to JSON.
+	Format string
+
+	// Ratio of successful requests logged, between 0 and 1. Failed
+	// requests are always logged.
+	Sample float64
+
+	// gRPC methods or HTTP paths never logged; either the method name, the
+	// full method name or path, or a pattern like "/v1/admin/*".
+	Omit []string
+
+	// gRPC requests for which `Skip` returns `true` are never logged; for
+	// example, requests forwarded by an HTTP gateway and already logged
+	// by `Handler`. Optional.
+	Skip func(ctx context.Context) bool
+
+	// Destination for log entries; defaults to standard output.
+	Sink io.Writer
+}
+
+// Entry describes a single request.
+type Entry struct {
+	// Time the request was received.
+	Time time.Time
+
+	// Request protocol: "grpc" or "http".
+	Protocol string
+
+	// Full gRPC method name, or HTTP request method.
+	Method string
+
+	// HTTP request path; empty for gRPC requests.
+	Route string
+
+	// gRPC status code name, or HTTP status text.
+	Status string
+
+	// Numeric gRPC status code, or HTTP status code.
+	Code int
+
+	// Time spent handling the request.
+	Latency time.Duration
+
+	// Address of the remote peer.
+	Peer string
+
+	// Size of the request and response payloads, in bytes.
+	RequestSize  int64
+	ResponseSize int64
+
+	// Trace identifier, if the request was traced.
+	TraceID string
+
+	// Identity of the authenticated caller, as "method:subject".
+	Identity string
+
+	// Whether the request failed.
+	Failed bool
+}
+
+// Logger produces access log entries for gRPC and HTTP requests.
+type Logger struct {
+	opts Options
+	mu   sync.Mutex
+}
+
+// New returns an access logger using the provided options.
+func New(opts Options) (*Logger, error) {
+	if opts.Format == "" {
+		opts.Format = FormatJSON
+	}
+	if opts.Format != FormatJSON && opts.Format != FormatLogfmt {
+		return nil, errors.Errorf("invalid format: %s", opts.Format)
+	}
+	if opts.Sample < 0 || opts.Sample > 1 {
+		return nil, errors.New("sample must be between 0 and 1")
+	}
+	for _, pattern := range opts.Omit {
+		if err := request.Validate(pattern); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Sink == nil {
+		opts.Sink = os.Stdout
+	}
+	return &Logger{opts: opts}, nil
+}
+
+// omit returns `true` if requests to `target` must not be logged.
+func (l *Logger) omit(target string) bool {
+	return slices.ContainsFunc(l.opts.Omit, func(pattern string) bool { return request.Match(pattern, target) })
+}
+
+// skip returns `true` if the gRPC request on `ctx` must not be logged.
+func (l *Logger) skip(ctx context.Context) bool {
+	return l.opts.Skip != nil && l.opts.Skip(ctx)
+}
+
+// start returns a context tracking the identities verified while the
+// request is handled, and a partial entry for it.
+func (l *Logger) start(ctx context.Context, protocol string) (context.Context, *Entry, func() []*auth.Identity) {
+	ctx, ids := auth.Track(ctx)
+	return ctx, &Entry{Time: time.Now(), Protocol: protocol}, ids
+}
+
+// finish completes and writes the entry, subject to sampling.
+func (l *Logger) finish(ctx context.Context, e *Entry, ids func() []*auth.Identity) {
+	e.Latency = time.Since(e.Time)
+	if !e.Failed && rand.Float64() >= l.opts.Sample { // nolint: gosec
+		return
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		e.TraceID = sc.TraceID().String()
+	}
+	if list := ids(); len(list) > 0 {
+		id := list[len(list)-1]
+		e.Identity = id.Method + ":" + id.Subject
+	}
+	l.write(e)
+}
+
+func (l *Logger) write(e *Entry) {
+	fields := e.fields()
+	buf := new(bytes.Buffer)
+	switch l.opts.Format {
+	case FormatLogfmt:
+		for i, f := range fields {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(f.key)
+			buf.WriteByte('=')
+			buf.WriteString(logfmtValue(f.value))
+		}
+	default:
+		buf.WriteByte('{')
+		for i, f := range fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(f.key)
+			v, _ := json.Marshal(f.value)
+			buf.Write(k)
+			buf.WriteByte(':')
+			buf.Write(v)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte('\n')
+	l.mu.Lock()
+	_, _ = l.opts.Sink.Write(buf.Bytes())
+	l.mu.Unlock()
+}
+
+type field struct {
+	key   string
+	value any
+}
+
+// fields returns the entry values in a stable order; empty optional
+// values are omitted.
+func (e *Entry) fields() []field {
+	list := []field{
+		{"time", e.Time.UTC().Format(time.RFC3339Nano)},
+		{"protocol", e.Protocol},
+		{"method", e.Method},
+	}
+	if e.Route != "" {
+		list = append(list, field{"route", e.Route})
+	}
+	list = append(list,
+		field{"status", e.Status},
+		field{"code", e.Code},
+		field{"latency_ms", float64(e.Latency.Microseconds()) / 1000},
+		field{"peer", e.Peer},
+		field{"request_size", e.RequestSize},
+		field{"response_size", e.ResponseSize},
+	)
+	if e.TraceID != "" {
+		list = append(list, field{"trace_id", e.TraceID})
+	}
+	if e.Identity != "" {
+		list = append(list, field{"identity", e.Identity})
+	}
+	return list
+}
+
+// logfmtValue returns the string representation of a value, quoted when
+// required.
+func logfmtValue(v any) string {
+	var s string
+	switch val := v.(type) {
+	case string:
+		s = val
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(val)
+		s = string(b)
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
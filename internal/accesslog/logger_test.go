@@ -0,0 +1,171 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSampling(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample float64
+		status int
+		logged int // entries expected for 100 requests
+	}{
+		{name: "all requests", sample: 1, status: http.StatusOK, logged: 100},
+		{name: "no successful requests", sample: 0, status: http.StatusOK},
+		{name: "failed requests", sample: 0, status: http.StatusInternalServerError, logged: 100},
+		{name: "client errors", sample: 0, status: http.StatusNotFound, logged: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := new(bytes.Buffer)
+			l, err := New(Options{Sample: tt.sample, Sink: sink})
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler := l.Handler()(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			for range 100 {
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/ping", nil))
+			}
+			if logged := strings.Count(sink.String(), "\n"); logged != tt.logged {
+				t.Errorf("expected %d entries, got %d", tt.logged, logged)
+			}
+		})
+	}
+
+	t.Run("ratio", func(t *testing.T) {
+		sink := new(bytes.Buffer)
+		l, err := New(Options{Sample: 0.5, Sink: sink})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok := l.Handler()(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+		failed := l.Handler()(http.NotFoundHandler())
+		for range 1000 {
+			ok.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/ping", nil))
+		}
+		// statistically, almost impossible to fall outside this range
+		if logged := strings.Count(sink.String(), "\n"); logged < 350 || logged > 650 {
+			t.Errorf("unexpected number of entries sampled: %d", logged)
+		}
+		sink.Reset()
+		failed.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/missing", nil))
+		if sink.Len() == 0 {
+			t.Error("failed request not logged")
+		}
+	})
+}
+
+func TestOmit(t *testing.T) {
+	l, err := New(Options{Omit: []string{"Ping", "/v1/ready", "/v1/admin/*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		target string
+		omit   bool
+	}{
+		{target: "/sample.v1.ServiceAPI/Ping", omit: true},
+		{target: "/v1/ping"},
+		{target: "/v1/ready", omit: true},
+		{target: "/sample.v1.ServiceAPI/Ready"},
+		{target: "/v1/admin/log", omit: true},
+		{target: "/v1/admin"},
+		{target: "/sample.v1.ServiceAPI/Echo"},
+	}
+	for _, tt := range tests {
+		if omit := l.omit(tt.target); omit != tt.omit {
+			t.Errorf("%s: expected omit=%v, got %v", tt.target, tt.omit, omit)
+		}
+	}
+	if _, err = New(Options{Omit: []string{"/v1/[admin"}}); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	sink := new(bytes.Buffer)
+	l, err := New(Options{
+		Sample: 1,
+		Omit:   []string{"Ping"},
+		Skip:   func(ctx context.Context) bool { return ctx.Value(skipKey{}) != nil },
+		Sink:   sink,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor := l.UnaryServerInterceptor()
+	call := func(ctx context.Context, method string, err error) {
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+		_, _ = interceptor(ctx, nil, info, func(_ context.Context, _ any) (any, error) { return nil, err })
+	}
+	call(context.Background(), "/sample.v1.ServiceAPI/Ping", nil)
+	call(context.WithValue(context.Background(), skipKey{}, true), "/sample.v1.ServiceAPI/Echo", nil)
+	call(context.Background(), "/sample.v1.ServiceAPI/Faulty", status.Error(codes.Internal, "failed"))
+
+	lines := strings.Split(strings.TrimSpace(sink.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected a single entry, got: %v", lines)
+	}
+	entry := map[string]any{}
+	if err = json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["method"] != "/sample.v1.ServiceAPI/Faulty" || entry["status"] != "Internal" || entry["protocol"] != "grpc" {
+		t.Errorf("unexpected entry: %s", lines[0])
+	}
+}
+
+func TestLogfmt(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{value: "GET", expected: "GET"},
+		{value: "/v1/ping", expected: "/v1/ping"},
+		{value: "", expected: `""`},
+		{value: "Not Found", expected: `"Not Found"`},
+		{value: "key=value", expected: `"key=value"`},
+		{value: `say "hi"`, expected: `"say \"hi\""`},
+		{value: "line\nbreak", expected: `"line\nbreak"`},
+		{value: "tab\there", expected: `"tab\there"`},
+		{value: 404, expected: "404"},
+		{value: int64(1024), expected: "1024"},
+		{value: 1.5, expected: "1.5"},
+		{value: true, expected: "true"},
+	}
+	for _, tt := range tests {
+		if res := logfmtValue(tt.value); res != tt.expected {
+			t.Errorf("%v: expected %s, got %s", tt.value, tt.expected, res)
+		}
+	}
+
+	// full entry
+	sink := new(bytes.Buffer)
+	l, err := New(Options{Format: FormatLogfmt, Sample: 1, Sink: sink})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Handler()(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodGet, "/v1/missing", nil))
+	for _, expected := range []string{"protocol=http", "method=GET", "route=/v1/missing", `status="Not Found"`, "code=404"} {
+		if !strings.Contains(sink.String(), expected) {
+			t.Errorf("expected %s on entry: %s", expected, sink.String())
+		}
+	}
+}
+
+// context key used to skip requests.
+type skipKey struct{}
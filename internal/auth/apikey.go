@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.bryk.io/pkg/errors"
+)
+
+// MethodAPIKey identifies callers authenticated using API keys.
+const MethodAPIKey = "apikey"
+
+// DefaultAPIKeyHeader is the request header used by default to provide
+// API keys.
+const DefaultAPIKeyHeader = "x-api-key"
+
+// APIKey describes a key registered on a store. The secret part of the
+// key is never stored; only its hash is retained.
+type APIKey struct {
+	// Public identifier, included as the first part of the key.
+	ID string `json:"id"`
+
+	// Hex-encoded SHA-256 hash of the secret part of the key.
+	Hash string `json:"hash"`
+
+	// Descriptive name for the key.
+	Label string `json:"label,omitempty"`
+
+	// Permissions granted to the key.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Key lifecycle.
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active returns `true` if the key is not revoked nor expired.
+func (k *APIKey) Active() bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt)
+}
+
+// APIKeyStore manages API keys saved on a local JSON file. The file is
+// reloaded, at most once per refresh period, when it changes.
+type APIKeyStore struct {
+	file    string
+	header  string
+	refresh time.Duration
+	mu      sync.Mutex
+	keys    []*APIKey
+	mod     time.Time // file modification time
+	size    int64     // file size
+	check   time.Time // last file check
+}
+
+// OpenAPIKeyStore returns a store backed by `file`; a missing file is
+// treated as an empty store. `header` is the request header used to
+// provide keys, `DefaultAPIKeyHeader` if empty.
+func OpenAPIKeyStore(file, header string, refresh time.Duration) (*APIKeyStore, error) {
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+	st := &APIKeyStore{
+		file:    filepath.Clean(file),
+		header:  strings.ToLower(header),
+		refresh: refresh,
+	}
+	if err := st.load(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// Create a new key; the returned value is the only time the full key is
+// available. If `ttl` is zero the key never expires.
+func (st *APIKeyStore) Create(label string, scopes []string, ttl time.Duration) (string, *APIKey, error) {
+	id := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	key := &APIKey{
+		ID:        hex.EncodeToString(id),
+		Label:     label,
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	value := base64.RawURLEncoding.EncodeToString(secret)
+	key.Hash = hashSecret(value)
+	if ttl > 0 {
+		exp := key.CreatedAt.Add(ttl)
+		key.ExpiresAt = &exp
+	}
+	if err := st.update(func(keys []*APIKey) []*APIKey { return append(keys, key) }); err != nil {
+		return "", nil, err
+	}
+	return key.ID + "." + value, key, nil
+}
+
+// Revoke the key with the provided identifier. Revoked keys are retained
+// on the store for auditing purposes.
+func (st *APIKeyStore) Revoke(id string) error {
+	found := false
+	err := st.update(func(keys []*APIKey) []*APIKey {
+		for _, k := range keys {
+			if k.ID == id && k.RevokedAt == nil {
+				now := time.Now().UTC()
+				k.RevokedAt = &now
+				found = true
+			}
+		}
+		return keys
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.Errorf("no active key with ID: %s", id)
+	}
+	return nil
+}
+
+// List the keys registered on the store.
+func (st *APIKeyStore) List() []APIKey {
+	st.reload()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	list := make([]APIKey, len(st.keys))
+	for i, k := range st.keys {
+		list[i] = *k
+	}
+	return list
+}
+
+// Credential returns the API key included on the request, if any.
+func (st *APIKeyStore) Credential(header headerFunc) (string, bool) {
+	value := strings.TrimSpace(header(st.header))
+	return value, value != ""
+}
+
+// Verify the provided key and return the identity of its holder; the
+// key scopes are available on the identity.
+func (st *APIKeyStore) Verify(value string) (*Identity, error) {
+	id, secret, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, errors.New("invalid API key")
+	}
+	st.reload()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, k := range st.keys {
+		if k.ID != id {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hashSecret(secret))) != 1 {
+			return nil, errors.New("invalid API key")
+		}
+		if !k.Active() {
+			return nil, errors.New("API key is revoked or expired")
+		}
+		return &Identity{
+			Method:  MethodAPIKey,
+			Subject: k.ID,
+			Label:   k.Label,
+			Scopes:  slices.Clone(k.Scopes),
+		}, nil
+	}
+	return nil, errors.New("invalid API key")
+}
+
+// reload the keys if the refresh period elapsed and the file was modified;
+// on errors the current keys are retained.
+func (st *APIKeyStore) reload() {
+	st.mu.Lock()
+	if time.Since(st.check) < st.refresh {
+		st.mu.Unlock()
+		return
+	}
+	st.check = time.Now()
+	st.mu.Unlock()
+	_ = st.load()
+}
+
+func (st *APIKeyStore) load() error {
+	info, err := os.Stat(st.file)
+	if errors.Is(err, os.ErrNotExist) {
+		st.mu.Lock()
+		st.keys, st.mod, st.size = []*APIKey{}, time.Time{}, 0
+		st.mu.Unlock()
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "API keys store not available")
+	}
+	st.mu.Lock()
+	changed := !info.ModTime().Equal(st.mod) || info.Size() != st.size
+	st.mu.Unlock()
+	if !changed {
+		return nil
+	}
+	data, err := os.ReadFile(st.file)
+	if err != nil {
+		return errors.Wrap(err, "failed to read API keys store")
+	}
+	keys := []*APIKey{}
+	if err = json.Unmarshal(data, &keys); err != nil {
+		return errors.Wrap(err, "invalid API keys store")
+	}
+	st.mu.Lock()
+	st.keys, st.mod, st.size, st.check = keys, info.ModTime(), info.Size(), time.Now()
+	st.mu.Unlock()
+	return nil
+}
+
+// update the store contents using `fn` and save them to the file.
+func (st *APIKeyStore) update(fn func([]*APIKey) []*APIKey) error {
+	st.mu.Lock()
+	st.mod = time.Time{} // force reload
+	st.mu.Unlock()
+	if err := st.load(); err != nil {
+		return err
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	keys := fn(st.keys)
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// replace the file atomically
+	tmp := st.file + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, st.file); err != nil {
+		return err
+	}
+	st.keys = keys
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
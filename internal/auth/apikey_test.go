@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyStoreVerify(t *testing.T) {
+	st, err := OpenAPIKeyStore(filepath.Join(t.TempDir(), "keys.json"), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, key, err := st.Create("ci", []string{"echo:write"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, revokedKey, err := st.Create("old", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = st.Revoke(revokedKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := st.Create("short", nil, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	id, secret, _ := strings.Cut(valid, ".")
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+	}{
+		{name: "valid", value: valid, ok: true},
+		{name: "wrong secret", value: id + ".invalid"},
+		{name: "unknown ID", value: "000000000000." + secret},
+		{name: "missing separator", value: id + secret},
+		{name: "empty", value: ""},
+		{name: "revoked", value: revoked},
+		{name: "expired", value: expired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := st.Verify(tt.value)
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected the key to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if identity.Method != MethodAPIKey || identity.Subject != key.ID || identity.Label != "ci" {
+				t.Errorf("unexpected identity: %+v", identity)
+			}
+			if !slices.Equal(identity.Scopes, []string{"echo:write"}) {
+				t.Errorf("unexpected scopes: %v", identity.Scopes)
+			}
+		})
+	}
+
+	t.Run("revoke unknown key", func(t *testing.T) {
+		if err := st.Revoke(revokedKey.ID); err == nil {
+			t.Error("expected an error revoking a key twice")
+		}
+	})
+}
+
+func TestAPIKeyStoreCredential(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		request map[string]string
+		value   string
+		found   bool
+	}{
+		{
+			name:    "default header",
+			request: map[string]string{"x-api-key": " abc.def "},
+			value:   "abc.def",
+			found:   true,
+		},
+		{
+			name:    "custom header",
+			header:  "X-Service-Key",
+			request: map[string]string{"x-service-key": "abc.def"},
+			value:   "abc.def",
+			found:   true,
+		},
+		{
+			name:    "missing",
+			request: map[string]string{"authorization": "Bearer abc.def"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := OpenAPIKeyStore(filepath.Join(t.TempDir(), "keys.json"), tt.header, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, found := st.Credential(func(name string) string { return tt.request[name] })
+			if value != tt.value || found != tt.found {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.value, tt.found, value, found)
+			}
+		})
+	}
+}
+
+func TestAPIKeyStoreReload(t *testing.T) {
+	tests := []struct {
+		name    string
+		refresh time.Duration
+		update  func(t *testing.T, file string) string // returns a key to verify
+		ok      bool
+	}{
+		{
+			name:    "key created by another process",
+			refresh: 0,
+			update: func(t *testing.T, file string) string {
+				return createKey(t, file)
+			},
+			ok: true,
+		},
+		{
+			name:    "changes ignored within the refresh period",
+			refresh: time.Hour,
+			update: func(t *testing.T, file string) string {
+				return createKey(t, file)
+			},
+		},
+		{
+			name:    "key revoked by another process",
+			refresh: 0,
+			update: func(t *testing.T, file string) string {
+				value := createKey(t, file)
+				other, err := OpenAPIKeyStore(file, "", 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				id, _, _ := strings.Cut(value, ".")
+				if err = other.Revoke(id); err != nil {
+					t.Fatal(err)
+				}
+				return value
+			},
+		},
+		{
+			name:    "invalid file retains current keys",
+			refresh: 0,
+			update: func(t *testing.T, file string) string {
+				if err := os.WriteFile(file, []byte("[{invalid"), 0600); err != nil {
+					t.Fatal(err)
+				}
+				return ""
+			},
+			ok: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "keys.json")
+			existing := createKey(t, file)
+			st, err := OpenAPIKeyStore(file, "", tt.refresh)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = st.Verify(existing); err != nil {
+				t.Fatalf("existing key rejected: %s", err)
+			}
+			value := tt.update(t, file)
+			if value == "" {
+				value = existing
+			}
+			_, err = st.Verify(value)
+			if tt.ok && err != nil {
+				t.Errorf("key rejected: %s", err)
+			}
+			if !tt.ok && err == nil {
+				t.Error("key accepted")
+			}
+		})
+	}
+
+	t.Run("invalid store", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "keys.json")
+		if err := os.WriteFile(file, []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenAPIKeyStore(file, "", 0); err == nil {
+			t.Error("expected an error opening an invalid store")
+		}
+	})
+}
+
+// createKey registers a new key on the store file using a separate store
+// instance, like the "apikey create" command does.
+func createKey(t *testing.T, file string) string {
+	t.Helper()
+	st, err := OpenAPIKeyStore(file, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, _, err := st.Create("test", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
@@ -0,0 +1,41 @@
+/*
+Package auth provides utilities to manage the identity of authenticated
+callers.
+
+Authentication layers verify the credentials presented by a caller and
+attach the resulting identity to the request context. Handlers can then
+retrieve it without depending on the specific mechanism used.
+
+	if id, ok := auth.FromContext(ctx); ok {
+		fmt.Println(id.Subject)
+	}
+
+JWT bearer tokens are validated using a `JWTVerifier`. The claims of valid
+tokens are retained on the caller identity.
+
+	claims, _ := auth.Claims(ctx)
+
+API keys are validated using an `APIKeyStore`, a local file with hashed
+keys. The scopes and label of the key used are retained on the caller
+identity.
+
+Verifiers are enabled using gRPC interceptors and HTTP middleware; when
+several are used, requests are accepted if any of them succeeds. Each
+verifier can exempt some requests; a request is accepted without credentials
+only when every verifier exempts it. For HTTP requests, exemptions must
+include the route, since method names don't match HTTP paths.
+
+	auth.Handler(
+		auth.Authenticator{Verifier: jwt, Exempt: []string{"GET /v1/ping"}},
+		auth.Authenticator{Verifier: keys, Exempt: []string{"GET /v1/ping"}},
+	)
+
+Roles and scopes are taken from the "roles" and "scope" claims of JWT
+tokens, or the scopes of API keys; authorization policies use them to
+restrict the callers allowed to access each method or route.
+
+	policy := &auth.Policy{Rules: []auth.Rule{
+		{Targets: []string{"/sample.v1.ServiceAPI/Echo"}, Roles: []string{"admin"}},
+	}}
+*/
+package auth
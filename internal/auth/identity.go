@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"sync"
+)
+
+// Supported authentication methods.
+const (
+	// MethodCertificate identifies callers authenticated using x509 client
+	// certificates (mTLS).
+	MethodCertificate = "mtls"
+
+	// MethodJWT identifies callers authenticated using JWT bearer tokens.
+	MethodJWT = "jwt"
+)
+
+// Identity describes an authenticated caller.
+type Identity struct {
+	// Authentication mechanism used to verify the identity.
+	Method string `json:"method"`
+
+	// Unique identifier for the caller.
+	Subject string `json:"subject"`
+
+	// Descriptive name for the caller, if any.
+	Label string `json:"label,omitempty"`
+
+	// Roles assigned to the caller, if any.
+	Roles []string `json:"roles,omitempty"`
+
+	// Permissions granted to the caller, if any.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// DNS names included as SANs on the caller certificate, if any.
+	DNSNames []string `json:"dns_names,omitempty"`
+
+	// URIs included as SANs on the caller certificate, if any.
+	URIs []string `json:"uris,omitempty"`
+
+	// SPIFFE ID included on the caller certificate, if any.
+	SPIFFEID string `json:"spiffe_id,omitempty"`
+
+	// Claims included on the caller token, if any.
+	Claims map[string]any `json:"claims,omitempty"`
+}
+
+// FromCertificate returns the identity described by a verified x509
+// client certificate.
+func FromCertificate(cert *x509.Certificate) *Identity {
+	id := &Identity{
+		Method:   MethodCertificate,
+		Subject:  cert.Subject.String(),
+		DNSNames: cert.DNSNames,
+	}
+	for _, uri := range cert.URIs {
+		id.URIs = append(id.URIs, uri.String())
+		if uri.Scheme == "spiffe" && id.SPIFFEID == "" {
+			id.SPIFFEID = uri.String()
+		}
+	}
+	return id
+}
+
+type ctxKey struct{}
+
+// WithIdentity returns a copy of `ctx` including the provided identity.
+// A caller can be authenticated by several mechanisms; all identities
+// are retained.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	list := append(All(ctx), id)
+	if t, ok := ctx.Value(trackerKey{}).(*tracker); ok {
+		t.add(id)
+	}
+	return context.WithValue(ctx, ctxKey{}, list)
+}
+
+// Track returns a copy of `ctx` recording the identities verified on any
+// context derived from it; they are available using the returned function.
+// Useful for layers wrapping the authentication process, like access logs.
+func Track(ctx context.Context) (context.Context, func() []*Identity) {
+	t := new(tracker)
+	return context.WithValue(ctx, trackerKey{}, t), t.all
+}
+
+type trackerKey struct{}
+
+type tracker struct {
+	mu   sync.Mutex
+	list []*Identity
+}
+
+func (t *tracker) add(id *Identity) {
+	t.mu.Lock()
+	t.list = append(t.list, id)
+	t.mu.Unlock()
+}
+
+func (t *tracker) all() []*Identity {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Identity{}, t.list...)
+}
+
+// FromContext returns the most recently verified identity available
+// in `ctx`, if any.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	list := All(ctx)
+	if len(list) == 0 {
+		return nil, false
+	}
+	return list[len(list)-1], true
+}
+
+// Claims returns the claims of the most recently verified identity
+// including them, if any; for example a JWT bearer token.
+func Claims(ctx context.Context) (map[string]any, bool) {
+	list := All(ctx)
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i].Claims != nil {
+			return list[i].Claims, true
+		}
+	}
+	return nil, false
+}
+
+// All returns every identity available in `ctx`, in the order they
+// were verified.
+func All(ctx context.Context) []*Identity {
+	list, _ := ctx.Value(ctxKey{}).([]*Identity)
+	return append([]*Identity{}, list...)
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.bryk.io/pkg/errors"
+)
+
+// DefaultAlgorithms are the signature algorithms accepted by default;
+// only asymmetric algorithms are supported.
+var DefaultAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// JWTOptions adjust the validation of JWT bearer tokens.
+type JWTOptions struct {
+	// PEM-encoded public keys used to verify token signatures.
+	Keys [][]byte
+
+	// Local JWKS file with additional verification keys.
+	JWKS string
+
+	// Minimum period of time between checks for JWKS file updates.
+	Refresh time.Duration
+
+	// Expected token issuer; not verified if empty.
+	Issuer string
+
+	// Audiences accepted; tokens must include at least one of them.
+	Audience []string
+
+	// Tolerance used when verifying time-based claims.
+	Leeway time.Duration
+
+	// Signature algorithms accepted; `DefaultAlgorithms` if empty.
+	Algorithms []string
+}
+
+// JWTVerifier validates JWT bearer tokens. Keys loaded from a JWKS file
+// are reloaded, at most once per refresh period, when the file changes.
+type JWTVerifier struct {
+	opts   JWTOptions
+	static []crypto.PublicKey
+	parser *jwt.Parser
+	mu     sync.Mutex
+	keys   map[string]crypto.PublicKey // JWKS keys by ID
+	mod    time.Time                   // JWKS file modification time
+	check  time.Time                   // last JWKS file check
+}
+
+// NewJWTVerifier returns a verifier based on the provided options. At
+// least one verification key is required.
+func NewJWTVerifier(opts JWTOptions) (*JWTVerifier, error) {
+	if len(opts.Algorithms) == 0 {
+		opts.Algorithms = DefaultAlgorithms
+	}
+	for _, alg := range opts.Algorithms {
+		if !slices.Contains(DefaultAlgorithms, alg) {
+			return nil, errors.Errorf("unsupported signature algorithm: %s", alg)
+		}
+	}
+	v := &JWTVerifier{opts: opts, keys: map[string]crypto.PublicKey{}}
+	for _, key := range opts.Keys {
+		pub, err := parsePublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		v.static = append(v.static, pub)
+	}
+	if opts.JWKS != "" {
+		if err := v.loadJWKS(); err != nil {
+			return nil, err
+		}
+	}
+	if len(v.static) == 0 && len(v.keys) == 0 {
+		return nil, errors.New("no verification keys available")
+	}
+	po := []jwt.ParserOption{
+		jwt.WithValidMethods(opts.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(opts.Leeway),
+	}
+	if opts.Issuer != "" {
+		po = append(po, jwt.WithIssuer(opts.Issuer))
+	}
+	v.parser = jwt.NewParser(po...)
+	return v, nil
+}
+
+// Credential returns the bearer token included on the "authorization"
+// header, if any.
+func (v *JWTVerifier) Credential(header headerFunc) (string, bool) {
+	return bearerToken(header("authorization"))
+}
+
+// Verify the provided token and return the identity of its subject. The
+// token claims are available on the identity.
+func (v *JWTVerifier) Verify(token string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.keyFunc); err != nil {
+		return nil, errors.Wrap(err, "invalid token")
+	}
+	if len(v.opts.Audience) > 0 {
+		aud, _ := claims.GetAudience()
+		if !slices.ContainsFunc(aud, func(a string) bool { return slices.Contains(v.opts.Audience, a) }) {
+			return nil, errors.New("invalid token: audience not accepted")
+		}
+	}
+	sub, _ := claims.GetSubject()
+	return &Identity{
+		Method:  MethodJWT,
+		Subject: sub,
+		Roles:   claimValues(claims["roles"]),
+		Scopes:  tokenScopes(claims),
+		Claims:  claims,
+	}, nil
+}
+
+// tokenScopes returns the scopes granted to a token, using either the
+// space-delimited "scope" claim or the "scp" list.
+func tokenScopes(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	return claimValues(claims["scp"])
+}
+
+// keyFunc returns the keys used to verify the token signature.
+func (v *JWTVerifier) keyFunc(token *jwt.Token) (any, error) {
+	v.refresh()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+		if key, ok := v.keys[kid]; ok {
+			return key, nil
+		}
+	}
+	set := jwt.VerificationKeySet{}
+	for _, key := range v.static {
+		set.Keys = append(set.Keys, key)
+	}
+	for _, key := range v.keys {
+		set.Keys = append(set.Keys, key)
+	}
+	return set, nil
+}
+
+// refresh the JWKS keys if the refresh period elapsed and the file was
+// modified; on errors the current keys are retained.
+func (v *JWTVerifier) refresh() {
+	v.mu.Lock()
+	if v.opts.JWKS == "" || time.Since(v.check) < v.opts.Refresh {
+		v.mu.Unlock()
+		return
+	}
+	v.check = time.Now()
+	v.mu.Unlock()
+	_ = v.loadJWKS()
+}
+
+func (v *JWTVerifier) loadJWKS() error {
+	file := filepath.Clean(v.opts.JWKS)
+	info, err := os.Stat(file)
+	if err != nil {
+		return errors.Wrap(err, "JWKS file not available")
+	}
+	v.mu.Lock()
+	changed := !info.ModTime().Equal(v.mod)
+	v.mu.Unlock()
+	if !changed {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return errors.Wrap(err, "failed to read JWKS file")
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.keys, v.mod, v.check = keys, info.ModTime(), time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+// parsePublicKey decodes a PEM-encoded public key or certificate.
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid verification key: not a PEM value")
+	}
+	switch strings.TrimSpace(block.Type) {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid verification key")
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid verification key")
+		}
+		return pub, nil
+	}
+}
@@ -0,0 +1,409 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTVerifier(t *testing.T) {
+	key := newECKey(t)
+	other := newECKey(t)
+	now := time.Now()
+	claims := func(adjust func(c jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":   "user-1",
+			"iss":   "https://auth.example.com",
+			"aud":   []string{"echo-service"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"roles": []string{"admin"},
+			"scope": "echo:read echo:write",
+		}
+		if adjust != nil {
+			adjust(c)
+		}
+		return c
+	}
+	opts := JWTOptions{
+		Keys:     [][]byte{publicPEM(t, &key.PublicKey)},
+		Issuer:   "https://auth.example.com",
+		Audience: []string{"echo-service", "other-service"},
+		Leeway:   time.Minute,
+	}
+	v, err := NewJWTVerifier(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{
+			name:  "valid",
+			token: sign(t, jwt.SigningMethodES256, key, claims(nil)),
+			valid: true,
+		},
+		{
+			name: "expired within leeway",
+			token: sign(t, jwt.SigningMethodES256, key, claims(func(c jwt.MapClaims) {
+				c["exp"] = now.Add(-30 * time.Second).Unix()
+			})),
+			valid: true,
+		},
+		{
+			name: "expired",
+			token: sign(t, jwt.SigningMethodES256, key, claims(func(c jwt.MapClaims) {
+				c["exp"] = now.Add(-time.Hour).Unix()
+			})),
+		},
+		{
+			name: "missing expiration",
+			token: sign(t, jwt.SigningMethodES256, key, claims(func(c jwt.MapClaims) {
+				delete(c, "exp")
+			})),
+		},
+		{
+			name: "not valid yet",
+			token: sign(t, jwt.SigningMethodES256, key, claims(func(c jwt.MapClaims) {
+				c["nbf"] = now.Add(time.Hour).Unix()
+			})),
+		},
+		{
+			name: "wrong issuer",
+			token: sign(t, jwt.SigningMethodES256, key, claims(func(c jwt.MapClaims) {
+				c["iss"] = "https://evil.example.com"
+			})),
+		},
+		{
+			name: "audience not accepted",
+			token: sign(t, jwt.SigningMethodES256, key, claims(func(c jwt.MapClaims) {
+				c["aud"] = []string{"unknown"}
+			})),
+		},
+		{
+			name: "any accepted audience",
+			token: sign(t, jwt.SigningMethodES256, key, claims(func(c jwt.MapClaims) {
+				c["aud"] = "other-service"
+			})),
+			valid: true,
+		},
+		{
+			name:  "unknown key",
+			token: sign(t, jwt.SigningMethodES256, other, claims(nil)),
+		},
+		{
+			name:  "symmetric algorithm",
+			token: sign(t, jwt.SigningMethodHS256, []byte("secret"), claims(nil)),
+		},
+		{
+			name:  "unsigned",
+			token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims(nil)),
+		},
+		{
+			name:  "malformed",
+			token: "not-a-token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := v.Verify(tt.token)
+			if !tt.valid {
+				if err == nil {
+					t.Fatal("expected the token to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if id.Method != MethodJWT || id.Subject != "user-1" {
+				t.Errorf("unexpected identity: %+v", id)
+			}
+			if !slices.Equal(id.Roles, []string{"admin"}) {
+				t.Errorf("unexpected roles: %v", id.Roles)
+			}
+			if !slices.Equal(id.Scopes, []string{"echo:read", "echo:write"}) {
+				t.Errorf("unexpected scopes: %v", id.Scopes)
+			}
+		})
+	}
+}
+
+func TestNewJWTVerifier(t *testing.T) {
+	key := newECKey(t)
+	tests := []struct {
+		name string
+		opts JWTOptions
+		ok   bool
+	}{
+		{
+			name: "static key",
+			opts: JWTOptions{Keys: [][]byte{publicPEM(t, &key.PublicKey)}},
+			ok:   true,
+		},
+		{
+			name: "no keys",
+			opts: JWTOptions{},
+		},
+		{
+			name: "invalid key",
+			opts: JWTOptions{Keys: [][]byte{[]byte("not a key")}},
+		},
+		{
+			name: "unsupported algorithm",
+			opts: JWTOptions{Keys: [][]byte{publicPEM(t, &key.PublicKey)}, Algorithms: []string{"HS256"}},
+		},
+		{
+			name: "missing JWKS file",
+			opts: JWTOptions{JWKS: filepath.Join(t.TempDir(), "jwks.json")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJWTVerifier(tt.opts)
+			if tt.ok && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestJWTVerifierRefresh(t *testing.T) {
+	first, second := newEdKey(t), newEdKey(t)
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, file, time.Now().Add(-time.Hour), map[string]ed25519.PrivateKey{"first": first})
+
+	v, err := NewJWTVerifier(JWTOptions{JWKS: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	tokenFor := func(kid string, key ed25519.PrivateKey) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	steps := []struct {
+		name   string
+		update func()
+		first  bool
+		second bool
+	}{
+		{
+			name:  "initial keys",
+			first: true,
+		},
+		{
+			name: "key added",
+			update: func() {
+				writeJWKS(t, file, time.Now().Add(-30*time.Minute), map[string]ed25519.PrivateKey{
+					"first":  first,
+					"second": second,
+				})
+			},
+			first:  true,
+			second: true,
+		},
+		{
+			name: "key removed",
+			update: func() {
+				writeJWKS(t, file, time.Now().Add(-20*time.Minute), map[string]ed25519.PrivateKey{"second": second})
+			},
+			second: true,
+		},
+		{
+			name: "invalid file retains current keys",
+			update: func() {
+				if err := os.WriteFile(file, []byte("{invalid"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			second: true,
+		},
+	}
+	for _, step := range steps {
+		if step.update != nil {
+			step.update()
+		}
+		for kid, expected := range map[string]bool{"first": step.first, "second": step.second} {
+			key := first
+			if kid == "second" {
+				key = second
+			}
+			_, err := v.Verify(tokenFor(kid, key))
+			if expected && err != nil {
+				t.Errorf("%s: key %s rejected: %s", step.name, kid, err)
+			}
+			if !expected && err == nil {
+				t.Errorf("%s: key %s accepted", step.name, kid)
+			}
+		}
+	}
+
+	t.Run("refresh period", func(t *testing.T) {
+		writeJWKS(t, file, time.Now().Add(-10*time.Minute), map[string]ed25519.PrivateKey{"second": second})
+		v, err := NewJWTVerifier(JWTOptions{JWKS: file, Refresh: time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeJWKS(t, file, time.Now(), map[string]ed25519.PrivateKey{"first": first})
+		if _, err = v.Verify(tokenFor("first", first)); err == nil {
+			t.Error("file reloaded before the refresh period elapsed")
+		}
+	})
+}
+
+func TestParseJWKS(t *testing.T) {
+	ec := newECKey(t)
+	ed := newEdKey(t)
+	ecJWK := map[string]string{
+		"kid": "ec", "kty": "EC", "crv": "P-256",
+		"x": b64(ec.X.Bytes()), "y": b64(ec.Y.Bytes()),
+	}
+	edJWK := map[string]string{"kid": "ed", "kty": "OKP", "crv": "Ed25519", "x": b64(ed.Public().(ed25519.PublicKey))}
+	tests := []struct {
+		name string
+		keys []map[string]string
+		kids []string
+		ok   bool
+	}{
+		{
+			name: "supported keys",
+			keys: []map[string]string{ecJWK, edJWK},
+			kids: []string{"ec", "ed"},
+			ok:   true,
+		},
+		{
+			name: "ignore encryption and symmetric keys",
+			keys: []map[string]string{
+				{"kid": "enc", "kty": "EC", "use": "enc", "crv": "P-256", "x": ecJWK["x"], "y": ecJWK["y"]},
+				{"kid": "hmac", "kty": "oct", "k": "c2VjcmV0"},
+				edJWK,
+			},
+			kids: []string{"ed"},
+			ok:   true,
+		},
+		{
+			name: "generated key IDs",
+			keys: []map[string]string{{"kty": "OKP", "crv": "Ed25519", "x": edJWK["x"]}},
+			kids: []string{"#0"},
+			ok:   true,
+		},
+		{
+			name: "unsupported curve",
+			keys: []map[string]string{{"kid": "x", "kty": "EC", "crv": "P-224", "x": ecJWK["x"], "y": ecJWK["y"]}},
+		},
+		{
+			name: "invalid parameter",
+			keys: []map[string]string{{"kid": "x", "kty": "RSA", "n": "!!", "e": "AQAB"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := json.Marshal(map[string]any{"keys": tt.keys})
+			keys, err := parseJWKS(data)
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			kids := []string{}
+			for kid := range keys {
+				kids = append(kids, kid)
+			}
+			slices.Sort(kids)
+			if !slices.Equal(kids, tt.kids) {
+				t.Errorf("expected keys %v, got %v", tt.kids, kids)
+			}
+		})
+	}
+}
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newEdKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func publicPEM(t *testing.T, pub any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// writeJWKS saves the public keys as a JWKS file with the provided
+// modification time.
+func writeJWKS(t *testing.T, file string, mod time.Time, keys map[string]ed25519.PrivateKey) {
+	t.Helper()
+	list := []map[string]string{}
+	for kid, key := range keys {
+		list = append(list, map[string]string{
+			"kid": kid,
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"use": "sig",
+			"x":   b64(key.Public().(ed25519.PublicKey)),
+		})
+	}
+	data, _ := json.Marshal(map[string]any{"keys": list})
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/bcessa/echo-service/internal/request"
+	"go.bryk.io/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Actions applied to requests not matching any policy rule.
+const (
+	// PolicyAllow accepts requests not matching any rule.
+	PolicyAllow = "allow"
+
+	// PolicyDeny rejects requests not matching any rule.
+	PolicyDeny = "deny"
+)
+
+// Error returned to unauthorized callers; details are intentionally
+// omitted.
+var errPermissionDenied = errors.New("permission denied")
+
+// Rule grants access to a set of targets. A caller satisfies the rule if
+// it has at least one of the roles, all the scopes and all the claims
+// required. A rule without requirements grants access to every caller,
+// including unauthenticated ones.
+// nolint: lll
+type Rule struct {
+	Name    string            `json:"name" yaml:"name" mapstructure:"name" desc:"descriptive name for the rule; used when reporting decisions"`
+	Targets []string          `json:"targets" yaml:"targets" mapstructure:"targets" desc:"gRPC methods or HTTP routes covered by the rule; for example: /sample.v1.ServiceAPI/Echo or 'POST /v1/echo'"`
+	Roles   []string          `json:"roles" yaml:"roles" mapstructure:"roles" desc:"roles accepted; the caller must have at least one of them"`
+	Scopes  []string          `json:"scopes" yaml:"scopes" mapstructure:"scopes" desc:"scopes required; the caller must have all of them"`
+	Claims  map[string]string `json:"claims" yaml:"claims" mapstructure:"claims" desc:"token claims required and their expected values"`
+}
+
+// Policy restricts the callers allowed to access specific targets. Rules
+// are evaluated in order and the first one matching the target is used.
+type Policy struct {
+	// Rules evaluated for each request.
+	Rules []Rule
+
+	// Action applied to targets not covered by any rule: `PolicyAllow`
+	// or `PolicyDeny`; defaults to `PolicyDeny`.
+	Default string
+
+	// Report decisions without rejecting any request.
+	DryRun bool
+
+	// Report is called with every decision, if provided.
+	Report func(Decision)
+}
+
+// Decision describes the result of evaluating a policy for a request.
+type Decision struct {
+	// gRPC method or HTTP route evaluated.
+	Target string `json:"target"`
+
+	// Whether the caller is allowed to access the target.
+	Allowed bool `json:"allowed"`
+
+	// Rule applied; empty if the default action was used.
+	Rule string `json:"rule,omitempty"`
+
+	// Subject of the caller identity, if any.
+	Subject string `json:"subject,omitempty"`
+
+	// Whether the decision was enforced.
+	DryRun bool `json:"dry_run"`
+}
+
+// Validate the policy rules.
+func (p *Policy) Validate() error {
+	if p.Default != "" && p.Default != PolicyAllow && p.Default != PolicyDeny {
+		return errors.Errorf("invalid default action: %s", p.Default)
+	}
+	for i, r := range p.Rules {
+		if len(r.Targets) == 0 {
+			return errors.Errorf("rule %s: targets are required", ruleName(r, i))
+		}
+		for _, target := range r.Targets {
+			if err := request.Validate(target); err != nil {
+				return errors.Wrapf(err, "rule %s", ruleName(r, i))
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate the policy for a request to `target` made by the provided
+// identities; for HTTP requests the target includes the method, like
+// "GET /v1/ping".
+func (p *Policy) Evaluate(target string, ids []*Identity) Decision {
+	d := Decision{Target: target, DryRun: p.DryRun}
+	if len(ids) > 0 {
+		d.Subject = ids[len(ids)-1].Subject
+	}
+	for i, r := range p.Rules {
+		if !slices.ContainsFunc(r.Targets, func(t string) bool { return request.Match(t, target) }) {
+			continue
+		}
+		d.Rule = ruleName(r, i)
+		d.Allowed = r.satisfied(ids)
+		return d
+	}
+	d.Allowed = p.Default == PolicyAllow
+	return d
+}
+
+// UnaryServerInterceptor rejects requests not allowed by the policy; it
+// must be installed after the authentication interceptors.
+func (p *Policy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !p.allow(ctx, info.FullMethod) {
+			return nil, status.Error(codes.PermissionDenied, errPermissionDenied.Error())
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects streams not allowed by the policy; it
+// must be installed after the authentication interceptors.
+func (p *Policy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !p.allow(ss.Context(), info.FullMethod) {
+			return status.Error(codes.PermissionDenied, errPermissionDenied.Error())
+		}
+		return handler(srv, ss)
+	}
+}
+
+// Handler rejects HTTP requests not allowed by the policy; it must be
+// installed after the authentication middleware. CORS preflight requests
+// are always allowed.
+func (p *Policy) Handler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodOptions && !p.allow(r.Context(), r.Method+" "+r.URL.Path) {
+				http.Error(w, errPermissionDenied.Error(), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allow evaluates and reports the policy decision for the request; on
+// dry-run mode every request is allowed.
+func (p *Policy) allow(ctx context.Context, target string) bool {
+	d := p.Evaluate(target, All(ctx))
+	if p.Report != nil {
+		p.Report(d)
+	}
+	return d.Allowed || p.DryRun
+}
+
+// satisfied returns `true` if any of the identities meets the rule
+// requirements.
+func (r Rule) satisfied(ids []*Identity) bool {
+	if len(r.Roles) == 0 && len(r.Scopes) == 0 && len(r.Claims) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(ids, func(id *Identity) bool {
+		if len(r.Roles) > 0 && !slices.ContainsFunc(r.Roles, func(role string) bool {
+			return slices.Contains(id.Roles, role)
+		}) {
+			return false
+		}
+		for _, scope := range r.Scopes {
+			if !slices.Contains(id.Scopes, scope) {
+				return false
+			}
+		}
+		for name, value := range r.Claims {
+			if !slices.Contains(claimValues(id.Claims[name]), value) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// claimValues returns the string representation of a claim, or each of
+// its elements for list claims.
+func claimValues(claim any) []string {
+	switch v := claim.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		list := make([]string, len(v))
+		for i, el := range v {
+			list[i] = fmt.Sprint(el)
+		}
+		return list
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+func ruleName(r Rule, i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
@@ -0,0 +1,174 @@
+package auth
+
+import "testing"
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy := &Policy{
+		Rules: []Rule{
+			{
+				Name: "public",
+				Targets: []string{
+					"/sample.v1.ServiceAPI/Ping",
+					"GET /v1/ping",
+				},
+			},
+			{
+				Name:    "echo",
+				Targets: []string{"/sample.v1.ServiceAPI/Echo", "POST /v1/echo"},
+				Scopes:  []string{"echo:write"},
+			},
+			{
+				Targets: []string{"/sample.v1.ServiceAPI/*", "/v1/*"},
+				Roles:   []string{"admin"},
+			},
+		},
+	}
+	writer := &Identity{Subject: "writer", Scopes: []string{"echo:read", "echo:write"}}
+	admin := &Identity{Subject: "admin", Roles: []string{"admin"}}
+	tests := []struct {
+		name    string
+		target  string
+		ids     []*Identity
+		dflt    string
+		allowed bool
+		rule    string
+		subject string
+	}{
+		{
+			name:    "public method",
+			target:  "/sample.v1.ServiceAPI/Ping",
+			allowed: true,
+			rule:    "public",
+		},
+		{
+			name:    "public route",
+			target:  "GET /v1/ping",
+			allowed: true,
+			rule:    "public",
+		},
+		{
+			name:    "route with a different method",
+			target:  "DELETE /v1/ping",
+			ids:     []*Identity{writer},
+			allowed: false,
+			rule:    "#3",
+			subject: "writer",
+		},
+		{
+			name:    "first matching rule is used",
+			target:  "/sample.v1.ServiceAPI/Echo",
+			ids:     []*Identity{admin},
+			allowed: false,
+			rule:    "echo",
+			subject: "admin",
+		},
+		{
+			name:    "any identity satisfying the rule",
+			target:  "POST /v1/echo",
+			ids:     []*Identity{admin, writer},
+			allowed: true,
+			rule:    "echo",
+			subject: "writer",
+		},
+		{
+			name:    "pattern",
+			target:  "/sample.v1.ServiceAPI/Faulty",
+			ids:     []*Identity{admin},
+			allowed: true,
+			rule:    "#3",
+			subject: "admin",
+		},
+		{
+			name:    "unauthenticated caller",
+			target:  "/sample.v1.ServiceAPI/Faulty",
+			allowed: false,
+			rule:    "#3",
+		},
+		{
+			name:    "default action",
+			target:  "/grpc.health.v1.Health/Check",
+			allowed: false,
+		},
+		{
+			name:    "default allow",
+			target:  "/grpc.health.v1.Health/Check",
+			dflt:    PolicyAllow,
+			allowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy.Default = tt.dflt
+			d := policy.Evaluate(tt.target, tt.ids)
+			if d.Allowed != tt.allowed {
+				t.Errorf("expected allowed=%v", tt.allowed)
+			}
+			if d.Rule != tt.rule {
+				t.Errorf("expected rule %q, got %q", tt.rule, d.Rule)
+			}
+			if d.Subject != tt.subject {
+				t.Errorf("expected subject %q, got %q", tt.subject, d.Subject)
+			}
+			if d.Target != tt.target {
+				t.Errorf("expected target %q, got %q", tt.target, d.Target)
+			}
+		})
+	}
+}
+
+func TestRuleSatisfied(t *testing.T) {
+	id := &Identity{
+		Subject: "user",
+		Roles:   []string{"editor", "viewer"},
+		Scopes:  []string{"echo:read", "echo:write"},
+		Claims: map[string]any{
+			"tenant": "acme",
+			"groups": []any{"dev", "ops"},
+			"level":  float64(3),
+		},
+	}
+	tests := []struct {
+		name string
+		rule Rule
+		ids  []*Identity
+		ok   bool
+	}{
+		{name: "no requirements", ok: true},
+		{name: "no requirements without identity", ids: []*Identity{}, ok: true},
+		{name: "any role", rule: Rule{Roles: []string{"admin", "viewer"}}, ok: true},
+		{name: "missing role", rule: Rule{Roles: []string{"admin"}}},
+		{name: "all scopes", rule: Rule{Scopes: []string{"echo:read", "echo:write"}}, ok: true},
+		{name: "missing scope", rule: Rule{Scopes: []string{"echo:read", "echo:admin"}}},
+		{name: "string claim", rule: Rule{Claims: map[string]string{"tenant": "acme"}}, ok: true},
+		{name: "list claim", rule: Rule{Claims: map[string]string{"groups": "ops"}}, ok: true},
+		{name: "numeric claim", rule: Rule{Claims: map[string]string{"level": "3"}}, ok: true},
+		{name: "claim mismatch", rule: Rule{Claims: map[string]string{"tenant": "other"}}},
+		{name: "missing claim", rule: Rule{Claims: map[string]string{"region": "us"}}},
+		{
+			name: "all requirements",
+			rule: Rule{
+				Roles:  []string{"editor"},
+				Scopes: []string{"echo:write"},
+				Claims: map[string]string{"tenant": "acme"},
+			},
+			ok: true,
+		},
+		{
+			name: "requirements split across identities",
+			rule: Rule{Roles: []string{"admin"}, Scopes: []string{"echo:write"}},
+			ids:  []*Identity{{Roles: []string{"admin"}}, {Scopes: []string{"echo:write"}}},
+		},
+		{name: "unauthenticated caller", rule: Rule{Roles: []string{"viewer"}}, ids: []*Identity{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := tt.ids
+			if ids == nil {
+				ids = []*Identity{id}
+			}
+			if ok := tt.rule.satisfied(ids); ok != tt.ok {
+				t.Errorf("expected %v, got %v", tt.ok, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/bcessa/echo-service/internal/request"
+	"go.bryk.io/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Errors returned to unauthenticated callers; details are intentionally
+// omitted.
+var (
+	errMissingCredentials = errors.New("missing credentials")
+	errInvalidCredentials = errors.New("invalid credentials")
+)
+
+// headerFunc returns the value of a request header, or gRPC metadata
+// entry, by its lowercase name.
+type headerFunc = func(name string) string
+
+// Verifier validates the credentials presented by callers.
+type Verifier interface {
+	// Credential returns the credential included on the request, if any.
+	Credential(header headerFunc) (string, bool)
+
+	// Verify the credential and return the identity of the caller.
+	Verify(credential string) (*Identity, error)
+}
+
+// Authenticator pairs a verifier with the requests exempted from it.
+// Exemptions can be method names, like "Ping"; patterns matching the full
+// gRPC method name, like "/sample.v1.ServiceAPI/*"; or HTTP routes, like
+// "GET /v1/ping". HTTP routes without a method match any method.
+type Authenticator struct {
+	Verifier Verifier
+	Exempt   []string
+}
+
+// UnaryServerInterceptor requires every request to be authenticated by any
+// of the provided authenticators not exempting it; requests exempted by
+// all of them are accepted without credentials.
+func UnaryServerInterceptor(list ...Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticateRPC(ctx, info.FullMethod, list)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor requires every stream to be authenticated by any
+// of the provided authenticators not exempting it; streams exempted by all
+// of them are accepted without credentials.
+func StreamServerInterceptor(list ...Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateRPC(ss.Context(), info.FullMethod, list)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// Handler requires every HTTP request to be authenticated by any of the
+// provided authenticators not exempting it; requests exempted by all of
+// them are accepted without credentials. Exemptions are matched against
+// the request method and path, like "GET /v1/ping". CORS preflight
+// requests are always allowed.
+func Handler(list ...Authenticator) func(http.Handler) http.Handler {
+	challenge := ""
+	for _, a := range list {
+		if _, ok := a.Verifier.(*JWTVerifier); ok {
+			challenge = "Bearer"
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// CORS preflight requests don't include credentials
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, err := authenticate(r.Context(), r.Method+" "+r.URL.Path, r.Header.Get, list)
+			if err != nil {
+				if challenge != "" {
+					w.Header().Set("WWW-Authenticate", challenge)
+				}
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticateRPC verifies the credentials included on the request
+// metadata and returns a context including the caller identity.
+func authenticateRPC(ctx context.Context, method string, list []Authenticator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := func(name string) string {
+		if values := md.Get(name); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	ctx, err := authenticate(ctx, method, header, list)
+	if err != nil {
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return ctx, nil
+}
+
+// authenticate the request for `target`; credentials are retrieved using
+// the `get` function. Only the authenticators not exempting the target
+// are used.
+func authenticate(ctx context.Context, target string, get headerFunc, list []Authenticator) (context.Context, error) {
+	list = slices.DeleteFunc(slices.Clone(list), func(a Authenticator) bool { return isExempt(target, a.Exempt) })
+	if len(list) == 0 {
+		return ctx, nil
+	}
+	var found []*Identity
+	for _, a := range list {
+		cred, ok := a.Verifier.Credential(get)
+		if !ok {
+			continue
+		}
+		id, err := a.Verifier.Verify(cred)
+		if err != nil {
+			return ctx, errInvalidCredentials
+		}
+		found = append(found, id)
+	}
+	if len(found) == 0 {
+		return ctx, errMissingCredentials
+	}
+	for _, id := range found {
+		ctx = WithIdentity(ctx, id)
+	}
+	return ctx, nil
+}
+
+// bearerToken extracts the token from an "Authorization" header value.
+func bearerToken(value string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// isExempt returns `true` if `target` matches any of the exemptions.
+func isExempt(target string, exempt []string) bool {
+	return slices.ContainsFunc(exempt, func(pattern string) bool { return request.Match(pattern, target) })
+}
+
+// wrappedStream allows adjusting the context of a server stream.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the adjusted stream context.
+func (ws *wrappedStream) Context() context.Context {
+	return ws.ctx
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.bryk.io/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// verifier accepting a single credential on a specific header.
+type staticVerifier struct {
+	header string
+	secret string
+}
+
+func (v staticVerifier) Credential(header headerFunc) (string, bool) {
+	cred := header(v.header)
+	return cred, cred != ""
+}
+
+func (v staticVerifier) Verify(credential string) (*Identity, error) {
+	if credential != v.secret {
+		return nil, errors.New("invalid credential")
+	}
+	return &Identity{Subject: v.header}, nil
+}
+
+func TestHandler(t *testing.T) {
+	token := Authenticator{
+		Verifier: staticVerifier{header: "x-token", secret: "token"},
+		Exempt:   []string{"GET /v1/ping", "GET /v1/ready"},
+	}
+	key := Authenticator{
+		Verifier: staticVerifier{header: "x-api-key", secret: "key"},
+		Exempt:   []string{"GET /v1/ping", "/v1/status/*"},
+	}
+	tests := []struct {
+		name    string
+		list    []Authenticator
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+		subject string
+	}{
+		{
+			name:   "exempted by every authenticator",
+			list:   []Authenticator{token, key},
+			method: http.MethodGet,
+			path:   "/v1/ping",
+			status: http.StatusOK,
+		},
+		{
+			name:   "exempted by a single authenticator",
+			list:   []Authenticator{token, key},
+			method: http.MethodGet,
+			path:   "/v1/ready",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:    "credentials for the authenticator not exempting the route",
+			list:    []Authenticator{token, key},
+			method:  http.MethodGet,
+			path:    "/v1/ready",
+			headers: map[string]string{"x-api-key": "key"},
+			status:  http.StatusOK,
+			subject: "x-api-key",
+		},
+		{
+			name:    "credentials for the authenticator exempting the route are ignored",
+			list:    []Authenticator{token, key},
+			method:  http.MethodGet,
+			path:    "/v1/ready",
+			headers: map[string]string{"x-token": "token"},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:   "route exempted for a different method",
+			list:   []Authenticator{token},
+			method: http.MethodPost,
+			path:   "/v1/ping",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "route without method matches any method",
+			list:   []Authenticator{key},
+			method: http.MethodPost,
+			path:   "/v1/status/db",
+			status: http.StatusOK,
+		},
+		{
+			name:   "method names don't match HTTP routes",
+			list:   []Authenticator{{Verifier: token.Verifier, Exempt: []string{"Ping"}}},
+			method: http.MethodGet,
+			path:   "/v1/ping",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:    "any authenticator succeeding",
+			list:    []Authenticator{token, key},
+			method:  http.MethodPost,
+			path:    "/v1/echo",
+			headers: map[string]string{"x-token": "token"},
+			status:  http.StatusOK,
+			subject: "x-token",
+		},
+		{
+			name:    "invalid credentials",
+			list:    []Authenticator{token, key},
+			method:  http.MethodPost,
+			path:    "/v1/echo",
+			headers: map[string]string{"x-token": "token", "x-api-key": "invalid"},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:   "preflight requests",
+			list:   []Authenticator{token},
+			method: http.MethodOptions,
+			path:   "/v1/echo",
+			status: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject := ""
+			h := Handler(tt.list...)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				if id, ok := FromContext(r.Context()); ok {
+					subject = id.Subject
+				}
+			}))
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if subject != tt.subject {
+				t.Errorf("expected subject %q, got %q", tt.subject, subject)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	token := Authenticator{
+		Verifier: staticVerifier{header: "x-token", secret: "token"},
+		Exempt:   []string{"Ping", "Ready"},
+	}
+	key := Authenticator{
+		Verifier: staticVerifier{header: "x-api-key", secret: "key"},
+		Exempt:   []string{"/sample.v1.ServiceAPI/Ping", "GET /v1/ready"},
+	}
+	tests := []struct {
+		name   string
+		method string
+		md     metadata.MD
+		code   codes.Code
+	}{
+		{
+			name:   "exempted by every authenticator",
+			method: "/sample.v1.ServiceAPI/Ping",
+			code:   codes.OK,
+		},
+		{
+			name:   "HTTP routes don't match gRPC methods",
+			method: "/sample.v1.ServiceAPI/Ready",
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "valid credentials",
+			method: "/sample.v1.ServiceAPI/Echo",
+			md:     metadata.Pairs("x-api-key", "key"),
+			code:   codes.OK,
+		},
+		{
+			name:   "missing credentials",
+			method: "/sample.v1.ServiceAPI/Echo",
+			code:   codes.Unauthenticated,
+		},
+	}
+	interceptor := UnaryServerInterceptor(token, key)
+	handler := func(_ context.Context, _ any) (any, error) { return nil, nil }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.code {
+				t.Errorf("expected code %s, got %s", tt.code, code)
+			}
+		})
+	}
+}
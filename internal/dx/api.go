@@ -1,6 +1,8 @@
 package dx
 
 import (
+	"reflect"
+	"slices"
 	"sync"
 
 	"github.com/spf13/viper"
@@ -13,14 +15,23 @@ import (
 type Registry struct {
 	name    string
 	modules map[string]Module
-	mu      sync.Mutex
+	order   []string // registration order, used to break ties when sorting
+	started []Runnable
+	cache   map[string]*resource // resources provided during the current load cycle
+	mu      sync.Mutex           // guards the registry state
+	run     sync.Mutex           // serializes load, start and stop operations
 }
 
+// ErrNotProvided is returned by `Resolve` when no module in the registry
+// provides a resource of the requested type.
+var ErrNotProvided = errors.New("no module provides the requested resource")
+
 // NewRegistry returns a new module registry.
 func NewRegistry(name string, mods ...Module) *Registry {
 	r := &Registry{
 		name:    name,
 		modules: make(map[string]Module),
+		cache:   make(map[string]*resource),
 	}
 	for _, mod := range mods {
 		r.Add(mod)
@@ -29,24 +40,148 @@ func NewRegistry(name string, mods ...Module) *Registry {
 }
 
 // Load configuration options managed by the provided Viper instance.
+// Modules are loaded in dependency order. Settings still using a previous
+// location are loaded from it, unless also set on their current location.
+// Every module is loaded even if others fail; all problems found are
+// returned as a `*ValidationError`.
 func (r *Registry) Load(v *viper.Viper) error {
+	r.run.Lock()
+	defer r.run.Unlock()
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	for name, mod := range r.modules {
+	mods, err := r.sorted()
+	r.invalidate()
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	file := v.ConfigFileUsed()
+	v, _, _ = relocate(v, moves(mods))
+	// modules sharing settings report the same problems
+	issues := []Issue{}
+	for _, mod := range mods {
 		if err := mod.Load(v); err != nil {
-			return errors.Wrapf(err, "failed loading module %s", name)
+			for _, issue := range loadIssues(mod.Name(), err) {
+				if !slices.Contains(issues, issue) {
+					issues = append(issues, issue)
+				}
+			}
+		}
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	if file != "" {
+		locate(file, issues)
+	}
+	return &ValidationError{Issues: issues}
+}
+
+// Start all runnable modules in dependency order. Consumer modules are
+// handed the registry right before being started, so they can resolve
+// the resources provided by their dependencies. If a module fails to
+// start, any module already started is stopped before returning the error.
+// Modules already started must be stopped before starting them again.
+func (r *Registry) Start() error {
+	r.run.Lock()
+	defer r.run.Unlock()
+	r.mu.Lock()
+	mods, err := r.sorted()
+	running := len(r.started) > 0
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if running {
+		return errors.New("modules already started")
+	}
+	for _, mod := range mods {
+		if cm, ok := mod.(Consumer); ok {
+			if err := cm.Consume(r); err != nil {
+				_ = r.stop()
+				return errors.Wrapf(err, "failed resolving dependencies of module %s", mod.Name())
+			}
+		}
+		rm, ok := mod.(Runnable)
+		if !ok {
+			continue
 		}
+		if err := rm.Start(); err != nil {
+			_ = r.stop()
+			return errors.Wrapf(err, "failed starting module %s", mod.Name())
+		}
+		r.mu.Lock()
+		r.started = append(r.started, rm)
+		r.mu.Unlock()
 	}
 	return nil
 }
 
+// Stop all previously started modules in reverse dependency order. All
+// modules are stopped even if some of them fail; the first error
+// encountered is returned.
+func (r *Registry) Stop() error {
+	r.run.Lock()
+	defer r.run.Unlock()
+	return r.stop()
+}
+
+// Order returns the name of all registered modules sorted in dependency
+// order, i.e., every module is listed after all of its dependencies.
+func (r *Registry) Order() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mods, err := r.sorted()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]string, len(mods))
+	for i, mod := range mods {
+		list[i] = mod.Name()
+	}
+	return list, nil
+}
+
 // Add (or) replace a module to the registry.
 func (r *Registry) Add(mod Module) {
 	r.mu.Lock()
+	if _, ok := r.modules[mod.Name()]; !ok {
+		r.order = append(r.order, mod.Name())
+	}
 	r.modules[mod.Name()] = mod
 	r.mu.Unlock()
 }
 
+// Resolve a resource of type `T` from the provider modules available in
+// the registry. Providers are consulted in dependency order and the first
+// resource of the requested type is returned. Provided resources are cached
+// and reused until the registry is loaded again or stopped. Providers can
+// resolve the resources provided by their dependencies. If no module
+// provides a resource of type `T` the error returned wraps `ErrNotProvided`.
+//
+//	settings, err := dx.Resolve[*tls.Settings](reg)
+func Resolve[T any](r *Registry) (res T, err error) {
+	r.mu.Lock()
+	mods, err := r.sorted()
+	r.mu.Unlock()
+	if err != nil {
+		return res, err
+	}
+	for _, mod := range mods {
+		pm, ok := mod.(Provider)
+		if !ok {
+			continue
+		}
+		rs, err := r.provide(pm)
+		if err != nil {
+			return res, err
+		}
+		if v, ok := rs.(T); ok {
+			return v, nil
+		}
+	}
+	return res, errors.Wrap(ErrNotProvided, reflect.TypeFor[T]().String())
+}
+
 // Get a module from the registry; if no module with the given name
 // exists this method returns `nil`.
 func (r *Registry) Get(name string) Module {
@@ -59,6 +194,76 @@ func (r *Registry) Get(name string) Module {
 	return mod
 }
 
+// stop started modules in reverse order; must be called with the
+// run lock held.
+func (r *Registry) stop() (err error) {
+	defer r.reset()
+	r.mu.Lock()
+	started := r.started
+	r.started = nil
+	r.mu.Unlock()
+	for i := len(started) - 1; i >= 0; i-- {
+		mod := started[i]
+		if sErr := mod.Stop(); sErr != nil && err == nil {
+			err = errors.Wrapf(sErr, "failed stopping module %s", mod.Name())
+		}
+	}
+	return err
+}
+
+// reset discards all cached resources.
+func (r *Registry) reset() {
+	r.mu.Lock()
+	r.invalidate()
+	r.mu.Unlock()
+}
+
+// resource handed over by a provider module.
+type resource struct {
+	value any
+	mu    sync.Mutex // held while the module provides the resource
+}
+
+// provide returns the resource handed over by the provider module,
+// using a cached value if available. The registry lock is not held
+// while the module provides the resource; concurrent calls for the same
+// module wait for the first one to complete.
+func (r *Registry) provide(mod Provider) (any, error) {
+	r.mu.Lock()
+	rs, ok := r.cache[mod.Name()]
+	if !ok {
+		rs = new(resource)
+		r.cache[mod.Name()] = rs
+	}
+	r.mu.Unlock()
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.value != nil {
+		return rs.value, nil
+	}
+	res, err := mod.Provide()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed resolving module %s", mod.Name())
+	}
+
+	// modules may be able to provide a resource later on
+	rs.value = res
+	return res, nil
+}
+
+// invalidate all cached resources; must be called with the registry
+// lock held.
+func (r *Registry) invalidate() {
+	clear(r.cache)
+}
+
+// sorted returns registered modules in dependency order; must be called
+// with the registry lock held.
+func (r *Registry) sorted() ([]Module, error) {
+	return sortModules(r.order, r.modules)
+}
+
 // Module implementations provide a basic responsibility encapsulation and
 // reutilization mechanism for higher-level applications. The most basic
 // form of modules simply manage internal state; their interaction with
@@ -80,6 +285,76 @@ type Module interface {
 	Customize(target any) error
 }
 
+// Dependent modules require other modules to be loaded and started
+// before them.
+type Dependent interface {
+	// "inherit" all the base functions of a simple module
+	Module
+
+	// Depends returns the identifiers of all modules required.
+	Depends() []string
+}
+
+// Configurable modules expose the structure used to decode their
+// configuration; this allows tools to document and validate it.
+type Configurable interface {
+	// "inherit" all the base functions of a simple module
+	Module
+
+	// Defaults returns a new instance of the structure used by the module
+	// to decode its configuration, populated with default values. Fields
+	// are described using the `mapstructure` and `desc` struct tags.
+	Defaults() any
+}
+
+// Relocator modules moved some of their settings to a new location. The
+// previous location is still supported, but reported as deprecated.
+type Relocator interface {
+	// "inherit" all the base functions of a simple module
+	Module
+
+	// Moved returns the current location of the relocated settings, indexed
+	// by their previous location; for example: {"rpc.tls": "tls"}.
+	Moved() map[string]string
+}
+
+// Validator modules can check the consistency of their settings once
+// loaded.
+type Validator interface {
+	// "inherit" all the base functions of a simple module
+	Module
+
+	// Validate returns all the problems found on the module's current
+	// settings. Issue keys are relative to the configuration root.
+	Validate() []Issue
+}
+
+// Runnable modules manage components with a lifecycle of their own, for
+// example a network server. The registry starts runnable modules in
+// dependency order and stops them in reverse order.
+type Runnable interface {
+	// "inherit" all the base functions of a simple module
+	Module
+
+	// Start the components managed by the module; must not block.
+	Start() error
+
+	// Stop the components managed by the module and free any used
+	// resources.
+	Stop() error
+}
+
+// Consumer modules use resources provided by other modules, usually their
+// dependencies.
+type Consumer interface {
+	// "inherit" all the base functions of a simple module
+	Module
+
+	// Consume is called by the registry before the module is started;
+	// use `Resolve` to obtain the required resources from the registry.
+	Consume(r *Registry) error
+}
+
 // Provider modules can also initialize specialized components and hand them
 // over for consumption.
 type Provider interface {
@@ -88,6 +363,8 @@ type Provider interface {
 
 	// Provide is responsible of returning a reference that was properly
 	// initialized by the module implementation. The caller is responsible
-	// for any further management tasks related to the resource.
+	// for any further management tasks related to the resource. Modules
+	// not able to provide a resource in their current state should return
+	// a `nil` resource.
 	Provide() (resource any, err error)
 }
@@ -0,0 +1,399 @@
+package dx
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	"go.bryk.io/pkg/errors"
+)
+
+// module used to exercise the registry; every lifecycle operation is
+// recorded on the shared `events` list.
+type fakeModule struct {
+	name     string
+	deps     []string
+	resource any
+	issues   []Issue
+	failOn   string // lifecycle operation that must fail
+	events   *[]string
+	consume  func(r *Registry) error
+	provide  func() (any, error) // used instead of `resource` if set
+	moved    map[string]string
+	Settings *fakeSettings `mapstructure:"fake"`
+	Rules    []fakeRule    `mapstructure:"rules"`
+}
+
+type fakeSettings struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Name    string `mapstructure:"name"`
+	Port    int    `mapstructure:"port"`
+	Token   string `mapstructure:"token" secret:"true"`
+}
+
+type fakeRule struct {
+	Name string `mapstructure:"name"`
+}
+
+func (m *fakeModule) Name() string { return m.name }
+
+func (m *fakeModule) Depends() []string { return m.deps }
+
+func (m *fakeModule) Load(v *viper.Viper) error {
+	if err := m.record("load"); err != nil {
+		return err
+	}
+	return v.Unmarshal(m)
+}
+
+func (m *fakeModule) Flags(_ string) []cli.Param { return nil }
+
+func (m *fakeModule) Customize(_ any) error { return nil }
+
+func (m *fakeModule) Defaults() any { return &fakeModule{Settings: new(fakeSettings)} }
+
+func (m *fakeModule) Moved() map[string]string { return m.moved }
+
+func (m *fakeModule) Validate() []Issue { return m.issues }
+
+func (m *fakeModule) Start() error { return m.record("start") }
+
+func (m *fakeModule) Stop() error { return m.record("stop") }
+
+func (m *fakeModule) Provide() (any, error) {
+	if err := m.record("provide"); err != nil {
+		return nil, err
+	}
+	if m.provide != nil {
+		return m.provide()
+	}
+	return m.resource, nil
+}
+
+func (m *fakeModule) Consume(r *Registry) error {
+	if m.consume == nil {
+		return nil
+	}
+	return m.consume(r)
+}
+
+func (m *fakeModule) record(op string) error {
+	if m.events != nil {
+		*m.events = append(*m.events, op+":"+m.name)
+	}
+	if m.failOn == op {
+		return errors.Errorf("%s failed", op)
+	}
+	return nil
+}
+
+func TestRegistryLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	config := "fake:\n  enabled: maybe\n  port: abc\n"
+	if err := os.WriteFile(file, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	events := []string{}
+	reg := NewRegistry("test",
+		&fakeModule{name: "a", failOn: "load", events: &events},
+		&fakeModule{name: "b", deps: []string{"a"}, events: &events},
+		&fakeModule{name: "fake", events: &events},
+	)
+
+	// every module is loaded and all problems are reported
+	ve := new(ValidationError)
+	if err := reg.Load(v); !errors.As(err, &ve) {
+		t.Fatalf("expected a validation error, got: %v", err)
+	}
+	if !slices.Contains(events, "load:b") || !slices.Contains(events, "load:fake") {
+		t.Errorf("modules not loaded after a failure: %v", events)
+	}
+	keys := []string{}
+	for _, issue := range ve.Issues {
+		keys = append(keys, issue.Key)
+		if strings.HasPrefix(issue.Key, "fake.") && (issue.File != file || issue.Line == 0) {
+			t.Errorf("missing position for issue: %s", issue)
+		}
+	}
+	slices.Sort(keys)
+	expected := []string{"a", "fake.enabled", "fake.port"}
+	if !slices.Equal(keys, expected) {
+		t.Errorf("expected issues for %v, got %v", expected, keys)
+	}
+}
+
+func TestRegistryStart(t *testing.T) {
+	tests := []struct {
+		name   string
+		mods   []*fakeModule
+		events []string
+		err    string
+	}{
+		{
+			name:   "dependency order",
+			mods:   []*fakeModule{{name: "b", deps: []string{"a"}}, {name: "a"}},
+			events: []string{"start:a", "start:b"},
+		},
+		{
+			name: "stop started modules on failure",
+			mods: []*fakeModule{
+				{name: "a"},
+				{name: "b", deps: []string{"a"}},
+				{name: "c", deps: []string{"b"}, failOn: "start"},
+				{name: "d", deps: []string{"c"}},
+			},
+			events: []string{"start:a", "start:b", "start:c", "stop:b", "stop:a"},
+			err:    "failed starting module c",
+		},
+		{
+			name: "stop started modules when consuming fails",
+			mods: []*fakeModule{
+				{name: "a"},
+				{name: "b", deps: []string{"a"}, consume: func(_ *Registry) error {
+					return errors.New("missing resource")
+				}},
+			},
+			events: []string{"start:a", "stop:a"},
+			err:    "failed resolving dependencies of module b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := []string{}
+			reg := NewRegistry("test")
+			for _, mod := range tt.mods {
+				mod.events = &events
+				reg.Add(mod)
+			}
+			err := reg.Start()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got: %v", tt.err, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !slices.Equal(events, tt.events) {
+				t.Errorf("expected events %v, got %v", tt.events, events)
+			}
+
+			// nothing else is stopped after a failed start
+			events = events[:0]
+			if err = reg.Stop(); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tt.err != "" && len(events) > 0 {
+				t.Errorf("modules stopped twice: %v", events)
+			}
+		})
+	}
+}
+
+func TestRegistryStop(t *testing.T) {
+	events := []string{}
+	reg := NewRegistry("test",
+		&fakeModule{name: "a", events: &events},
+		&fakeModule{name: "b", deps: []string{"a"}, failOn: "stop", events: &events},
+		&fakeModule{name: "c", deps: []string{"b"}, events: &events},
+	)
+	if err := reg.Start(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err := reg.Stop()
+	if err == nil || !strings.Contains(err.Error(), "failed stopping module b") {
+		t.Fatalf("expected stop error, got: %v", err)
+	}
+	expected := []string{"start:a", "start:b", "start:c", "stop:c", "stop:b", "stop:a"}
+	if !slices.Equal(events, expected) {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+}
+
+func TestRegistryRestart(t *testing.T) {
+	events := []string{}
+	reg := NewRegistry("test", &fakeModule{name: "a", events: &events})
+	if err := reg.Start(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := reg.Start(); err == nil {
+		t.Fatal("expected an error when starting modules already started")
+	}
+	if err := reg.Stop(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := reg.Start(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := reg.Stop(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []string{"start:a", "stop:a", "start:a", "stop:a"}
+	if !slices.Equal(events, expected) {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	type settings struct{ value string }
+	tests := []struct {
+		name     string
+		mods     []*fakeModule
+		expected string
+		err      error
+	}{
+		{
+			name:     "single provider",
+			mods:     []*fakeModule{{name: "a", resource: &settings{value: "a"}}},
+			expected: "a",
+		},
+		{
+			name: "first provider in dependency order",
+			mods: []*fakeModule{
+				{name: "b", deps: []string{"a"}, resource: &settings{value: "b"}},
+				{name: "a", resource: &settings{value: "a"}},
+			},
+			expected: "a",
+		},
+		{
+			name: "skip other resource types",
+			mods: []*fakeModule{
+				{name: "a", resource: "text"},
+				{name: "b", resource: &settings{value: "b"}},
+			},
+			expected: "b",
+		},
+		{
+			name: "skip nil resources",
+			mods: []*fakeModule{
+				{name: "a"},
+				{name: "b", resource: &settings{value: "b"}},
+			},
+			expected: "b",
+		},
+		{
+			name: "not provided",
+			mods: []*fakeModule{{name: "a", resource: "text"}},
+			err:  ErrNotProvided,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := NewRegistry("test")
+			for _, mod := range tt.mods {
+				reg.Add(mod)
+			}
+			res, err := Resolve[*settings](reg)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %v, got: %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if res.value != tt.expected {
+				t.Errorf("expected resource %q, got %q", tt.expected, res.value)
+			}
+		})
+	}
+
+	t.Run("provider error", func(t *testing.T) {
+		reg := NewRegistry("test", &fakeModule{name: "a", failOn: "provide"})
+		if _, err := Resolve[*settings](reg); err == nil || !strings.Contains(err.Error(), "failed resolving module a") {
+			t.Fatalf("expected provider error, got: %v", err)
+		}
+	})
+
+	t.Run("cached per load cycle", func(t *testing.T) {
+		events := []string{}
+		reg := NewRegistry("test", &fakeModule{name: "a", resource: &settings{value: "a"}, events: &events})
+		for range 2 {
+			if _, err := Resolve[*settings](reg); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		}
+		if n := count(events, "provide:a"); n != 1 {
+			t.Errorf("expected the resource to be provided once, got %d", n)
+		}
+		if err := reg.Load(viper.New()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, err := Resolve[*settings](reg); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if n := count(events, "provide:a"); n != 2 {
+			t.Errorf("expected the resource to be provided again after loading, got %d", n)
+		}
+	})
+
+	t.Run("cached while started", func(t *testing.T) {
+		events := []string{}
+		reg := NewRegistry("test", &fakeModule{name: "a", resource: &settings{value: "a"}, events: &events})
+		if _, err := Resolve[*settings](reg); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := reg.Start(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, err := Resolve[*settings](reg); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if n := count(events, "provide:a"); n != 1 {
+			t.Errorf("expected the resource to be provided once, got %d", n)
+		}
+		if err := reg.Stop(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, err := Resolve[*settings](reg); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if n := count(events, "provide:a"); n != 2 {
+			t.Errorf("expected the resource to be provided again after stopping, got %d", n)
+		}
+	})
+
+	t.Run("provider resolving a dependency", func(t *testing.T) {
+		type wrapper struct{ inner *settings }
+		reg := NewRegistry("test", &fakeModule{name: "a", resource: &settings{value: "a"}})
+		reg.Add(&fakeModule{name: "b", deps: []string{"a"}, provide: func() (any, error) {
+			inner, err := Resolve[*settings](reg)
+			return &wrapper{inner: inner}, err
+		}})
+		done := make(chan error, 1)
+		go func() {
+			res, err := Resolve[*wrapper](reg)
+			if err == nil && res.inner.value != "a" {
+				err = errors.Errorf("unexpected resource %q", res.inner.value)
+			}
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("resolve blocked")
+		}
+	})
+}
+
+func count(list []string, el string) (n int) {
+	for _, v := range list {
+		if v == el {
+			n++
+		}
+	}
+	return n
+}
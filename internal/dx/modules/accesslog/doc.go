@@ -0,0 +1,23 @@
+/*
+Package accesslog provides a `dx` module to manage access logs.
+
+This module expects a configuration source like:
+
+	access_log:
+		enabled: true
+		format: logfmt
+		sample: 0.25
+		omit_paths:
+			- Ping
+			- /v1/ping
+			- /v1/ready
+
+An entry is produced for every request including the method or route, status
+code, latency, peer address, request and response sizes, trace identifier and
+the identity of the authenticated caller. Sampling only applies to successful
+requests; failed requests are always logged.
+
+Entries are written to the same destination as the application logs, set by
+the "log" module.
+*/
+package accesslog
@@ -0,0 +1,86 @@
+package accesslog
+
+import (
+	"io"
+
+	"github.com/bcessa/echo-service/internal/accesslog"
+	"github.com/bcessa/echo-service/internal/dx"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	"go.bryk.io/pkg/errors"
+)
+
+// Module to manage access log settings.
+// nolint: lll
+type Module struct {
+	Enabled   bool     `json:"enabled" yaml:"enabled" mapstructure:"enabled" desc:"produce a log entry for every request"`
+	Format    string   `json:"format" yaml:"format" mapstructure:"format" desc:"output format: json or logfmt"`
+	Sample    float64  `json:"sample" yaml:"sample" mapstructure:"sample" desc:"ratio of successful requests logged, between 0 and 1; failed requests are always logged"`
+	OmitPaths []string `json:"omit_paths" yaml:"omit_paths" mapstructure:"omit_paths" desc:"methods or paths never logged; for example: Ping or /v1/ready"`
+}
+
+// Name returns the default module identifier: "access_log".
+func (m *Module) Name() string {
+	return "access_log"
+}
+
+// Load configuration settings from the provided viper instance.
+func (m *Module) Load(v *viper.Viper) error {
+	return v.Unmarshal(&m)
+}
+
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &Module{
+		Format: accesslog.FormatJSON,
+		Sample: 1,
+	}
+}
+
+// Flags returns no CLI options by default.
+func (m *Module) Flags(_ string) []cli.Param {
+	return []cli.Param{}
+}
+
+// Customize is not supported by the module.
+func (m *Module) Customize(_ any) error {
+	return errors.New("invalid operation on 'access_log' module")
+}
+
+// Validate the access log settings.
+func (m *Module) Validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if !m.Enabled {
+		return issues
+	}
+	if m.Format != "" && m.Format != accesslog.FormatJSON && m.Format != accesslog.FormatLogfmt {
+		issues = append(issues, dx.Issue{Key: "format", Message: "must be one of: json, logfmt"})
+	}
+	if m.Sample < 0 || m.Sample > 1 {
+		issues = append(issues, dx.Issue{Key: "sample", Message: "must be between 0 and 1"})
+	}
+	if len(issues) == 0 {
+		if _, err := m.Logger(io.Discard); err != nil {
+			issues = append(issues, dx.Issue{Key: "omit_paths", Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// Logger returns an access logger based on the module settings, writing
+// entries to `sink`.
+func (m *Module) Logger(sink io.Writer) (*accesslog.Logger, error) {
+	return accesslog.New(m.Options(sink))
+}
+
+// Options returns the access logger options based on the module settings,
+// writing entries to `sink`; useful to adjust them before creating a logger.
+func (m *Module) Options(sink io.Writer) accesslog.Options {
+	return accesslog.Options{
+		Format: m.Format,
+		Sample: m.Sample,
+		Omit:   m.OmitPaths,
+		Sink:   sink,
+	}
+}
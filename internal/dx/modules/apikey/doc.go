@@ -0,0 +1,32 @@
+/*
+Package apikey provides a `dx` module to manage API key authentication.
+
+This module expects a configuration source like:
+
+	apikey:
+		enabled: true
+		store: /etc/echoctl/apikeys.json
+		header: x-api-key
+		store_refresh: 30s
+		exempt: [Ping, Ready, "GET /v1/ping", "GET /v1/ready"]
+
+Keys are registered on the store file using the `apikey create` command, and
+disabled using `apikey revoke`. Only a hash of each key is saved on the store,
+along with its label, scopes and expiration date. The file is checked for
+updates at most once per `store_refresh` period, so changes are applied
+without restarting the server.
+
+Keys are provided using the `header` value, as gRPC metadata or an HTTP header.
+When used through the HTTP gateway the header must also be retained as request
+metadata by the gateway middleware. The identity of authenticated callers,
+including the key scopes, is available on the request context using
+`auth.FromContext`.
+
+Exemptions match either the method name, like "Ping"; the full gRPC method
+using patterns, like "/sample.v1.ServiceAPI/*"; or an HTTP route, like
+"GET /v1/ping". Method names only match gRPC requests, so HTTP routes must be
+listed explicitly when the module is used as HTTP middleware. When several
+authentication modules are enabled, a request is accepted without credentials
+only if all of them exempt it.
+*/
+package apikey
@@ -0,0 +1,77 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/bcessa/echo-service/internal/auth"
+	"github.com/bcessa/echo-service/internal/dx"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	"go.bryk.io/pkg/errors"
+)
+
+// DefaultRefresh is the default period between checks for store updates.
+const DefaultRefresh = 30 * time.Second
+
+// Module to manage API key authentication settings.
+// nolint: lll
+type Module struct {
+	Enabled bool          `json:"enabled" yaml:"enabled" mapstructure:"enabled" desc:"accept API keys to authenticate requests"`
+	Store   string        `json:"store" yaml:"store" mapstructure:"store" desc:"local file with the registered keys; managed with the 'apikey' commands"`
+	Header  string        `json:"header" yaml:"header" mapstructure:"header" desc:"request header used to provide keys"`
+	Refresh time.Duration `json:"store_refresh" yaml:"store_refresh" mapstructure:"store_refresh" desc:"period between checks for store updates"`
+	Exempt  []string      `json:"exempt" yaml:"exempt" mapstructure:"exempt" desc:"methods or routes not requiring authentication; for example: Ping or 'GET /v1/ping'"`
+}
+
+// Name returns the default module identifier: "apikey".
+func (m *Module) Name() string {
+	return "apikey"
+}
+
+// Load configuration settings from the provided viper instance.
+func (m *Module) Load(v *viper.Viper) error {
+	return v.Unmarshal(&m)
+}
+
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &Module{
+		Header:  auth.DefaultAPIKeyHeader,
+		Refresh: DefaultRefresh,
+	}
+}
+
+// Flags returns no CLI options by default.
+func (m *Module) Flags(_ string) []cli.Param {
+	return []cli.Param{}
+}
+
+// Customize is not supported by the module.
+func (m *Module) Customize(_ any) error {
+	return errors.New("invalid operation on 'apikey' module")
+}
+
+// Validate the API key settings. When enabled, a valid store is required;
+// a missing store file is accepted and treated as empty.
+func (m *Module) Validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if !m.Enabled {
+		return issues
+	}
+	if m.Refresh < 0 {
+		issues = append(issues, dx.Issue{Key: "store_refresh", Message: "must not be negative"})
+	}
+	if m.Store == "" {
+		return append(issues, dx.Issue{Key: "store", Message: "required"})
+	}
+	if _, err := m.Verifier(); err != nil {
+		issues = append(issues, dx.Issue{Key: "store", Message: err.Error()})
+	}
+	return issues
+}
+
+// Verifier returns a key verifier based on the module settings.
+func (m *Module) Verifier() (*auth.APIKeyStore, error) {
+	return auth.OpenAPIKeyStore(m.Store, m.Header, m.Refresh)
+}
@@ -0,0 +1,36 @@
+/*
+Package handler provides a `dx` module to manage the behavior of a
+`handler.ServiceOperator` instance.
+
+This module expects a configuration source like:
+
+	handler:
+		seed: 42 # if 0, a random seed is used
+		slow:
+			latency:
+				distribution: normal # fixed, uniform, normal or pareto
+				min: 20ms
+				max: 2s
+				mean: 150ms
+				std_dev: 50ms
+				shape: 0
+			error_rate: 0.05
+		faulty:
+			error_rate: 0.5
+
+The same seed always produces the same sequence of delays and errors, which
+makes the `Slow` and `Faulty` methods reproducible on tests and demos. For
+the "pareto" distribution, `min` is used as the scale and `shape` controls
+the tail; for example, `min: 20ms` and `shape: 1.5` produce mostly short
+delays with occasional very slow requests, capped at `max`; the tail is
+unbounded, so `max` is required. Default latency settings only apply to the
+default "uniform" distribution; when using a different one, values not set
+are zero.
+
+The module creates the service operator when first started, using the
+"handler" component logger provided by the `log` module, and provides it to
+the `rpc` module as an `rpc.ServiceProvider`. Settings are applied by
+reloading the operator every time the module starts again, which also seeds
+the random source again; use `Close` to free the operator on shutdown.
+*/
+package handler
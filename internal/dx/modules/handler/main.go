@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bcessa/echo-service/handler"
+	"github.com/bcessa/echo-service/internal/dx"
+	dxLog "github.com/bcessa/echo-service/internal/dx/modules/log"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
+)
+
+// Module to manage a `handler.ServiceOperator` instance.
+type Module struct {
+	conf     config
+	log      xlog.Logger
+	extras   []handler.Option
+	operator *handler.ServiceOperator
+	mu       sync.Mutex
+}
+
+type config struct {
+	Handler *settings `json:"handler" yaml:"handler" mapstructure:"handler" desc:"service operator behavior"`
+}
+
+// nolint: lll
+type settings struct {
+	Seed   int64           `json:"seed" yaml:"seed" mapstructure:"seed" desc:"seed for the random source used by Slow and Faulty; if 0, a random seed is used"`
+	Slow   *slowSettings   `json:"slow" yaml:"slow" mapstructure:"slow" desc:"latency and errors for the Slow method"`
+	Faulty *faultySettings `json:"faulty" yaml:"faulty" mapstructure:"faulty" desc:"errors for the Faulty method"`
+}
+
+// nolint: lll
+type slowSettings struct {
+	Latency   *latencySettings `json:"latency" yaml:"latency" mapstructure:"latency" desc:"distribution of the latency introduced"`
+	ErrorRate float64          `json:"error_rate" yaml:"error_rate" mapstructure:"error_rate" desc:"probability of returning an error, between 0 and 1"`
+}
+
+// nolint: lll
+type latencySettings struct {
+	Distribution string        `json:"distribution" yaml:"distribution" mapstructure:"distribution" desc:"fixed, uniform, normal or pareto"`
+	Min          time.Duration `json:"min" yaml:"min" mapstructure:"min" desc:"lower bound for all distributions; scale for pareto"`
+	Max          time.Duration `json:"max" yaml:"max" mapstructure:"max" desc:"upper bound for all distributions; ignored if 0, except for uniform and pareto"`
+	Mean         time.Duration `json:"mean" yaml:"mean" mapstructure:"mean" desc:"value used for fixed, and mean for normal"`
+	StdDev       time.Duration `json:"std_dev" yaml:"std_dev" mapstructure:"std_dev" desc:"standard deviation for normal"`
+	Shape        float64       `json:"shape" yaml:"shape" mapstructure:"shape" desc:"shape for pareto; lower values produce longer tails"`
+}
+
+// nolint: lll
+type faultySettings struct {
+	ErrorRate float64 `json:"error_rate" yaml:"error_rate" mapstructure:"error_rate" desc:"probability of returning an error, between 0 and 1"`
+}
+
+// Name returns the default module identifier: "handler".
+func (m *Module) Name() string {
+	return "handler"
+}
+
+// Depends on the "log" module, used to obtain the operator logger; and on
+// the "otel" module, telemetry must be available before the operator
+// starts handling requests.
+func (m *Module) Depends() []string {
+	return []string{"log", "otel"}
+}
+
+// Consume the "handler" component logger provided by the "log" module.
+func (m *Module) Consume(r *dx.Registry) error {
+	loggers, err := dx.Resolve[dxLog.Loggers](r)
+	if err != nil {
+		return err
+	}
+	m.log = loggers.Component("handler")
+	return nil
+}
+
+// Load configuration settings from the provided viper instance.
+func (m *Module) Load(v *viper.Viper) error {
+	conf := config{Handler: defaultSettings()}
+	if err := v.Unmarshal(&conf); err != nil {
+		return err
+	}
+
+	// restore any section removed explicitly
+	def := defaultSettings()
+	if conf.Handler == nil {
+		conf.Handler = def
+	}
+	if conf.Handler.Slow == nil {
+		conf.Handler.Slow = def.Slow
+	}
+	if conf.Handler.Slow.Latency == nil {
+		conf.Handler.Slow.Latency = def.Slow.Latency
+	}
+	if conf.Handler.Faulty == nil {
+		conf.Handler.Faulty = def.Faulty
+	}
+
+	// the default latency settings only apply to the default distribution;
+	// otherwise start from zero values
+	if lat := conf.Handler.Slow.Latency; lat.Distribution != def.Slow.Latency.Distribution {
+		lat = new(latencySettings)
+		if err := v.UnmarshalKey("handler.slow.latency", lat); err != nil {
+			return err
+		}
+		conf.Handler.Slow.Latency = lat
+	}
+	m.conf = conf
+	return nil
+}
+
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &config{Handler: defaultSettings()}
+}
+
+// Flags returns no CLI options by default.
+func (m *Module) Flags(_ string) []cli.Param {
+	return []cli.Param{}
+}
+
+// Validate the service operator settings.
+func (m *Module) Validate() []dx.Issue {
+	issues := []dx.Issue{}
+	conf := m.conf.Handler
+	if conf == nil {
+		return issues
+	}
+	if err := conf.latency().Validate(); err != nil {
+		issues = append(issues, dx.Issue{Key: "handler.slow.latency", Message: err.Error()})
+	}
+	if rate := conf.Slow.ErrorRate; rate < 0 || rate > 1 {
+		issues = append(issues, dx.Issue{Key: "handler.slow.error_rate", Message: "must be between 0 and 1"})
+	}
+	if rate := conf.Faulty.ErrorRate; rate < 0 || rate > 1 {
+		issues = append(issues, dx.Issue{Key: "handler.faulty.error_rate", Message: "must be between 0 and 1"})
+	}
+	return issues
+}
+
+// Customize the provided `*[]handler.Option` target.
+func (m *Module) Customize(target any) error {
+	// ensure provide target is of correct type
+	opts, ok := target.(*[]handler.Option)
+	if !ok {
+		return errors.New("target must be of type `*[]handler.Option`")
+	}
+	conf := m.conf.Handler
+	if conf == nil {
+		return nil
+	}
+	if m.log != nil {
+		*opts = append(*opts, handler.WithLogger(m.log))
+	}
+	*opts = append(*opts,
+		handler.WithSeed(conf.Seed),
+		handler.WithLatency(conf.latency()),
+		handler.WithErrorRate(conf.Slow.ErrorRate, conf.Faulty.ErrorRate),
+	)
+	return nil
+}
+
+// Extend the operator settings with additional options; they take
+// precedence over the module settings. Extra options are applied on the
+// next call to `Start` and discarded when the module is stopped.
+func (m *Module) Extend(opts ...handler.Option) {
+	m.extras = append(m.extras, opts...)
+}
+
+// Start a new service operator based on the module's current settings.
+// If the operator is already available, it is reloaded instead.
+func (m *Module) Start() (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	opts := []handler.Option{}
+	if err = m.Customize(&opts); err != nil {
+		return err
+	}
+	opts = append(opts, m.extras...)
+	if m.operator != nil {
+		return m.operator.Reload(opts...)
+	}
+	m.operator, err = handler.New(opts...)
+	return err
+}
+
+// Stop discards any extra options; the operator is retained to be reloaded
+// when the module starts again, use `Close` to free it.
+func (m *Module) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.extras = nil
+	return nil
+}
+
+// Provide the service operator as an `rpc.ServiceProvider` instance, to be
+// exposed by the "rpc" module; returns `nil` if the module is not started.
+func (m *Module) Provide() (any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.operator == nil {
+		return nil, nil
+	}
+	return m.operator.RPC(), nil
+}
+
+// Operator returns the service operator managed by the module; returns
+// `nil` if the module was never started.
+func (m *Module) Operator() *handler.ServiceOperator {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.operator
+}
+
+// Close the service operator, if any.
+func (m *Module) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.operator == nil {
+		return nil
+	}
+	err := m.operator.Close()
+	m.operator = nil
+	return err
+}
+
+// latency settings for the `Slow` method.
+func (s *settings) latency() handler.Latency {
+	l := s.Slow.Latency
+	return handler.Latency{
+		Distribution: l.Distribution,
+		Min:          l.Min,
+		Max:          l.Max,
+		Mean:         l.Mean,
+		StdDev:       l.StdDev,
+		Shape:        l.Shape,
+	}
+}
+
+// apply the same default settings used by the service operator.
+func defaultSettings() *settings {
+	def := handler.DefaultLatency
+	return &settings{
+		Slow: &slowSettings{
+			Latency: &latencySettings{
+				Distribution: def.Distribution,
+				Min:          def.Min,
+				Max:          def.Max,
+				Mean:         def.Mean,
+				StdDev:       def.StdDev,
+				Shape:        def.Shape,
+			},
+			ErrorRate: handler.DefaultSlowErrorRate,
+		},
+		Faulty: &faultySettings{ErrorRate: handler.DefaultFaultyErrorRate},
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bcessa/echo-service/handler"
+	"github.com/spf13/viper"
+)
+
+func TestLoad(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		config  string
+		latency handler.Latency
+	}{
+		{
+			name:    "defaults",
+			config:  "handler:\n  seed: 1\n",
+			latency: handler.DefaultLatency,
+		},
+		{
+			name:    "default distribution",
+			config:  "handler:\n  slow:\n    latency:\n      max: 1s\n",
+			latency: handler.Latency{Distribution: handler.LatencyUniform, Min: 20 * ms, Max: time.Second},
+		},
+		{
+			name:    "normal distribution",
+			config:  "handler:\n  slow:\n    latency:\n      distribution: normal\n      mean: 500ms\n      std_dev: 100ms\n",
+			latency: handler.Latency{Distribution: handler.LatencyNormal, Mean: 500 * ms, StdDev: 100 * ms},
+		},
+		{
+			name:    "fixed distribution",
+			config:  "handler:\n  slow:\n    latency:\n      distribution: fixed\n      mean: 300ms\n",
+			latency: handler.Latency{Distribution: handler.LatencyFixed, Mean: 300 * ms},
+		},
+		{
+			name:    "section removed",
+			config:  "handler:\n  slow:\n    latency: null\n",
+			latency: handler.DefaultLatency,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.SetConfigType("yaml")
+			if err := v.ReadConfig(strings.NewReader(tt.config)); err != nil {
+				t.Fatal(err)
+			}
+			m := new(Module)
+			if err := m.Load(v); err != nil {
+				t.Fatal(err)
+			}
+			if res := m.conf.Handler.latency(); res != tt.latency {
+				t.Errorf("expected %+v, got %+v", tt.latency, res)
+			}
+			if issues := m.Validate(); len(issues) > 0 {
+				t.Errorf("unexpected issues: %v", issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+/*
+Package jwt provides a `dx` module to manage JWT bearer authentication.
+
+This module expects a configuration source like:
+
+	jwt:
+		enabled: true
+		keys:
+			- testdata/issuer.pub
+		jwks: /etc/echoctl/jwks.json
+		jwks_refresh: 5m
+		issuer: https://auth.example.com
+		audience:
+			- echo-service
+		leeway: 30s
+		algorithms: [RS256, ES256, EdDSA]
+		exempt: [Ping, Ready, "GET /v1/ping", "GET /v1/ready"]
+
+Tokens are verified using the static keys and the keys on the JWKS file; the
+file is checked for updates at most once per `jwks_refresh` period. Tokens
+must not be expired, and must include the expected issuer and one of the
+accepted audiences when set. The claims of valid tokens are available on the
+request context using `auth.FromContext` or `auth.Claims`.
+
+Exemptions match either the method name, like "Ping"; the full gRPC method
+using patterns, like "/sample.v1.ServiceAPI/*"; or an HTTP route, like
+"GET /v1/ping". Method names only match gRPC requests, so HTTP routes must be
+listed explicitly when the module is used as HTTP middleware. When several
+authentication modules are enabled, a request is accepted without credentials
+only if all of them exempt it.
+*/
+package jwt
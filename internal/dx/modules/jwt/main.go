@@ -0,0 +1,122 @@
+package jwt
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/bcessa/echo-service/internal/auth"
+	"github.com/bcessa/echo-service/internal/dx"
+	dxTLS "github.com/bcessa/echo-service/internal/dx/modules/tls"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	"go.bryk.io/pkg/errors"
+)
+
+const (
+	// DefaultRefresh is the default period between checks for JWKS
+	// file updates.
+	DefaultRefresh = 5 * time.Minute
+
+	// DefaultLeeway is the default tolerance used when verifying
+	// time-based claims.
+	DefaultLeeway = 30 * time.Second
+)
+
+// Module to manage JWT bearer authentication settings.
+// nolint: lll
+type Module struct {
+	Enabled    bool          `json:"enabled" yaml:"enabled" mapstructure:"enabled" desc:"require a valid JWT bearer token on every request"`
+	Keys       []string      `json:"keys" yaml:"keys" mapstructure:"keys" desc:"public keys or certificates used to verify tokens; path to a PEM file or base64-encoded PEM"`
+	JWKS       string        `json:"jwks" yaml:"jwks" mapstructure:"jwks" desc:"local JWKS file with additional verification keys"`
+	Refresh    time.Duration `json:"jwks_refresh" yaml:"jwks_refresh" mapstructure:"jwks_refresh" desc:"period between checks for JWKS file updates"`
+	Issuer     string        `json:"issuer" yaml:"issuer" mapstructure:"issuer" desc:"expected token issuer; not verified if empty"`
+	Audience   []string      `json:"audience" yaml:"audience" mapstructure:"audience" desc:"audiences accepted; tokens must include at least one of them"`
+	Leeway     time.Duration `json:"leeway" yaml:"leeway" mapstructure:"leeway" desc:"tolerance used when verifying expiration and other time-based claims"`
+	Algorithms []string      `json:"algorithms" yaml:"algorithms" mapstructure:"algorithms" desc:"signature algorithms accepted; for example: RS256, ES256 or EdDSA"`
+	Exempt     []string      `json:"exempt" yaml:"exempt" mapstructure:"exempt" desc:"methods or routes not requiring authentication; for example: Ping or 'GET /v1/ping'"`
+}
+
+// Name returns the default module identifier: "jwt".
+func (m *Module) Name() string {
+	return "jwt"
+}
+
+// Load configuration settings from the provided viper instance.
+func (m *Module) Load(v *viper.Viper) error {
+	return v.Unmarshal(&m)
+}
+
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &Module{
+		Refresh:    DefaultRefresh,
+		Leeway:     DefaultLeeway,
+		Algorithms: auth.DefaultAlgorithms,
+	}
+}
+
+// Flags returns no CLI options by default.
+func (m *Module) Flags(_ string) []cli.Param {
+	return []cli.Param{}
+}
+
+// Customize is not supported by the module.
+func (m *Module) Customize(_ any) error {
+	return errors.New("invalid operation on 'jwt' module")
+}
+
+// Validate the JWT settings. When enabled, at least one source of
+// verification keys is required.
+func (m *Module) Validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if !m.Enabled {
+		return issues
+	}
+	if len(m.Keys) == 0 && m.JWKS == "" {
+		issues = append(issues, dx.Issue{Key: "keys", Message: "verification keys or a JWKS file are required"})
+	}
+	for _, key := range m.Keys {
+		if _, err := dxTLS.LoadPEM(key); err != nil {
+			issues = append(issues, dx.Issue{Key: "keys", Message: fmt.Sprintf("key not available: %s", key)})
+		}
+	}
+	for _, alg := range m.Algorithms {
+		if !slices.Contains(auth.DefaultAlgorithms, alg) {
+			issues = append(issues, dx.Issue{Key: "algorithms", Message: fmt.Sprintf("unsupported algorithm: %s", alg)})
+		}
+	}
+	if m.Refresh < 0 {
+		issues = append(issues, dx.Issue{Key: "jwks_refresh", Message: "must not be negative"})
+	}
+	if m.Leeway < 0 {
+		issues = append(issues, dx.Issue{Key: "leeway", Message: "must not be negative"})
+	}
+	if len(issues) == 0 {
+		if _, err := m.Verifier(); err != nil {
+			issues = append(issues, dx.Issue{Key: "keys", Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// Verifier returns a token verifier based on the module settings.
+func (m *Module) Verifier() (*auth.JWTVerifier, error) {
+	opts := auth.JWTOptions{
+		JWKS:       m.JWKS,
+		Refresh:    m.Refresh,
+		Issuer:     m.Issuer,
+		Audience:   m.Audience,
+		Leeway:     m.Leeway,
+		Algorithms: m.Algorithms,
+	}
+	for _, key := range m.Keys {
+		data, err := dxTLS.LoadPEM(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load key %s", key)
+		}
+		opts.Keys = append(opts.Keys, data)
+	}
+	return auth.NewJWTVerifier(opts)
+}
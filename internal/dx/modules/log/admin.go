@@ -0,0 +1,92 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/bcessa/echo-service/internal/auth"
+)
+
+// levels describes the current log levels; used by the admin handler.
+type levels struct {
+	Level      string            `json:"level,omitempty"`
+	Component  string            `json:"component,omitempty"`
+	Components map[string]string `json:"components,omitempty"`
+}
+
+// SetLevel adjusts, at runtime, the level of a component or the main
+// level if `component` is empty. Adjustments are discarded when the
+// module is reloaded.
+func (m *Module) SetLevel(component, level string) error {
+	if _, err := parseLevel(level); err != nil {
+		return err
+	}
+	if component != "" && !slices.Contains(components, component) {
+		return errUnknownComponent
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.overrides == nil {
+		m.overrides = map[string]string{}
+	}
+	m.overrides[component] = level
+	m.apply()
+	return nil
+}
+
+// Levels returns the effective main level and the level of each component.
+func (m *Module) Levels() (string, map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := map[string]string{}
+	for _, name := range components {
+		list[name] = levelName(m.level(name))
+	}
+	return levelName(m.level("")), list
+}
+
+// AdminHandler returns an HTTP middleware exposing the log levels on the
+// configured `admin_path`. A GET request returns the current levels, and
+// a PUT request adjusts them using a JSON body like:
+//
+//	{"level": "debug", "component": "rpc"}
+//
+// Requests must be authenticated, and are rejected otherwise; the handler
+// must be installed after the authentication middleware. Use authorization
+// policies to further restrict access to it.
+func (m *Module) AdminHandler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if m.AdminPath == "" || r.URL.Path != m.AdminPath {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(auth.All(r.Context())) == 0 {
+				http.Error(w, "authentication required", http.StatusUnauthorized)
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+			case http.MethodPut, http.MethodPost:
+				req := levels{}
+				if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&req); err != nil {
+					http.Error(w, "invalid request", http.StatusBadRequest)
+					return
+				}
+				if err := m.SetLevel(req.Component, req.Level); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			default:
+				w.Header().Set("Allow", "GET, PUT, POST")
+				http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+				return
+			}
+			res := levels{}
+			res.Level, res.Components = m.Levels()
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(res)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Package log provides a `dx` module to manage the application logger.
+
+This module expects a configuration source like:
+
+	log:
+		level: info
+		format: json
+		output: file
+		file:
+			path: /var/log/echoctl/server.log
+			max_size: 100
+			max_backups: 5
+			max_age: 30
+			compress: true
+		components:
+			rpc: debug
+			otel: warning
+		admin_path: /admin/log
+
+Supported formats are "console", human-readable and colored when writing to
+a terminal; "json"; and "logfmt". Messages can be sent to standard output,
+standard error or a file rotated based on its size. Use "discard" to only
+export messages as OTLP logs, for example when running tests.
+
+Components use the main level unless a specific one is provided. Levels,
+format and output are applied again when the module is reloaded, for example
+when the server receives a SIGHUP signal or the configuration file changes;
+loggers already in use produce messages on the new output.
+
+When `admin_path` is set, the HTTP gateway exposes the current levels on it.
+Levels can be adjusted until the next reload using a PUT request:
+
+	curl -X PUT -H "x-api-key: $API_KEY" -d '{"level":"debug","component":"rpc"}' localhost:9090/admin/log
+
+Requests without an authenticated identity are rejected, so JWT or API key
+authentication must be enabled on the "middleware" module; use authorization
+policies to restrict access to the endpoint further.
+
+Other modules obtain the component loggers using `dx.Resolve`; the module
+provides them as a `Loggers` instance. Components producing their own
+entries, like access logs, write them to the same destination using
+`Writer`.
+
+	loggers, err := dx.Resolve[log.Loggers](registry)
+
+Messages can also be exported as OTLP logs using the `otel` module. Within a
+request, use `WithContext` to include the trace and span identifiers on the
+messages and correlate them with the request trace.
+
+	WithContext(ctx, logger).Info("processing request")
+*/
+package log
@@ -0,0 +1,385 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
+)
+
+// Supported level names, from lowest to highest.
+var levelNames = []string{"debug", "info", "warning", "error"}
+
+// parseLevel returns the log level for the provided name.
+func parseLevel(name string) (xlog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return xlog.Debug, nil
+	case "", "info":
+		return xlog.Info, nil
+	case "warning", "warn":
+		return xlog.Warning, nil
+	case "error":
+		return xlog.Error, nil
+	default:
+		return xlog.Info, errors.Errorf("invalid level: %s", name)
+	}
+}
+
+// levelName returns the name of the provided log level.
+func levelName(lvl xlog.Level) string {
+	if int(lvl) < len(levelNames) {
+		return levelNames[lvl]
+	}
+	return levelNames[len(levelNames)-1]
+}
+
+// leveled filters the messages sent to a base logger using a level that
+// can be adjusted at runtime. Loggers derived from it using `WithFields`
+// or `Sub` share the same level and output; when the output is replaced,
+// all of them use the new one. Messages are also exported as OTLP logs
+// when a provider is attached to the bridge.
+type leveled struct {
+	dest   *output
+	parent *leveled                      // logger derived from, if any
+	derive func(xlog.Logger) xlog.Logger // applied to the parent base logger
+	cache  atomic.Pointer[derived]       // base logger for the current output
+	level  *atomic.Uint32
+	out    *bridge
+	scope  string          // instrumentation scope for exported messages
+	fields xlog.Fields     // fields attached to exported messages
+	ctx    context.Context // used to correlate exported messages with spans
+}
+
+// derived base logger, and the output it was derived from.
+type derived struct {
+	from *sink
+	log  xlog.Logger
+}
+
+func newLeveled(dest *output, lvl xlog.Level, out *bridge, scope string) *leveled {
+	l := &leveled{dest: dest, level: new(atomic.Uint32), out: out, scope: scope}
+	l.level.Store(uint32(lvl))
+	return l
+}
+
+func (l *leveled) enabled(lvl xlog.Level) bool {
+	return lvl >= xlog.Panic || uint32(lvl) >= l.level.Load()
+}
+
+// with returns a logger sharing the level and bridge of `l`, including the
+// additional fields; `fn` adds them to the base logger.
+func (l *leveled) with(fn func(xlog.Logger) xlog.Logger, fields xlog.Fields) *leveled {
+	merged := make(xlog.Fields, len(l.fields)+len(fields))
+	maps.Copy(merged, l.fields)
+	maps.Copy(merged, fields)
+	return &leveled{
+		dest:   l.dest,
+		parent: l,
+		derive: fn,
+		level:  l.level,
+		out:    l.out,
+		scope:  l.scope,
+		fields: merged,
+		ctx:    l.ctx,
+	}
+}
+
+// base returns the logger used to produce messages on the current output;
+// derived loggers are adjusted when the output is replaced.
+func (l *leveled) base() xlog.Logger {
+	current := l.dest.current.Load()
+	if l.parent == nil {
+		return current.log
+	}
+	if d := l.cache.Load(); d != nil && d.from == current {
+		return d.log
+	}
+	log := l.derive(l.parent.base())
+	l.cache.Store(&derived{from: current, log: log})
+	return log
+}
+
+// export the message as an OTLP log, if a provider is attached.
+func (l *leveled) export(lvl xlog.Level, msg func() string) {
+	if l.out != nil {
+		l.out.emit(l.ctx, l.scope, lvl, msg, l.fields)
+	}
+}
+
+func (l *leveled) Debug(args ...any) {
+	if l.enabled(xlog.Debug) {
+		l.base().Debug(args...)
+		l.export(xlog.Debug, sprint(args))
+	}
+}
+
+func (l *leveled) Debugf(format string, args ...any) {
+	if l.enabled(xlog.Debug) {
+		l.base().Debugf(format, args...)
+		l.export(xlog.Debug, sprintf(format, args))
+	}
+}
+
+func (l *leveled) Info(args ...any) {
+	if l.enabled(xlog.Info) {
+		l.base().Info(args...)
+		l.export(xlog.Info, sprint(args))
+	}
+}
+
+func (l *leveled) Infof(format string, args ...any) {
+	if l.enabled(xlog.Info) {
+		l.base().Infof(format, args...)
+		l.export(xlog.Info, sprintf(format, args))
+	}
+}
+
+func (l *leveled) Warning(args ...any) {
+	if l.enabled(xlog.Warning) {
+		l.base().Warning(args...)
+		l.export(xlog.Warning, sprint(args))
+	}
+}
+
+func (l *leveled) Warningf(format string, args ...any) {
+	if l.enabled(xlog.Warning) {
+		l.base().Warningf(format, args...)
+		l.export(xlog.Warning, sprintf(format, args))
+	}
+}
+
+func (l *leveled) Error(args ...any) {
+	if l.enabled(xlog.Error) {
+		l.base().Error(args...)
+		l.export(xlog.Error, sprint(args))
+	}
+}
+
+func (l *leveled) Errorf(format string, args ...any) {
+	if l.enabled(xlog.Error) {
+		l.base().Errorf(format, args...)
+		l.export(xlog.Error, sprintf(format, args))
+	}
+}
+
+func (l *leveled) Panic(args ...any) {
+	l.export(xlog.Panic, sprint(args))
+	l.base().Panic(args...)
+}
+
+func (l *leveled) Panicf(format string, args ...any) {
+	l.export(xlog.Panic, sprintf(format, args))
+	l.base().Panicf(format, args...)
+}
+
+func (l *leveled) Fatal(args ...any) {
+	l.export(xlog.Fatal, sprint(args))
+	l.out.flush()
+	l.base().Fatal(args...)
+}
+
+func (l *leveled) Fatalf(format string, args ...any) {
+	l.export(xlog.Fatal, sprintf(format, args))
+	l.out.flush()
+	l.base().Fatalf(format, args...)
+}
+
+func (l *leveled) WithFields(fields xlog.Fields) xlog.Logger {
+	return l.with(func(base xlog.Logger) xlog.Logger { return base.WithFields(fields) }, fields)
+}
+
+func (l *leveled) WithField(key string, value any) xlog.Logger {
+	return l.with(func(base xlog.Logger) xlog.Logger { return base.WithField(key, value) }, xlog.Fields{key: value})
+}
+
+// SetLevel adjusts the level of the logger and all loggers derived from it.
+func (l *leveled) SetLevel(lvl xlog.Level) {
+	l.level.Store(uint32(lvl))
+}
+
+func (l *leveled) Sub(tags xlog.Fields) xlog.Logger {
+	return l.with(func(base xlog.Logger) xlog.Logger { return base.Sub(tags) }, tags)
+}
+
+func (l *leveled) Print(lvl xlog.Level, args ...any) {
+	if l.enabled(lvl) {
+		l.export(lvl, sprint(args))
+		l.base().Print(lvl, args...)
+	}
+}
+
+func (l *leveled) Printf(lvl xlog.Level, format string, args ...any) {
+	if l.enabled(lvl) {
+		l.export(lvl, sprintf(format, args))
+		l.base().Printf(lvl, format, args...)
+	}
+}
+
+// sprint and sprintf defer formatting until a message is exported.
+func sprint(args []any) func() string {
+	return func() string { return fmt.Sprint(args...) }
+}
+
+func sprintf(format string, args []any) func() string {
+	return func() string { return fmt.Sprintf(format, args...) }
+}
+
+// output shared by all the loggers created by the module; the sink is
+// replaced when the format or destination of the messages change. Writes
+// are sent to the current sink.
+type output struct {
+	current atomic.Pointer[sink]
+}
+
+// sink used to produce messages in a specific format and destination.
+type sink struct {
+	log xlog.Logger
+	w   io.Writer
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	return o.current.Load().w.Write(p)
+}
+
+// structured produces JSON or logfmt output using a `slog.Handler`. Level
+// filtering is left to the `leveled` wrapper.
+type structured struct {
+	l *slog.Logger
+}
+
+func newStructured(format string, sink io.Writer) xlog.Logger {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: replaceLevel}
+	if format == FormatJSON {
+		return &structured{l: slog.New(slog.NewJSONHandler(sink, opts))}
+	}
+	return &structured{l: slog.New(slog.NewTextHandler(sink, opts))}
+}
+
+// custom slog levels used for panic and fatal messages.
+const (
+	slogPanic = slog.LevelError + 4
+	slogFatal = slog.LevelError + 8
+)
+
+// replaceLevel uses lowercase names for all levels, including the custom
+// ones.
+func replaceLevel(_ []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.LevelKey {
+		return a
+	}
+	switch lvl, _ := a.Value.Any().(slog.Level); lvl {
+	case slogPanic:
+		a.Value = slog.StringValue("panic")
+	case slogFatal:
+		a.Value = slog.StringValue("fatal")
+	case slog.LevelWarn:
+		a.Value = slog.StringValue("warning")
+	default:
+		a.Value = slog.StringValue(strings.ToLower(lvl.String()))
+	}
+	return a
+}
+
+func (s *structured) log(lvl slog.Level, msg string) {
+	s.l.Log(context.Background(), lvl, msg)
+}
+
+func (s *structured) Debug(args ...any) {
+	s.log(slog.LevelDebug, fmt.Sprint(args...))
+}
+
+func (s *structured) Debugf(format string, args ...any) {
+	s.log(slog.LevelDebug, fmt.Sprintf(format, args...))
+}
+
+func (s *structured) Info(args ...any) {
+	s.log(slog.LevelInfo, fmt.Sprint(args...))
+}
+
+func (s *structured) Infof(format string, args ...any) {
+	s.log(slog.LevelInfo, fmt.Sprintf(format, args...))
+}
+
+func (s *structured) Warning(args ...any) {
+	s.log(slog.LevelWarn, fmt.Sprint(args...))
+}
+
+func (s *structured) Warningf(format string, args ...any) {
+	s.log(slog.LevelWarn, fmt.Sprintf(format, args...))
+}
+
+func (s *structured) Error(args ...any) {
+	s.log(slog.LevelError, fmt.Sprint(args...))
+}
+
+func (s *structured) Errorf(format string, args ...any) {
+	s.log(slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+func (s *structured) Panic(args ...any) {
+	msg := fmt.Sprint(args...)
+	s.log(slogPanic, msg)
+	panic(msg)
+}
+
+func (s *structured) Panicf(format string, args ...any) {
+	s.Panic(fmt.Sprintf(format, args...))
+}
+
+func (s *structured) Fatal(args ...any) {
+	s.log(slogFatal, fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+func (s *structured) Fatalf(format string, args ...any) {
+	s.Fatal(fmt.Sprintf(format, args...))
+}
+
+func (s *structured) WithFields(fields xlog.Fields) xlog.Logger {
+	attrs := make([]any, 0, len(fields))
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		attrs = append(attrs, slog.Any(k, fields[k]))
+	}
+	return &structured{l: s.l.With(attrs...)}
+}
+
+func (s *structured) WithField(key string, value any) xlog.Logger {
+	return &structured{l: s.l.With(slog.Any(key, value))}
+}
+
+// SetLevel is a no-op; levels are managed by the `leveled` wrapper.
+func (s *structured) SetLevel(_ xlog.Level) {}
+
+func (s *structured) Sub(tags xlog.Fields) xlog.Logger {
+	return s.WithFields(tags)
+}
+
+func (s *structured) Print(lvl xlog.Level, args ...any) {
+	s.Printf(lvl, "%s", fmt.Sprint(args...))
+}
+
+func (s *structured) Printf(lvl xlog.Level, format string, args ...any) {
+	switch lvl {
+	case xlog.Debug:
+		s.Debugf(format, args...)
+	case xlog.Info:
+		s.Infof(format, args...)
+	case xlog.Warning:
+		s.Warningf(format, args...)
+	case xlog.Error:
+		s.Errorf(format, args...)
+	case xlog.Panic:
+		s.Panicf(format, args...)
+	case xlog.Fatal:
+		s.Fatalf(format, args...)
+	}
+}
@@ -0,0 +1,341 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bcessa/echo-service/internal/dx"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
+	otelLog "go.opentelemetry.io/otel/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Supported output formats.
+const (
+	// FormatConsole produces human-readable, colored output.
+	FormatConsole = "console"
+
+	// FormatJSON produces a JSON object per message.
+	FormatJSON = "json"
+
+	// FormatLogfmt produces a line of `key=value` pairs per message.
+	FormatLogfmt = "logfmt"
+)
+
+// Components supporting specific log levels.
+var components = []string{"rpc", "otel", "handler"}
+
+var errUnknownComponent = errors.New("unknown component")
+
+// Loggers provides the loggers used by the application components; the
+// module hands it over to other modules using `dx.Resolve`.
+type Loggers interface {
+	// Logger returns the main application logger.
+	Logger() xlog.Logger
+
+	// Component returns the logger for a specific component.
+	Component(name string) xlog.Logger
+
+	// Writer returns the destination of log messages; used by components
+	// producing their own entries, like access logs.
+	Writer() io.Writer
+}
+
+// Module to manage the application logger. The output format and
+// destination are set when the logger is first used, and replaced when
+// the module is reloaded using different ones; levels can be adjusted at
+// any time by reloading the module, or using the admin handler.
+// nolint: lll
+type Module struct {
+	Level      string            `json:"level" yaml:"level" mapstructure:"level" desc:"minimum level of the messages logged: debug, info, warning or error"`
+	Format     string            `json:"format" yaml:"format" mapstructure:"format" desc:"output format: console, json or logfmt"`
+	Output     string            `json:"output" yaml:"output" mapstructure:"output" desc:"destination for log messages: stdout, stderr, file or discard"`
+	File       *fileSettings     `json:"file" yaml:"file" mapstructure:"file" desc:"rotating log file; used when output is 'file'"`
+	Components map[string]string `json:"components" yaml:"components" mapstructure:"components" desc:"specific levels for individual components: rpc, otel or handler"`
+	AdminPath  string            `json:"admin_path" yaml:"admin_path" mapstructure:"admin_path" desc:"HTTP gateway path to inspect and adjust log levels at runtime; requests must be authenticated; disabled if empty"`
+
+	root      *leveled
+	loggers   map[string]*leveled // component loggers
+	overrides map[string]string   // levels adjusted at runtime
+	out       *bridge             // export messages as OTLP logs
+	dest      *output             // destination of log messages
+	active    outputSettings      // settings used by the current destination
+	sink      io.Closer
+	mu        sync.Mutex
+}
+
+// nolint: lll
+type fileSettings struct {
+	Path       string `json:"path" yaml:"path" mapstructure:"path" desc:"log file location"`
+	MaxSize    int    `json:"max_size" yaml:"max_size" mapstructure:"max_size" desc:"maximum size in megabytes before the file is rotated; 0 to use the default of 100"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" mapstructure:"max_backups" desc:"maximum number of rotated files retained; 0 to retain all"`
+	MaxAge     int    `json:"max_age" yaml:"max_age" mapstructure:"max_age" desc:"maximum number of days to retain rotated files; 0 to retain all"`
+	Compress   bool   `json:"compress" yaml:"compress" mapstructure:"compress" desc:"compress rotated files using gzip"`
+}
+
+// settings determining the format and destination of log messages.
+type outputSettings struct {
+	format string
+	output string
+	file   fileSettings
+}
+
+type config struct {
+	Log *Module `json:"log" yaml:"log" mapstructure:"log" desc:"application logs"`
+}
+
+// Name returns the default module identifier: "log".
+func (m *Module) Name() string {
+	return "log"
+}
+
+// Load configuration settings from the provided viper instance. If the
+// logger is already in use, the new levels, format and destination are
+// applied immediately.
+func (m *Module) Load(v *viper.Viper) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	conf := config{Log: defaultSettings()}
+	if err := v.Unmarshal(&conf); err != nil {
+		return err
+	}
+	m.Level = conf.Log.Level
+	m.Format = conf.Log.Format
+	m.Output = conf.Log.Output
+	m.File = conf.Log.File
+	m.Components = conf.Log.Components
+	m.AdminPath = conf.Log.AdminPath
+	m.overrides = nil // runtime adjustments are discarded
+	m.apply()
+	if m.root != nil && m.active != m.outputSettings() {
+		m.open()
+	}
+	return nil
+}
+
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &config{Log: defaultSettings()}
+}
+
+// Flags returns no CLI options by default.
+func (m *Module) Flags(_ string) []cli.Param {
+	return []cli.Param{}
+}
+
+// Customize is not supported by the module.
+func (m *Module) Customize(_ any) error {
+	return errors.New("invalid operation on 'log' module")
+}
+
+// Validate the logger settings.
+func (m *Module) Validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if _, err := parseLevel(m.Level); err != nil {
+		issues = append(issues, dx.Issue{Key: "log.level", Message: err.Error()})
+	}
+	if !slices.Contains([]string{"", FormatConsole, FormatJSON, FormatLogfmt}, m.Format) {
+		issues = append(issues, dx.Issue{Key: "log.format", Message: "must be one of: console, json, logfmt"})
+	}
+	switch m.Output {
+	case "", "stdout", "stderr", "discard":
+	case "file":
+		if m.File == nil || m.File.Path == "" {
+			issues = append(issues, dx.Issue{Key: "log.file.path", Message: "required when output is 'file'"})
+		} else if m.File.MaxSize < 0 || m.File.MaxBackups < 0 || m.File.MaxAge < 0 {
+			issues = append(issues, dx.Issue{Key: "log.file", Message: "limits must not be negative"})
+		}
+	default:
+		issues = append(issues, dx.Issue{Key: "log.output", Message: "must be one of: stdout, stderr, file, discard"})
+	}
+	for name, lvl := range m.Components {
+		key := fmt.Sprintf("log.components.%s", name)
+		if !slices.Contains(components, name) {
+			issues = append(issues, dx.Issue{Key: key, Message: errUnknownComponent.Error()})
+		}
+		if _, err := parseLevel(lvl); err != nil {
+			issues = append(issues, dx.Issue{Key: key, Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// Logger returns the main application logger.
+func (m *Module) Logger() xlog.Logger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setup()
+	return m.root
+}
+
+// Component returns the logger for a specific component; it uses the
+// component level if set, and the main level otherwise.
+func (m *Module) Component(name string) xlog.Logger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setup()
+	if l, ok := m.loggers[name]; ok {
+		return l
+	}
+	tags := xlog.Fields{"component": name}
+	l := m.root.with(func(base xlog.Logger) xlog.Logger { return base.Sub(tags) }, tags)
+	l.level, l.scope = new(atomic.Uint32), name
+	l.SetLevel(m.level(name))
+	m.loggers[name] = l
+	return l
+}
+
+// Writer returns the destination of log messages, based on the `output`
+// setting.
+func (m *Module) Writer() io.Writer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setup()
+	return m.dest
+}
+
+// Provide the application loggers as a `Loggers` instance.
+func (m *Module) Provide() (any, error) {
+	return Loggers(m), nil
+}
+
+// Export log messages using the provided OpenTelemetry logger provider,
+// in addition to the regular output; use `nil` to stop exporting them.
+func (m *Module) Export(provider otelLog.LoggerProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setup()
+	m.out.attach(provider)
+}
+
+// Close the log file, if any.
+func (m *Module) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sink == nil {
+		return nil
+	}
+	err := m.sink.Close()
+	m.sink = nil
+	return err
+}
+
+// setup the base logger on first use; must be called with the lock held.
+func (m *Module) setup() {
+	if m.root != nil {
+		return
+	}
+	m.dest = new(output)
+	m.open()
+	m.out = new(bridge)
+	m.root = newLeveled(m.dest, m.level(""), m.out, "main")
+	m.loggers = map[string]*leveled{}
+}
+
+// open the destination of log messages based on the current settings,
+// replacing the one in use, if any; must be called with the lock held.
+func (m *Module) open() {
+	var (
+		w      io.Writer
+		closer io.Closer
+	)
+	switch m.Output {
+	case "stdout":
+		w = os.Stdout
+	case "discard":
+		w = io.Discard // messages are only exported, if enabled
+	case "file":
+		lj := &lumberjack.Logger{
+			Filename:   filepath.Clean(m.File.Path),
+			MaxSize:    m.File.MaxSize,
+			MaxBackups: m.File.MaxBackups,
+			MaxAge:     m.File.MaxAge,
+			Compress:   m.File.Compress,
+		}
+		w, closer = lj, lj
+	default:
+		w = os.Stderr
+	}
+	var base xlog.Logger
+	switch m.Format {
+	case FormatJSON, FormatLogfmt:
+		base = newStructured(m.Format, w)
+	default:
+		base = xlog.WithCharm(xlog.CharmOptions{
+			WithColor: m.Output != "file" && m.Output != "discard",
+			Sink:      w,
+		})
+	}
+	base.SetLevel(xlog.Debug) // filtering is done by the `leveled` wrapper
+	m.dest.current.Store(&sink{log: base, w: w})
+
+	// close the previous log file, if any
+	if m.sink != nil {
+		_ = m.sink.Close()
+	}
+	m.sink = closer
+	m.active = m.outputSettings()
+}
+
+// outputSettings returns the settings determining the current format and
+// destination of log messages.
+func (m *Module) outputSettings() outputSettings {
+	res := outputSettings{format: m.Format, output: m.Output}
+	if m.File != nil {
+		res.file = *m.File
+	}
+	return res
+}
+
+// apply the current levels to the loggers in use; must be called with
+// the lock held.
+func (m *Module) apply() {
+	if m.root == nil {
+		return
+	}
+	m.root.SetLevel(m.level(""))
+	for name, l := range m.loggers {
+		l.SetLevel(m.level(name))
+	}
+}
+
+// level returns the effective level for a component, or the main level
+// if `name` is empty. Runtime adjustments take precedence over settings.
+func (m *Module) level(name string) xlog.Level {
+	if name != "" {
+		if lvl, ok := m.overrides[name]; ok {
+			return must(parseLevel(lvl))
+		}
+		if lvl, ok := m.Components[name]; ok {
+			return must(parseLevel(lvl))
+		}
+	}
+	if lvl, ok := m.overrides[""]; ok {
+		return must(parseLevel(lvl))
+	}
+	return must(parseLevel(m.Level))
+}
+
+// apply minimal default settings.
+func defaultSettings() *Module {
+	return &Module{
+		Level:  "info",
+		Format: FormatConsole,
+		Output: "stderr",
+	}
+}
+
+// must returns the level ignoring errors; invalid levels are reported
+// during validation and default to "info".
+func must(lvl xlog.Level, _ error) xlog.Level {
+	return lvl
+}
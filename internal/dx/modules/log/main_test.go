@@ -0,0 +1,103 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bcessa/echo-service/internal/auth"
+	"github.com/spf13/viper"
+)
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	load := func(t *testing.T, m *Module, format, file string) {
+		t.Helper()
+		v := viper.New()
+		v.Set("log.format", format)
+		v.Set("log.output", "file")
+		v.Set("log.file.path", file)
+		if err := m.Load(v); err != nil {
+			t.Fatal(err)
+		}
+		if issues := m.Validate(); len(issues) > 0 {
+			t.Fatalf("invalid settings: %v", issues)
+		}
+	}
+	read := func(t *testing.T, file string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	m := new(Module)
+	defer func() { _ = m.Close() }()
+	first := filepath.Join(dir, "first.log")
+	load(t, m, FormatJSON, first)
+	logger := m.Component("rpc").WithField("request", "sample")
+	writer := m.Writer()
+	logger.Info("first message")
+	_, _ = fmt.Fprintln(writer, "first entry")
+
+	// format and output changes are applied to the loggers in use
+	second := filepath.Join(dir, "second.log")
+	load(t, m, FormatLogfmt, second)
+	logger.Info("second message")
+	_, _ = fmt.Fprintln(writer, "second entry")
+
+	if out := read(t, first); !strings.Contains(out, `"msg":"first message"`) || !strings.Contains(out, "first entry") {
+		t.Errorf("unexpected content on the first file: %s", out)
+	}
+	out := read(t, second)
+	for _, expected := range []string{`msg="second message"`, "component=rpc", "request=sample", "second entry"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q on the second file: %s", expected, out)
+		}
+	}
+	if strings.Contains(out, "first") {
+		t.Errorf("unexpected content on the second file: %s", out)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	m := &Module{Level: "info", Output: "discard", AdminPath: "/admin/log"}
+	handler := m.AdminHandler()(http.NotFoundHandler())
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		id     *auth.Identity
+		status int
+	}{
+		{name: "unauthenticated read", method: http.MethodGet, status: http.StatusUnauthorized},
+		{name: "unauthenticated update", method: http.MethodPut, body: `{"level":"debug"}`, status: http.StatusUnauthorized},
+		{name: "read", method: http.MethodGet, id: &auth.Identity{Subject: "admin"}, status: http.StatusOK},
+		{name: "update", method: http.MethodPut, body: `{"level":"debug"}`, id: &auth.Identity{Subject: "admin"}, status: http.StatusOK},
+		{name: "invalid level", method: http.MethodPut, body: `{"level":"trace"}`, id: &auth.Identity{Subject: "admin"}, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/log", strings.NewReader(tt.body))
+			if tt.id != nil {
+				req = req.WithContext(auth.WithIdentity(req.Context(), tt.id))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				body, _ := io.ReadAll(rec.Body)
+				t.Errorf("expected status %d, got %d: %s", tt.status, rec.Code, body)
+			}
+		})
+	}
+	if lvl, _ := m.Levels(); lvl != "debug" {
+		t.Errorf("expected level to be adjusted only by authenticated requests, got %s", lvl)
+	}
+}
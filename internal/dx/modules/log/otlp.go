@@ -0,0 +1,174 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	xlog "go.bryk.io/pkg/log"
+	otelLog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// bridge exports log messages using an OpenTelemetry logger provider.
+type bridge struct {
+	provider otelLog.LoggerProvider
+	loggers  map[string]otelLog.Logger
+	mu       sync.RWMutex
+}
+
+// attach the provider used to export messages; `nil` to disable it.
+func (b *bridge) attach(provider otelLog.LoggerProvider) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.provider = provider
+	b.loggers = map[string]otelLog.Logger{}
+}
+
+// emit a log record, if a provider is attached. If available on `ctx`,
+// the record is correlated with the active span.
+func (b *bridge) emit(ctx context.Context, scope string, lvl xlog.Level, msg func() string, fields xlog.Fields) {
+	logger := b.logger(scope)
+	if logger == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rec := otelLog.Record{}
+	rec.SetTimestamp(time.Now())
+	rec.SetSeverity(severity(lvl))
+	rec.SetSeverityText(levelText(lvl))
+	rec.SetBody(otelLog.StringValue(msg()))
+	for k, v := range fields {
+		rec.AddAttributes(otelLog.KeyValue{Key: k, Value: attrValue(v)})
+	}
+	logger.Emit(ctx, rec)
+}
+
+// flush pending records before the process exits, if supported by the
+// provider.
+func (b *bridge) flush() {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	p, ok := b.provider.(interface{ ForceFlush(context.Context) error })
+	b.mu.RUnlock()
+	if ok {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = p.ForceFlush(ctx)
+	}
+}
+
+// logger returns the OpenTelemetry logger for an instrumentation scope.
+func (b *bridge) logger(scope string) otelLog.Logger {
+	b.mu.RLock()
+	if b.provider == nil {
+		b.mu.RUnlock()
+		return nil
+	}
+	logger, ok := b.loggers[scope]
+	b.mu.RUnlock()
+	if ok {
+		return logger
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.provider == nil {
+		return nil
+	}
+	logger = b.provider.Logger(scope)
+	b.loggers[scope] = logger
+	return logger
+}
+
+// WithContext returns a logger including the trace and span identifiers
+// available on `ctx`, if any. When OTLP logs are exported, messages
+// written using the returned logger are correlated with the active span.
+func WithContext(ctx context.Context, log xlog.Logger) xlog.Logger {
+	if l, ok := log.(*leveled); ok {
+		return l.WithContext(ctx)
+	}
+	if fields := traceFields(ctx); fields != nil {
+		return log.WithFields(fields)
+	}
+	return log
+}
+
+// WithContext returns a logger including the trace and span identifiers
+// available on `ctx`, if any; exported messages are correlated with the
+// active span. Loggers provided by the module can be used by components
+// that don't depend on it, like the service handler.
+func (l *leveled) WithContext(ctx context.Context) xlog.Logger {
+	fields := traceFields(ctx)
+	if fields == nil {
+		return l
+	}
+	derived := l.with(func(base xlog.Logger) xlog.Logger { return base.WithFields(fields) }, fields)
+	derived.ctx = ctx
+	return derived
+}
+
+// traceFields returns the identifiers of the span active on `ctx`, or
+// `nil` if there's none.
+func traceFields(ctx context.Context) xlog.Fields {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return xlog.Fields{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	}
+}
+
+func severity(lvl xlog.Level) otelLog.Severity {
+	switch lvl {
+	case xlog.Debug:
+		return otelLog.SeverityDebug
+	case xlog.Info:
+		return otelLog.SeverityInfo
+	case xlog.Warning:
+		return otelLog.SeverityWarn
+	case xlog.Error:
+		return otelLog.SeverityError
+	case xlog.Panic:
+		return otelLog.SeverityFatal
+	default:
+		return otelLog.SeverityFatal4
+	}
+}
+
+func levelText(lvl xlog.Level) string {
+	switch lvl {
+	case xlog.Panic:
+		return "panic"
+	case xlog.Fatal:
+		return "fatal"
+	default:
+		return levelName(lvl)
+	}
+}
+
+// attrValue converts a field value to an OpenTelemetry log value.
+func attrValue(v any) otelLog.Value {
+	switch val := v.(type) {
+	case string:
+		return otelLog.StringValue(val)
+	case bool:
+		return otelLog.BoolValue(val)
+	case int:
+		return otelLog.IntValue(val)
+	case int64:
+		return otelLog.Int64Value(val)
+	case float64:
+		return otelLog.Float64Value(val)
+	case error:
+		return otelLog.StringValue(val.Error())
+	default:
+		return otelLog.StringValue(fmt.Sprint(val))
+	}
+}
@@ -0,0 +1,21 @@
+/*
+Package metrics provides a `dx` module to manage request metrics.
+
+This module expects a configuration source like:
+
+	metrics:
+		enabled: true
+		buckets: [0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10]
+		max_labels: 100
+		routes:
+			- /v1/users/*
+		omit_paths:
+			- Ping
+			- /metrics
+
+For every request a counter is incremented and the duration is recorded on a
+histogram, labeled by method or route and status code. Use `routes` to group
+paths including variable segments; `max_labels` limits the number of distinct
+methods or routes recorded to prevent unbounded cardinality.
+*/
+package metrics
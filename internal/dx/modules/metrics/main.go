@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"github.com/bcessa/echo-service/internal/dx"
+	"github.com/bcessa/echo-service/internal/metrics"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	"go.bryk.io/pkg/errors"
+)
+
+// Module to manage request metrics settings.
+// nolint: lll
+type Module struct {
+	Enabled   bool      `json:"enabled" yaml:"enabled" mapstructure:"enabled" desc:"record rate, errors and duration metrics for every request"`
+	Buckets   []float64 `json:"buckets" yaml:"buckets" mapstructure:"buckets" desc:"histogram boundaries used to record request durations, in seconds"`
+	MaxLabels int       `json:"max_labels" yaml:"max_labels" mapstructure:"max_labels" desc:"maximum number of distinct methods or routes recorded; additional values are reported as '_other'"`
+	Routes    []string  `json:"routes" yaml:"routes" mapstructure:"routes" desc:"HTTP route templates used to group request paths; for example: /v1/users/*"`
+	OmitPaths []string  `json:"omit_paths" yaml:"omit_paths" mapstructure:"omit_paths" desc:"methods or paths never recorded; for example: Ping or /metrics"`
+}
+
+// Name returns the default module identifier: "metrics".
+func (m *Module) Name() string {
+	return "metrics"
+}
+
+// Load configuration settings from the provided viper instance.
+func (m *Module) Load(v *viper.Viper) error {
+	return v.Unmarshal(&m)
+}
+
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &Module{
+		Buckets:   metrics.DefaultBuckets,
+		MaxLabels: metrics.DefaultMaxLabels,
+	}
+}
+
+// Flags returns no CLI options by default.
+func (m *Module) Flags(_ string) []cli.Param {
+	return []cli.Param{}
+}
+
+// Customize is not supported by the module.
+func (m *Module) Customize(_ any) error {
+	return errors.New("invalid operation on 'metrics' module")
+}
+
+// Validate the request metrics settings.
+func (m *Module) Validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if !m.Enabled {
+		return issues
+	}
+	if m.MaxLabels < 0 {
+		issues = append(issues, dx.Issue{Key: "max_labels", Message: "can't be negative"})
+	}
+	for i := 1; i < len(m.Buckets); i++ {
+		if m.Buckets[i] <= m.Buckets[i-1] {
+			issues = append(issues, dx.Issue{Key: "buckets", Message: "must be in increasing order"})
+			break
+		}
+	}
+	if len(issues) == 0 {
+		if _, err := m.Recorder(); err != nil {
+			issues = append(issues, dx.Issue{Key: "routes", Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// Recorder returns a metrics recorder based on the module settings.
+func (m *Module) Recorder() (*metrics.Recorder, error) {
+	return metrics.New(metrics.Options{
+		Buckets:   m.Buckets,
+		MaxLabels: m.MaxLabels,
+		Routes:    m.Routes,
+		Omit:      m.OmitPaths,
+	})
+}
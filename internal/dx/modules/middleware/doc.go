@@ -26,7 +26,39 @@ This module expects a configuration source like:
 			omit_paths:
 				- /metrics
 				- /v1/ping
-				- /v1/health
+				- /v1/ready
+		# access logs for HTTP requests
+		access_log:
+			enabled: true
+			format: logfmt
+			sample: 0.25
+			omit_paths:
+				- /v1/ping
+				- /v1/ready
+		# rate, errors and duration metrics for HTTP requests
+		metrics:
+			enabled: true
+			buckets: [0.01, 0.05, 0.1, 0.5, 1, 5]
+			max_labels: 100
+			routes:
+				- /v1/users/*
+			omit_paths:
+				- /metrics
+				- /v1/ping
+		# JWT bearer authentication; HTTP routes must be exempted explicitly
+		jwt:
+			enabled: true
+			jwks: /etc/echoctl/jwks.json
+			exempt:
+				- GET /v1/ping
+				- GET /v1/ready
+		# API key authentication
+		apikey:
+			enabled: true
+			store: /etc/echoctl/apikeys.json
+			exempt:
+				- GET /v1/ping
+				- GET /v1/ready
 		# rate limiting
 		rate:
 			limit: 100
@@ -51,5 +83,13 @@ This module expects a configuration source like:
 			exposed_headers:
 				- authorization
 				- x-api-key
+
+The middleware functions enabled are provided to other modules, such as
+"rpc" or "server", as a `[]Handler` instance; the first one is the outermost.
+Access logs are written to the destination of the application logs, provided
+by the "log" module.
+
+Settings on the previous "rpc.http.middleware" location are still supported,
+but reported as deprecated; settings on "middleware" take precedence.
 */
 package middleware
@@ -1,11 +1,24 @@
 package middleware
 
 import (
+	"fmt"
+	"io"
 	"net/http"
+	"slices"
+	"strings"
 
+	"github.com/bcessa/echo-service/internal/auth"
+	"github.com/bcessa/echo-service/internal/dx"
+	dxAccess "github.com/bcessa/echo-service/internal/dx/modules/accesslog"
+	dxKey "github.com/bcessa/echo-service/internal/dx/modules/apikey"
+	dxJWT "github.com/bcessa/echo-service/internal/dx/modules/jwt"
+	dxLog "github.com/bcessa/echo-service/internal/dx/modules/log"
+	dxMetrics "github.com/bcessa/echo-service/internal/dx/modules/metrics"
+	dxPolicy "github.com/bcessa/echo-service/internal/dx/modules/policy"
 	"github.com/spf13/viper"
 	"go.bryk.io/pkg/cli"
 	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
 	mwCors "go.bryk.io/pkg/net/middleware/cors"
 	mwGzip "go.bryk.io/pkg/net/middleware/gzip"
 	mwHeaders "go.bryk.io/pkg/net/middleware/headers"
@@ -18,29 +31,80 @@ import (
 )
 
 // Module to manage common HTTP middleware functions.
+// nolint: lll
 type Module struct {
-	Proxy    bool                `json:"proxy_protocol" yaml:"proxy_protocol" mapstructure:"proxy_protocol"`
-	Recovery bool                `json:"panic_recovery" yaml:"panic_recovery" mapstructure:"panic_recovery"`
-	Otel     *otelSettings       `json:"otel" yaml:"otel" mapstructure:"otel"`
-	Gzip     int                 `json:"gzip" yaml:"gzip" mapstructure:"gzip"`
-	Cors     *mwCors.Options     `json:"cors" yaml:"cors" mapstructure:"cors"`
-	Headers  map[string]string   `json:"headers" yaml:"headers" mapstructure:"headers"`
-	Metadata *mwMetadata.Options `json:"metadata" yaml:"metadata" mapstructure:"metadata"`
-	Hsts     *mwHSTS.Options     `json:"hsts" yaml:"hsts" mapstructure:"hsts"`
-	Rate     *rateSettings       `json:"rate" yaml:"rate" mapstructure:"rate"`
+	Proxy    bool                `json:"proxy_protocol" yaml:"proxy_protocol" mapstructure:"proxy_protocol" desc:"support PROXY headers"`
+	Recovery bool                `json:"panic_recovery" yaml:"panic_recovery" mapstructure:"panic_recovery" desc:"return internal errors if 'panic' occurs"`
+	Otel     *otelSettings       `json:"otel" yaml:"otel" mapstructure:"otel" desc:"OpenTelemetry instrumentation"`
+	Gzip     int                 `json:"gzip" yaml:"gzip" mapstructure:"gzip" desc:"compression level between 1 and 9; 0 to disable"`
+	Cors     *mwCors.Options     `json:"cors" yaml:"cors" mapstructure:"cors" desc:"settings for: Cross-Origin-Request-Support"`
+	Headers  map[string]string   `json:"headers" yaml:"headers" mapstructure:"headers" desc:"custom headers returned on every response"`
+	Metadata *mwMetadata.Options `json:"metadata" yaml:"metadata" mapstructure:"metadata" desc:"retain some headers as 'context' metadata"`
+	Hsts     *mwHSTS.Options     `json:"hsts" yaml:"hsts" mapstructure:"hsts" desc:"HTTP Strict Transport Security"`
+	Rate     *rateSettings       `json:"rate" yaml:"rate" mapstructure:"rate" desc:"rate limiting"`
+	JWT      *dxJWT.Module       `json:"jwt" yaml:"jwt" mapstructure:"jwt" desc:"JWT bearer authentication"`
+	APIKey   *dxKey.Module       `json:"apikey" yaml:"apikey" mapstructure:"apikey" desc:"API key authentication"`
+	Policy   *dxPolicy.Module    `json:"policy" yaml:"policy" mapstructure:"policy" desc:"authorization policy"`
+	Access   *dxAccess.Module    `json:"access_log" yaml:"access_log" mapstructure:"access_log" desc:"access logs for HTTP requests"`
+	Metrics  *dxMetrics.Module   `json:"metrics" yaml:"metrics" mapstructure:"metrics" desc:"rate, errors and duration metrics for HTTP requests"`
+
+	// Logger used to report policy decisions, optional.
+	Logger xlog.Logger `json:"-" yaml:"-" mapstructure:"-"`
+
+	logs io.Writer // destination of access logs
 }
 
 // Handler defines the common signature for middleware functions.
 type Handler = func(http.Handler) http.Handler
 
+// HTTP methods supported on CORS settings.
+var corsMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
 // Name returns the default module identifier: "middleware".
 func (m *Module) Name() string {
 	return "middleware"
 }
 
+// Depends on the "log" module, used to obtain the destination of access
+// logs.
+func (m *Module) Depends() []string {
+	return []string{"log"}
+}
+
+// Consume the log destination provided by the "log" module.
+func (m *Module) Consume(r *dx.Registry) error {
+	loggers, err := dx.Resolve[dxLog.Loggers](r)
+	if err != nil {
+		return err
+	}
+	m.logs = loggers.Writer()
+	return nil
+}
+
 // Load configuration settings from the provided viper instance.
 func (m *Module) Load(v *viper.Viper) error {
-	return v.Unmarshal(&m)
+	return v.Unmarshal(&config{Middleware: m})
+}
+
+// Moved reports the previous location of the module settings: "rpc.http.middleware".
+func (m *Module) Moved() map[string]string {
+	return map[string]string{"rpc.http.middleware": "middleware"}
+}
+
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &config{Middleware: new(Module)}
 }
 
 // Flags returns no CLI options by default.
@@ -48,6 +112,47 @@ func (m *Module) Flags(_ string) []cli.Param {
 	return []cli.Param{}
 }
 
+// Validate the middleware settings.
+func (m *Module) Validate() []dx.Issue {
+	return dx.Nest("middleware", m.validate())
+}
+
+func (m *Module) validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if m.Gzip < 0 || m.Gzip > 9 {
+		issues = append(issues, dx.Issue{
+			Key:     "gzip",
+			Message: fmt.Sprintf("invalid compression level %d; must be between 0 and 9", m.Gzip),
+		})
+	}
+	if m.Cors != nil {
+		for _, method := range m.Cors.AllowedMethods {
+			if !slices.Contains(corsMethods, strings.ToUpper(method)) {
+				issues = append(issues, dx.Issue{
+					Key:     "cors.allowed_methods",
+					Message: fmt.Sprintf("invalid HTTP method: %s", method),
+				})
+			}
+		}
+	}
+	if m.JWT != nil {
+		issues = append(issues, dx.Nest("jwt", m.JWT.Validate())...)
+	}
+	if m.APIKey != nil {
+		issues = append(issues, dx.Nest("apikey", m.APIKey.Validate())...)
+	}
+	if m.Policy != nil {
+		issues = append(issues, dx.Nest("policy", m.Policy.Validate())...)
+	}
+	if m.Access != nil {
+		issues = append(issues, dx.Nest("access_log", m.Access.Validate())...)
+	}
+	if m.Metrics != nil {
+		issues = append(issues, dx.Nest("metrics", m.Metrics.Validate())...)
+	}
+	return issues
+}
+
 // Customize the provided `*[]func(http.Handler) http.Handler` target.
 func (m *Module) Customize(target any) error {
 	// ensure provide target is of correct type
@@ -85,6 +190,41 @@ func (m *Module) Customize(target any) error {
 		}
 		nOpts = append(nOpts, mwOtel.NewMonitor(mwOtelOpts...).ServerMiddleware())
 	}
+	if m.Metrics != nil && m.Metrics.Enabled {
+		rec, err := m.Metrics.Recorder()
+		if err != nil {
+			return errors.Wrap(err, "failed to setup request metrics")
+		}
+		nOpts = append(nOpts, rec.Handler())
+	}
+	if m.Access != nil && m.Access.Enabled {
+		logger, err := m.Access.Logger(m.logs)
+		if err != nil {
+			return errors.Wrap(err, "failed to setup access logs")
+		}
+		nOpts = append(nOpts, logger.Handler())
+	}
+	authList := []auth.Authenticator{}
+	if m.JWT != nil && m.JWT.Enabled {
+		verifier, err := m.JWT.Verifier()
+		if err != nil {
+			return errors.Wrap(err, "failed to setup JWT authentication")
+		}
+		authList = append(authList, auth.Authenticator{Verifier: verifier, Exempt: m.JWT.Exempt})
+	}
+	if m.APIKey != nil && m.APIKey.Enabled {
+		verifier, err := m.APIKey.Verifier()
+		if err != nil {
+			return errors.Wrap(err, "failed to setup API key authentication")
+		}
+		authList = append(authList, auth.Authenticator{Verifier: verifier, Exempt: m.APIKey.Exempt})
+	}
+	if len(authList) > 0 {
+		nOpts = append(nOpts, auth.Handler(authList...))
+	}
+	if m.Policy != nil && m.Policy.Enabled {
+		nOpts = append(nOpts, m.Policy.Policy(m.Logger).Handler())
+	}
 	if m.Hsts != nil {
 		nOpts = append(nOpts, mwHSTS.Handler(*m.Hsts))
 	}
@@ -100,14 +240,29 @@ func (m *Module) Customize(target any) error {
 	return nil
 }
 
+// Provide the middleware functions enabled as a `[]Handler` instance;
+// the first one is the outermost.
+func (m *Module) Provide() (any, error) {
+	list := []Handler{}
+	if err := m.Customize(&list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+type config struct {
+	Middleware *Module `json:"middleware" yaml:"middleware" mapstructure:"middleware" desc:"HTTP middleware"`
+}
+
 type rateSettings struct {
-	Limit uint `json:"limit" yaml:"limit" mapstructure:"limit"`
-	Burst uint `json:"burst" yaml:"burst" mapstructure:"burst"`
+	Limit uint `json:"limit" yaml:"limit" mapstructure:"limit" desc:"requests per second"`
+	Burst uint `json:"burst" yaml:"burst" mapstructure:"burst" desc:"maximum burst size"`
 }
 
+// nolint: lll
 type otelSettings struct {
-	Enabled       bool     `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
-	NetworkEvents bool     `json:"network_events" yaml:"network_events" mapstructure:"network_events"`
-	TraceHeader   string   `json:"trace_header" yaml:"trace_header" mapstructure:"trace_header"`
-	OmitPaths     []string `json:"omit_paths" yaml:"omit_paths" mapstructure:"omit_paths"`
+	Enabled       bool     `json:"enabled" yaml:"enabled" mapstructure:"enabled" desc:"enable HTTP instrumentation"`
+	NetworkEvents bool     `json:"network_events" yaml:"network_events" mapstructure:"network_events" desc:"record read and write events"`
+	TraceHeader   string   `json:"trace_header" yaml:"trace_header" mapstructure:"trace_header" desc:"return the trace identifier on this response header"`
+	OmitPaths     []string `json:"omit_paths" yaml:"omit_paths" mapstructure:"omit_paths" desc:"paths excluded from instrumentation"`
 }
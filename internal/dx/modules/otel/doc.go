@@ -8,6 +8,31 @@ This module expects a configuration source like:
 		service_version: "0.1.0"
 		metrics_host: true
 		metrics_runtime: true
+		logs: true
+		sampling:
+			strategy: ratio # always, never or ratio
+			ratio: 0.25
+			parent_based: true
+			rate_limit: 100 # traces per second; 0 disables the limit
+			overrides:
+				- targets: [Faulty]
+					strategy: always
+				- targets: [/sample.v1.ServiceAPI/Ping, /v1/ping]
+					strategy: never
+		prometheus:
+			enabled: true
+			path: /metrics
+			port: 0 # if 0, metrics are exposed on the HTTP gateway
+			network_interface: local # local, all or a specific name
+		export:
+			output: file # stdout or file; if empty, nothing is written locally
+			file:
+				path: telemetry.jsonl
+				max_size: 50
+				max_backups: 3
+			traces: true
+			metrics: false
+			logs: true
 		collector:
 			endpoint: "" # if empty, output will be discarded
 			protocol: "grpc" # grpc or http
@@ -16,5 +41,45 @@ This module expects a configuration source like:
 		sentry:
 			dsn: "" # if empty, output will be discarded
 			environment: dev
+
+Once started, the module provides the tracer, meter and logger providers in
+use as a `*Telemetry` instance; other modules obtain it using `dx.Resolve` to
+instrument their components.
+
+When `logs` is enabled, application logs are exported to the collector along
+with traces and metrics. Messages written using a logger adjusted with the
+`log.WithContext` function include the identifiers of the active trace and
+span, so logs and traces can be correlated.
+
+Sampling decisions are taken when a trace starts. The `strategy` is used for
+new traces and, when `parent_based` is set, requests carrying a trace context
+follow the decision already taken by the caller. Overrides are evaluated in
+order and take precedence over both; a target can be a full gRPC method, an
+HTTP route, a glob pattern or just the method name. Finally, `rate_limit` caps
+the number of traces started per second, regardless of the strategy used.
+
+Since decisions are taken before the request is handled, the outcome of the
+request can't be used to keep a trace. An override can keep every trace for
+methods known to fail, like `Faulty`; keeping every trace with errors, from
+any method, requires tail sampling on the collector, for example using its
+"tail_sampling" processor with a "status_code" policy.
+
+Metrics can also be scraped by Prometheus, even when no collector is used.
+The exporter includes runtime and host metrics, RPC server metrics and any
+instrument created using the global meter provider. When `port` is 0, the
+endpoint is served by the HTTP gateway using the middleware returned by
+`MetricsHandler`; consider excluding the path from instrumentation, access
+logs and authentication. Otherwise, a dedicated server is started on the
+provided port; it only accepts local connections unless `network_interface`
+is set to "all" or a specific interface name.
+
+To debug the service without a collector, telemetry data can be written to
+the standard output or to a rotating file using `export`. Spans, metrics and
+log records are written as JSON lines; use the `echoctl telemetry view`
+command to display a captured file as trace trees.
+
+To verify the `collector` settings, run a fake collector locally using the
+`echoctl dev collector` command; the `otlptest` package provides the same
+receiver for Go tests.
 */
 package otel
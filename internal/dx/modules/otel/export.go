@@ -0,0 +1,132 @@
+package otel
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bcessa/echo-service/internal/dx"
+	otelSdk "go.bryk.io/pkg/otel/sdk"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdkLog "go.opentelemetry.io/otel/sdk/log"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Supported local export destinations.
+const (
+	ExportStdout = "stdout"
+	ExportFile   = "file"
+)
+
+// how often metrics are written when exported locally.
+const exportInterval = 15 * time.Second
+
+// nolint: lll
+type exportSettings struct {
+	Output  string              `json:"output" yaml:"output" mapstructure:"output" desc:"write telemetry data as JSON lines to: stdout or file; disabled if empty"`
+	File    *exportFileSettings `json:"file" yaml:"file" mapstructure:"file" desc:"rotating file; used when output is 'file'"`
+	Traces  bool                `json:"traces" yaml:"traces" mapstructure:"traces" desc:"export spans"`
+	Metrics bool                `json:"metrics" yaml:"metrics" mapstructure:"metrics" desc:"export metrics"`
+	Logs    bool                `json:"logs" yaml:"logs" mapstructure:"logs" desc:"export application logs"`
+}
+
+// nolint: lll
+type exportFileSettings struct {
+	Path       string `json:"path" yaml:"path" mapstructure:"path" desc:"file location"`
+	MaxSize    int    `json:"max_size" yaml:"max_size" mapstructure:"max_size" desc:"maximum size in megabytes before the file is rotated; 0 to use the default of 100"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" mapstructure:"max_backups" desc:"maximum number of rotated files retained; 0 to retain all"`
+	MaxAge     int    `json:"max_age" yaml:"max_age" mapstructure:"max_age" desc:"maximum number of days to retain rotated files; 0 to retain all"`
+	Compress   bool   `json:"compress" yaml:"compress" mapstructure:"compress" desc:"compress rotated files using gzip"`
+}
+
+func (es *exportSettings) enabled() bool {
+	return es != nil && es.Output != ""
+}
+
+func (es *exportSettings) validate() []dx.Issue {
+	issues := []dx.Issue{}
+	switch es.Output {
+	case "", ExportStdout:
+	case ExportFile:
+		if es.File == nil || es.File.Path == "" {
+			issues = append(issues, dx.Issue{Key: "otel.export.file.path", Message: "a file is required when output is 'file'"})
+			break
+		}
+		if es.File.MaxSize < 0 || es.File.MaxBackups < 0 || es.File.MaxAge < 0 {
+			issues = append(issues, dx.Issue{Key: "otel.export.file", Message: "rotation settings can't be negative"})
+		}
+	default:
+		issues = append(issues, dx.Issue{
+			Key:     "otel.export.output",
+			Message: fmt.Sprintf("invalid output %s; must be 'stdout' or 'file'", es.Output),
+		})
+	}
+	return issues
+}
+
+// exportOptions opens the local export destination and returns the
+// options required to write spans and metrics to it.
+func (m *Module) exportOptions() ([]otelSdk.Option, error) {
+	conf := m.conf.Otel.Export
+	switch conf.Output {
+	case ExportFile:
+		m.sink = &lumberjack.Logger{
+			Filename:   filepath.Clean(conf.File.Path),
+			MaxSize:    conf.File.MaxSize,
+			MaxBackups: conf.File.MaxBackups,
+			MaxAge:     conf.File.MaxAge,
+			Compress:   conf.File.Compress,
+		}
+	default:
+		m.sink = nopCloser{os.Stdout}
+	}
+	opts := []otelSdk.Option{}
+	if conf.Traces {
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(m.sink))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otelSdk.WithSpanExporter(exp))
+	}
+	if conf.Metrics {
+		exp, err := stdoutmetric.New(stdoutmetric.WithWriter(m.sink))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otelSdk.WithMetricReader(sdkMetric.NewPeriodicReader(exp,
+			sdkMetric.WithInterval(exportInterval))))
+	}
+	return opts, nil
+}
+
+// exportLogs returns a processor writing application logs to the local
+// export destination.
+func (m *Module) exportLogs() (sdkLog.Processor, error) {
+	exp, err := stdoutlog.New(stdoutlog.WithWriter(m.sink))
+	if err != nil {
+		return nil, err
+	}
+	return sdkLog.NewBatchProcessor(exp), nil
+}
+
+// closeExport closes the local export destination, if any.
+func (m *Module) closeExport() {
+	if m.sink != nil {
+		_ = m.sink.Close()
+		m.sink = nil
+	}
+}
+
+// nopCloser prevents the standard output from being closed.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
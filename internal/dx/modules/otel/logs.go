@@ -0,0 +1,76 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otelLog "go.opentelemetry.io/otel/log"
+	sdkLog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// LoggerProvider returns the provider used to export application logs
+// over OTLP; returns `nil` if logs are not exported.
+func (m *Module) LoggerProvider() otelLog.LoggerProvider {
+	if m.logs == nil {
+		return nil
+	}
+	return m.logs
+}
+
+// logsEnabled returns `true` if application logs are exported to the
+// collector, to the local export destination or to extra processors.
+func (m *Module) logsEnabled() bool {
+	conf := m.conf.Otel
+	if len(m.logExtras) > 0 {
+		return true
+	}
+	return (conf.Logs && conf.Collector.Endpoint != "") || (conf.Export.enabled() && conf.Export.Logs)
+}
+
+// newLoggerProvider returns a provider exporting logs to the collector
+// and the local export destination, using batch processors.
+func (m *Module) newLoggerProvider() (*sdkLog.LoggerProvider, error) {
+	conf := m.conf.Otel
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(conf.ServiceName),
+		semconv.ServiceVersion(conf.ServiceVersion),
+	}
+	for k, v := range conf.Attributes {
+		attrs = append(attrs, attribute.String(k, fmt.Sprint(v)))
+	}
+	opts := []sdkLog.LoggerProviderOption{
+		sdkLog.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attrs...)),
+	}
+	if conf.Logs && conf.Collector.Endpoint != "" {
+		var (
+			exp sdkLog.Exporter
+			err error
+		)
+		ctx := context.Background()
+		if conf.Collector.Protocol == "http" {
+			exp, err = otlploghttp.New(ctx, otlploghttp.WithEndpoint(conf.Collector.Endpoint), otlploghttp.WithInsecure())
+		} else {
+			exp, err = otlploggrpc.New(ctx, otlploggrpc.WithEndpoint(conf.Collector.Endpoint), otlploggrpc.WithInsecure())
+		}
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdkLog.WithProcessor(sdkLog.NewBatchProcessor(exp)))
+	}
+	if conf.Export.enabled() && conf.Export.Logs {
+		proc, err := m.exportLogs()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdkLog.WithProcessor(proc))
+	}
+	for _, proc := range m.logExtras {
+		opts = append(opts, sdkLog.WithProcessor(proc))
+	}
+	return sdkLog.NewLoggerProvider(opts...), nil
+}
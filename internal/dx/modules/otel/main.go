@@ -1,20 +1,43 @@
 package otel
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
 	"time"
 
+	"github.com/bcessa/echo-service/internal/dx"
+	"github.com/bcessa/echo-service/internal/rpc"
 	"github.com/spf13/viper"
 	"go.bryk.io/pkg/cli"
 	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
 	otelSdk "go.bryk.io/pkg/otel/sdk"
 	"go.bryk.io/pkg/otel/sentry"
+	"go.opentelemetry.io/otel"
+	otelLog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/metric"
+	sdkLog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Module to manage the settings for an `otel.Operator` instance.
 type Module struct {
-	conf struct {
-		Otel *settings `json:"otel" yaml:"otel" mapstructure:"otel"`
-	}
+	// Base logger used by the telemetry operator, optional.
+	Logger xlog.Logger
+
+	conf        config
+	telemetry   *otelSdk.Instrumentation
+	logs        *sdkLog.LoggerProvider
+	metrics     http.Handler
+	metricsPath string
+	metricsSrv  *http.Server
+	sink        io.WriteCloser
+	extras      []otelSdk.Option
+	logExtras   []sdkLog.Processor
+	mu          sync.RWMutex
 }
 
 // Name returns the default module identifier: "otel".
@@ -25,16 +48,53 @@ func (m *Module) Name() string {
 // Load configuration settings from the provided viper instance.
 func (m *Module) Load(v *viper.Viper) error {
 	if m.conf.Otel == nil {
-		m.conf.Otel = &settings{Sentry: new(sentry.Options)}
+		m.conf.Otel = defaultSettings()
 	}
 	return v.Unmarshal(&m.conf)
 }
 
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &config{Otel: defaultSettings()}
+}
+
 // Flags returns no CLI options by default.
 func (m *Module) Flags(_ string) []cli.Param {
 	return []cli.Param{}
 }
 
+// Validate the telemetry settings.
+func (m *Module) Validate() []dx.Issue {
+	issues := []dx.Issue{}
+	conf := m.conf.Otel
+	if !conf.Enabled {
+		return issues
+	}
+	if conf.ServiceName == "" {
+		issues = append(issues, dx.Issue{Key: "otel.service_name", Message: "service name is required when enabled"})
+	}
+	if conf.Logs && conf.Collector.Endpoint == "" {
+		issues = append(issues, dx.Issue{Key: "otel.logs", Message: "a collector endpoint is required to export logs"})
+	}
+	if p := conf.Collector.Protocol; p != "" && p != "grpc" && p != "http" {
+		issues = append(issues, dx.Issue{
+			Key:     "otel.collector.protocol",
+			Message: fmt.Sprintf("invalid protocol %s; must be 'grpc' or 'http'", p),
+		})
+	}
+	if conf.Sampling != nil {
+		issues = append(issues, conf.Sampling.validate()...)
+	}
+	if conf.Prometheus != nil {
+		issues = append(issues, conf.Prometheus.validate()...)
+	}
+	if conf.Export != nil {
+		issues = append(issues, conf.Export.validate()...)
+	}
+	return issues
+}
+
 // Customize the provided `*[]otel.OperatorOption` target.
 func (m *Module) Customize(target any) error {
 	// ensure provide target is of correct type
@@ -59,6 +119,9 @@ func (m *Module) Customize(target any) error {
 	if m.conf.Otel.RuntimeMetrics {
 		nOpts = append(nOpts, otelSdk.WithRuntimeMetrics(5*time.Second))
 	}
+	if m.conf.Otel.Sampling != nil {
+		nOpts = append(nOpts, otelSdk.WithSampler(m.conf.Otel.Sampling.sampler()))
+	}
 	if len(m.conf.Otel.Attributes) > 0 {
 		nOpts = append(nOpts, otelSdk.WithResourceAttributes(m.conf.Otel.Attributes))
 	}
@@ -69,8 +132,8 @@ func (m *Module) Customize(target any) error {
 		}
 		nOpts = append(nOpts, otelSdk.WithExporterOTLP(collector.Endpoint, true, nil, protocol)...)
 	}
-	if sentryOpts := m.conf.Otel.Sentry; sentryOpts.DSN != "" {
-		rep, err := sentry.NewReporter(sentryOpts)
+	if ss := m.conf.Otel.Sentry; ss.DSN != "" {
+		rep, err := sentry.NewReporter(ss.options())
 		if err == nil {
 			nOpts = append(nOpts,
 				otelSdk.WithSpanProcessor(rep.SpanProcessor()),
@@ -84,16 +147,177 @@ func (m *Module) Customize(target any) error {
 	return nil
 }
 
+// Extend the telemetry settings with additional options, for example to
+// register span processors or metric readers. Extra options are applied on
+// the next call to `Start` and discarded when the module is stopped.
+func (m *Module) Extend(opts ...otelSdk.Option) {
+	m.extras = append(m.extras, opts...)
+}
+
+// ExtendLogs registers additional processors for the application logs.
+// Like `Extend`, processors are applied on the next call to `Start` and
+// discarded when the module is stopped.
+func (m *Module) ExtendLogs(procs ...sdkLog.Processor) {
+	m.logExtras = append(m.logExtras, procs...)
+}
+
+// Start the telemetry operator based on the module's current settings.
+// If the module is disabled no operator is started. If any step fails,
+// the components already started are released.
+func (m *Module) Start() (err error) {
+	defer func() {
+		if err != nil {
+			m.release()
+		}
+	}()
+	opts := []otelSdk.Option{}
+	if err = m.Customize(&opts); err != nil {
+		return err
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	if m.Logger != nil {
+		opts = append(opts, otelSdk.WithBaseLogger(m.Logger))
+	}
+	opts = append(opts, m.extras...)
+	if m.conf.Otel.Export.enabled() {
+		exportOpts, err := m.exportOptions()
+		if err != nil {
+			return errors.Wrap(err, "failed to setup local exporters")
+		}
+		opts = append(opts, exportOpts...)
+	}
+	if prom := m.conf.Otel.Prometheus; prom != nil && prom.Enabled {
+		reader, err := m.newPrometheusReader()
+		if err != nil {
+			return errors.Wrap(err, "failed to setup prometheus exporter")
+		}
+		opts = append(opts, otelSdk.WithMetricReader(reader))
+	}
+	m.telemetry, err = otelSdk.Setup(opts...)
+	if err != nil {
+		return err
+	}
+	if prom := m.conf.Otel.Prometheus; prom != nil && prom.Enabled {
+		if err = m.serveMetrics(); err != nil {
+			return errors.Wrap(err, "failed to start metrics server")
+		}
+	}
+	if m.logsEnabled() {
+		if m.logs, err = m.newLoggerProvider(); err != nil {
+			return errors.Wrap(err, "failed to setup logs exporter")
+		}
+	}
+	return nil
+}
+
+// Telemetry provides the instrumentation components used by the
+// application; the module hands it over to other modules using
+// `dx.Resolve`.
+type Telemetry struct {
+	// Provider used to create tracers.
+	TracerProvider trace.TracerProvider
+
+	// Provider used to create meters.
+	MeterProvider metric.MeterProvider
+
+	// Provider used to export application logs; `nil` if logs are
+	// not exported.
+	LoggerProvider otelLog.LoggerProvider
+}
+
+// Provide the active instrumentation as a `*Telemetry` instance; returns
+// `nil` if the telemetry operator is not running.
+func (m *Module) Provide() (any, error) {
+	if m.telemetry == nil {
+		return nil, nil
+	}
+	return &Telemetry{
+		TracerProvider: otel.GetTracerProvider(),
+		MeterProvider:  otel.GetMeterProvider(),
+		LoggerProvider: m.LoggerProvider(),
+	}, nil
+}
+
+// Stop drains the telemetry operator and logs exporter, if any, and
+// closes the metrics endpoint and local export destination.
+func (m *Module) Stop() error {
+	m.release()
+	m.extras, m.logExtras = nil, nil
+	return nil
+}
+
+// release the components started by the module, in the reverse order
+// they are started: logs exporter, metrics server, telemetry operator
+// and local export destination.
+func (m *Module) release() {
+	if m.logs != nil {
+		_ = m.logs.Shutdown(context.Background())
+		m.logs = nil
+	}
+	m.stopMetrics()
+	if m.telemetry != nil {
+		m.telemetry.Flush(context.Background())
+		m.telemetry = nil
+	}
+	m.closeExport()
+}
+
+// apply minimal default settings.
+func defaultSettings() *settings {
+	return &settings{
+		Sentry: new(sentrySettings),
+		Sampling: &samplingSettings{
+			Strategy:    SampleAlways,
+			Ratio:       1,
+			ParentBased: true,
+		},
+		Prometheus: &prometheusSettings{Path: DefaultMetricsPath, NetInt: rpc.NetworkInterfaceLocal},
+		Export:     &exportSettings{Traces: true, Metrics: true, Logs: true},
+	}
+}
+
+type config struct {
+	Otel *settings `json:"otel" yaml:"otel" mapstructure:"otel" desc:"OpenTelemetry instrumentation"`
+}
+
+// nolint: lll
 type settings struct {
-	Enabled        bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
-	ServiceName    string `json:"service_name" yaml:"service_name" mapstructure:"service_name"`
-	ServiceVersion string `json:"service_version" yaml:"service_version" mapstructure:"service_version"`
+	Enabled        bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled" desc:"if disabled, no telemetry will be collected"`
+	ServiceName    string `json:"service_name" yaml:"service_name" mapstructure:"service_name" desc:"service name reported on all telemetry data"`
+	ServiceVersion string `json:"service_version" yaml:"service_version" mapstructure:"service_version" desc:"service version reported on all telemetry data"`
 	Collector      struct {
-		Endpoint string `json:"endpoint" yaml:"endpoint" mapstructure:"endpoint"`
-		Protocol string `json:"protocol" yaml:"protocol" mapstructure:"protocol"`
-	} `json:"collector" yaml:"collector" mapstructure:"collector"`
-	HostMetrics    bool                   `json:"metrics_host" yaml:"metrics_host" mapstructure:"metrics_host"`
-	RuntimeMetrics bool                   `json:"metrics_runtime" yaml:"metrics_runtime" mapstructure:"metrics_runtime"`
-	Attributes     map[string]interface{} `json:"attributes" yaml:"attributes" mapstructure:"attributes"`
-	Sentry         *sentry.Options        `json:"sentry" yaml:"sentry" mapstructure:"sentry"`
+		Endpoint string `json:"endpoint" yaml:"endpoint" mapstructure:"endpoint" desc:"OTLP collector endpoint; if empty, output will be discarded"`
+		Protocol string `json:"protocol" yaml:"protocol" mapstructure:"protocol" desc:"OTLP protocol: grpc or http"`
+	} `json:"collector" yaml:"collector" mapstructure:"collector" desc:"OTLP exporter settings"`
+	HostMetrics    bool                   `json:"metrics_host" yaml:"metrics_host" mapstructure:"metrics_host" desc:"collect host metrics"`
+	RuntimeMetrics bool                   `json:"metrics_runtime" yaml:"metrics_runtime" mapstructure:"metrics_runtime" desc:"collect Go runtime metrics"`
+	Logs           bool                   `json:"logs" yaml:"logs" mapstructure:"logs" desc:"export application logs to the collector, correlated with traces"`
+	Sampling       *samplingSettings      `json:"sampling" yaml:"sampling" mapstructure:"sampling" desc:"trace sampling strategy"`
+	Prometheus     *prometheusSettings    `json:"prometheus" yaml:"prometheus" mapstructure:"prometheus" desc:"expose metrics to be scraped by Prometheus"`
+	Export         *exportSettings        `json:"export" yaml:"export" mapstructure:"export" desc:"write telemetry data locally, for offline debugging"`
+	Attributes     map[string]interface{} `json:"attributes" yaml:"attributes" mapstructure:"attributes" desc:"additional resource attributes"`
+	Sentry         *sentrySettings        `json:"sentry" yaml:"sentry" mapstructure:"sentry" desc:"Sentry error reporting; if dsn is empty, output will be discarded"`
+}
+
+// nolint: lll
+type sentrySettings struct {
+	DSN                   string  `json:"dsn" yaml:"dsn" mapstructure:"dsn" desc:"Sentry project DSN" secret:"true"`
+	Environment           string  `json:"environment" yaml:"environment" mapstructure:"environment" desc:"environment reported on all events"`
+	Release               string  `json:"release" yaml:"release" mapstructure:"release" desc:"release reported on all events"`
+	PerformanceMonitoring bool    `json:"performance_monitoring" yaml:"performance_monitoring" mapstructure:"performance_monitoring" desc:"report traces to Sentry"`
+	TracesSampleRate      float64 `json:"traces_sample_rate" yaml:"traces_sample_rate" mapstructure:"traces_sample_rate" desc:"fraction of traces reported, between 0 and 1"`
+	ProfilingSampleRate   float64 `json:"profiling_sample_rate" yaml:"profiling_sample_rate" mapstructure:"profiling_sample_rate" desc:"fraction of traces profiled, between 0 and 1"`
+}
+
+func (ss *sentrySettings) options() *sentry.Options {
+	return &sentry.Options{
+		DSN:                   ss.DSN,
+		Environment:           ss.Environment,
+		Release:               ss.Release,
+		PerformanceMonitoring: ss.PerformanceMonitoring,
+		TracesSampleRate:      ss.TracesSampleRate,
+		ProfilingSampleRate:   ss.ProfilingSampleRate,
+	}
 }
@@ -0,0 +1,35 @@
+package otel
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartFailure(t *testing.T) {
+	// keep the metrics port in use so the server can't be started
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lis.Close() }()
+
+	m := new(Module)
+	m.conf.Otel = defaultSettings()
+	m.conf.Otel.Enabled = true
+	m.conf.Otel.ServiceName = "test"
+	m.conf.Otel.Prometheus.Enabled = true
+	m.conf.Otel.Prometheus.Port = lis.Addr().(*net.TCPAddr).Port
+	m.conf.Otel.Export.Output = ExportFile
+	m.conf.Otel.Export.File = &exportFileSettings{Path: filepath.Join(t.TempDir(), "telemetry.jsonl")}
+	if err = m.Start(); err == nil {
+		_ = m.Stop()
+		t.Fatal("expected the metrics server to fail")
+	}
+	if m.telemetry != nil || m.sink != nil || m.metrics != nil || m.metricsSrv != nil {
+		t.Error("components not released after a failure")
+	}
+	if tel, _ := m.Provide(); tel != nil {
+		t.Error("telemetry provided after a failure")
+	}
+}
@@ -0,0 +1,121 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bcessa/echo-service/internal/dx"
+	"github.com/bcessa/echo-service/internal/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelProm "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// DefaultMetricsPath is used to expose metrics when no path is provided.
+const DefaultMetricsPath = "/metrics"
+
+// nolint: lll
+type prometheusSettings struct {
+	Enabled bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled" desc:"expose metrics to be scraped by Prometheus"`
+	Path    string `json:"path" yaml:"path" mapstructure:"path" desc:"HTTP path used to expose metrics"`
+	Port    int    `json:"port" yaml:"port" mapstructure:"port" desc:"TCP port for a dedicated metrics server; if 0, metrics are exposed on the HTTP gateway"`
+	NetInt  string `json:"network_interface" yaml:"network_interface" mapstructure:"network_interface" desc:"network interface used by the dedicated metrics server: local, all or a specific name"`
+}
+
+func (ps *prometheusSettings) validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if !ps.Enabled {
+		return issues
+	}
+	if !strings.HasPrefix(ps.Path, "/") {
+		issues = append(issues, dx.Issue{Key: "otel.prometheus.path", Message: "path must start with '/'"})
+	}
+	if ps.Port < 0 || ps.Port > 65535 {
+		issues = append(issues, dx.Issue{Key: "otel.prometheus.port", Message: fmt.Sprintf("invalid port %d", ps.Port)})
+	}
+	return issues
+}
+
+// MetricsHandler returns an HTTP middleware exposing the collected metrics
+// in the Prometheus text format. The endpoint is only available when the
+// exporter is enabled and no dedicated port is configured; otherwise all
+// requests are passed through to the next handler.
+func (m *Module) MetricsHandler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.mu.RLock()
+			h, path := m.metrics, m.metricsPath
+			m.mu.RUnlock()
+			if h == nil || path == "" || r.URL.Path != path {
+				next.ServeHTTP(w, r)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newPrometheusReader returns a metric reader collecting all the values
+// reported using the global meter provider: runtime and host metrics,
+// RPC server metrics and custom application instruments.
+func (m *Module) newPrometheusReader() (sdkMetric.Reader, error) {
+	registry := prometheus.NewRegistry()
+	reader, err := otelProm.New(otelProm.WithRegisterer(registry))
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.metrics = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	if m.conf.Otel.Prometheus.Port == 0 {
+		m.metricsPath = m.conf.Otel.Prometheus.Path
+	}
+	m.mu.Unlock()
+	return reader, nil
+}
+
+// serveMetrics starts a dedicated HTTP server to expose metrics, if a
+// port is configured. The server listens on the loopback interface unless
+// a different network interface is configured.
+func (m *Module) serveMetrics() error {
+	conf := m.conf.Otel.Prometheus
+	if conf.Port == 0 {
+		return nil
+	}
+	addr, err := rpc.Address(conf.NetInt, conf.Port)
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(conf.Path, m.metrics)
+	m.metricsSrv = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		_ = m.metricsSrv.Serve(lis)
+	}()
+	return nil
+}
+
+// stopMetrics closes the dedicated metrics server, if any, and stops
+// exposing metrics on the HTTP gateway.
+func (m *Module) stopMetrics() {
+	if m.metricsSrv != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = m.metricsSrv.Shutdown(ctx)
+		cancel()
+		m.metricsSrv = nil
+	}
+	m.mu.Lock()
+	m.metrics, m.metricsPath = nil, ""
+	m.mu.Unlock()
+}
@@ -0,0 +1,58 @@
+package otel
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestServeMetrics(t *testing.T) {
+	// find an available port
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := lis.Addr().(*net.TCPAddr).Port
+	_ = lis.Close()
+
+	m := new(Module)
+	m.conf.Otel = defaultSettings()
+	m.conf.Otel.Prometheus.Enabled = true
+	m.conf.Otel.Prometheus.Port = port
+	m.metrics = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	if err = m.serveMetrics(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.stopMetrics()
+
+	res, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", port, DefaultMetricsPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status: %d", res.StatusCode)
+	}
+
+	// not reachable using other network interfaces
+	if ip := externalIP(); ip != "" {
+		if conn, err := net.Dial("tcp", net.JoinHostPort(ip, fmt.Sprint(port))); err == nil {
+			_ = conn.Close()
+			t.Errorf("metrics server reachable on %s", ip)
+		}
+	}
+}
+
+// externalIP returns a non-loopback IPv4 address of the host, if any.
+func externalIP() string {
+	addrs, _ := net.InterfaceAddrs()
+	for _, addr := range addrs {
+		if ip, ok := addr.(*net.IPNet); ok && !ip.IP.IsLoopback() && ip.IP.To4() != nil {
+			return ip.IP.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package otel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bcessa/echo-service/internal/dx"
+	"github.com/bcessa/echo-service/internal/request"
+	"go.opentelemetry.io/otel/attribute"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// Supported sampling strategies.
+const (
+	SampleAlways = "always"
+	SampleNever  = "never"
+	SampleRatio  = "ratio"
+)
+
+// nolint: lll
+type samplingSettings struct {
+	Strategy    string             `json:"strategy" yaml:"strategy" mapstructure:"strategy" desc:"head sampling strategy: always, never or ratio"`
+	Ratio       float64            `json:"ratio" yaml:"ratio" mapstructure:"ratio" desc:"fraction of traces sampled when using the 'ratio' strategy"`
+	ParentBased bool               `json:"parent_based" yaml:"parent_based" mapstructure:"parent_based" desc:"respect the sampling decision of the parent span, if any"`
+	RateLimit   float64            `json:"rate_limit" yaml:"rate_limit" mapstructure:"rate_limit" desc:"maximum number of traces started per second; 0 disables the limit"`
+	Overrides   []samplingOverride `json:"overrides" yaml:"overrides" mapstructure:"overrides" desc:"strategies used for specific gRPC methods or HTTP routes"`
+}
+
+// nolint: lll
+type samplingOverride struct {
+	Targets  []string `json:"targets" yaml:"targets" mapstructure:"targets" desc:"gRPC methods or HTTP routes covered by the override; for example: /sample.v1.ServiceAPI/Ping or Faulty"`
+	Strategy string   `json:"strategy" yaml:"strategy" mapstructure:"strategy" desc:"sampling strategy: always, never or ratio"`
+	Ratio    float64  `json:"ratio" yaml:"ratio" mapstructure:"ratio" desc:"fraction of traces sampled when using the 'ratio' strategy"`
+}
+
+func (ss *samplingSettings) validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if err := checkStrategy(ss.Strategy, ss.Ratio); err != "" {
+		issues = append(issues, dx.Issue{Key: "otel.sampling", Message: err})
+	}
+	if ss.RateLimit < 0 {
+		issues = append(issues, dx.Issue{Key: "otel.sampling.rate_limit", Message: "rate limit can't be negative"})
+	}
+	for i, o := range ss.Overrides {
+		key := fmt.Sprintf("otel.sampling.overrides[%d]", i)
+		if len(o.Targets) == 0 {
+			issues = append(issues, dx.Issue{Key: key, Message: "at least one target is required"})
+		}
+		for _, target := range o.Targets {
+			if err := request.Validate(target); err != nil {
+				issues = append(issues, dx.Issue{Key: key, Message: err.Error()})
+			}
+		}
+		if err := checkStrategy(o.Strategy, o.Ratio); err != "" {
+			issues = append(issues, dx.Issue{Key: key, Message: err})
+		}
+	}
+	return issues
+}
+
+// sampler returns the trace sampler described by the settings. Overrides
+// are evaluated first, in order, and take precedence over the parent
+// span decision.
+func (ss *samplingSettings) sampler() sdkTrace.Sampler {
+	sm := &sampler{base: strategy(ss.Strategy, ss.Ratio)}
+	if ss.ParentBased {
+		sm.base = sdkTrace.ParentBased(sm.base)
+	}
+	for _, o := range ss.Overrides {
+		sm.overrides = append(sm.overrides, override{targets: o.Targets, sampler: strategy(o.Strategy, o.Ratio)})
+	}
+	if ss.RateLimit > 0 {
+		burst := int(ss.RateLimit)
+		if burst < 1 {
+			burst = 1
+		}
+		sm.limit = rate.NewLimiter(rate.Limit(ss.RateLimit), burst)
+	}
+	return sm
+}
+
+type override struct {
+	targets []string
+	sampler sdkTrace.Sampler
+}
+
+// sampler applies per-target overrides over a base strategy, and caps
+// the number of traces started per second.
+type sampler struct {
+	base      sdkTrace.Sampler
+	overrides []override
+	limit     *rate.Limiter
+}
+
+func (s *sampler) ShouldSample(p sdkTrace.SamplingParameters) sdkTrace.SamplingResult {
+	res := s.decide(p)
+	if res.Decision != sdkTrace.RecordAndSample || s.limit == nil {
+		return res
+	}
+
+	// only spans starting a trace on this service consume the limit; local
+	// child spans must follow the decision taken for their parent
+	parent := trace.SpanContextFromContext(p.ParentContext)
+	if parent.IsValid() && !parent.IsRemote() {
+		return res
+	}
+	if !s.limit.Allow() {
+		res.Decision = sdkTrace.Drop
+		res.Attributes = nil
+	}
+	return res
+}
+
+func (s *sampler) Description() string {
+	desc := []string{s.base.Description()}
+	for _, o := range s.overrides {
+		desc = append(desc, fmt.Sprintf("%s=%s", strings.Join(o.targets, ","), o.sampler.Description()))
+	}
+	if s.limit != nil {
+		desc = append(desc, fmt.Sprintf("RateLimit{%g}", float64(s.limit.Limit())))
+	}
+	return fmt.Sprintf("Composite{%s}", strings.Join(desc, ";"))
+}
+
+func (s *sampler) decide(p sdkTrace.SamplingParameters) sdkTrace.SamplingResult {
+	names := spanTargets(p)
+	for _, o := range s.overrides {
+		for _, pattern := range o.targets {
+			for _, name := range names {
+				if request.Match(pattern, name) {
+					return o.sampler.ShouldSample(p)
+				}
+			}
+		}
+	}
+	return s.base.ShouldSample(p)
+}
+
+// spanTargets returns the gRPC method and HTTP route values that can be
+// used to match a span against the sampling overrides.
+func spanTargets(p sdkTrace.SamplingParameters) []string {
+	list := []string{p.Name}
+	if i := strings.Index(p.Name, " "); i > 0 {
+		list = append(list, p.Name[i+1:]) // "GET /v1/ping"
+	} else if !strings.HasPrefix(p.Name, "/") {
+		list = append(list, "/"+p.Name) // "sample.v1.ServiceAPI/Ping"
+	}
+	var service, method string
+	for _, kv := range p.Attributes {
+		switch kv.Key {
+		case "rpc.service":
+			service = kv.Value.AsString()
+		case "rpc.method":
+			method = kv.Value.AsString()
+		case "http.route", "url.path", "http.target":
+			if kv.Value.Type() == attribute.STRING {
+				list = append(list, kv.Value.AsString())
+			}
+		}
+	}
+	if service != "" && method != "" {
+		list = append(list, fmt.Sprintf("/%s/%s", service, method))
+	}
+	return list
+}
+
+func strategy(name string, ratio float64) sdkTrace.Sampler {
+	switch name {
+	case SampleNever:
+		return sdkTrace.NeverSample()
+	case SampleRatio:
+		return sdkTrace.TraceIDRatioBased(ratio)
+	default:
+		return sdkTrace.AlwaysSample()
+	}
+}
+
+func checkStrategy(name string, ratio float64) string {
+	switch name {
+	case "", SampleAlways, SampleNever:
+		return ""
+	case SampleRatio:
+		if ratio < 0 || ratio > 1 {
+			return "ratio must be between 0 and 1"
+		}
+		return ""
+	default:
+		return fmt.Sprintf("invalid strategy %s; must be 'always', 'never' or 'ratio'", name)
+	}
+}
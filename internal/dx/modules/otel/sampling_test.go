@@ -0,0 +1,205 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSamplerDecide(t *testing.T) {
+	overrides := []samplingOverride{
+		{Targets: []string{"Faulty", "/v1/ping"}, Strategy: SampleNever},
+		{Targets: []string{"POST /v1/echo"}, Strategy: SampleNever},
+		{Targets: []string{"/sample.v1.ServiceAPI/Slow"}, Strategy: SampleAlways},
+	}
+	tests := []struct {
+		name     string
+		settings samplingSettings
+		params   sdkTrace.SamplingParameters
+		sampled  bool
+	}{
+		{
+			name:     "base strategy",
+			settings: samplingSettings{Strategy: SampleAlways, Overrides: overrides},
+			params:   params(context.Background(), "/sample.v1.ServiceAPI/Echo"),
+			sampled:  true,
+		},
+		{
+			name:     "method name",
+			settings: samplingSettings{Strategy: SampleAlways, Overrides: overrides},
+			params:   params(context.Background(), "sample.v1.ServiceAPI/Faulty"),
+		},
+		{
+			name:     "rpc attributes",
+			settings: samplingSettings{Strategy: SampleAlways, Overrides: overrides},
+			params: params(context.Background(), "faulty call",
+				attribute.String("rpc.service", "sample.v1.ServiceAPI"),
+				attribute.String("rpc.method", "Faulty")),
+		},
+		{
+			name:     "HTTP route on span name",
+			settings: samplingSettings{Strategy: SampleAlways, Overrides: overrides},
+			params:   params(context.Background(), "GET /v1/ping"),
+		},
+		{
+			name:     "HTTP route attribute",
+			settings: samplingSettings{Strategy: SampleAlways, Overrides: overrides},
+			params:   params(context.Background(), "GET", attribute.String("url.path", "/v1/ping")),
+		},
+		{
+			name:     "HTTP route for a different method",
+			settings: samplingSettings{Strategy: SampleAlways, Overrides: overrides},
+			params:   params(context.Background(), "GET /v1/echo"),
+			sampled:  true,
+		},
+		{
+			name:     "HTTP route and method",
+			settings: samplingSettings{Strategy: SampleAlways, Overrides: overrides},
+			params:   params(context.Background(), "POST /v1/echo"),
+		},
+		{
+			name:     "override over base strategy",
+			settings: samplingSettings{Strategy: SampleNever, Overrides: overrides},
+			params:   params(context.Background(), "/sample.v1.ServiceAPI/Slow"),
+			sampled:  true,
+		},
+		{
+			name:     "sampled parent",
+			settings: samplingSettings{Strategy: SampleNever, ParentBased: true},
+			params:   params(parent(true, true), "/sample.v1.ServiceAPI/Echo"),
+			sampled:  true,
+		},
+		{
+			name:     "parent not sampled",
+			settings: samplingSettings{Strategy: SampleAlways, ParentBased: true},
+			params:   params(parent(true, false), "/sample.v1.ServiceAPI/Echo"),
+		},
+		{
+			name:     "parent ignored",
+			settings: samplingSettings{Strategy: SampleAlways},
+			params:   params(parent(true, false), "/sample.v1.ServiceAPI/Echo"),
+			sampled:  true,
+		},
+		{
+			name:     "override over parent decision",
+			settings: samplingSettings{Strategy: SampleAlways, ParentBased: true, Overrides: overrides},
+			params:   params(parent(true, true), "/sample.v1.ServiceAPI/Faulty"),
+		},
+		{
+			name:     "ratio",
+			settings: samplingSettings{Strategy: SampleRatio, Ratio: 0},
+			params:   params(context.Background(), "/sample.v1.ServiceAPI/Echo"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.settings.sampler().ShouldSample(tt.params)
+			if sampled := res.Decision == sdkTrace.RecordAndSample; sampled != tt.sampled {
+				t.Errorf("expected sampled=%v, got %v", tt.sampled, res.Decision)
+			}
+		})
+	}
+}
+
+func TestSamplerRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   float64
+		ctx     context.Context
+		sampled int // spans sampled out of 5
+	}{
+		{name: "root spans", limit: 2, ctx: context.Background(), sampled: 2},
+		{name: "remote parent", limit: 2, ctx: parent(true, true), sampled: 2},
+		{name: "local parent", limit: 2, ctx: parent(false, true), sampled: 5},
+		{name: "minimum burst", limit: 0.5, ctx: context.Background(), sampled: 1},
+		{name: "disabled", limit: 0, ctx: context.Background(), sampled: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := samplingSettings{Strategy: SampleAlways, RateLimit: tt.limit}
+			sm := settings.sampler()
+			sampled := 0
+			for range 5 {
+				res := sm.ShouldSample(params(tt.ctx, "/sample.v1.ServiceAPI/Echo"))
+				if res.Decision == sdkTrace.RecordAndSample {
+					sampled++
+				} else if res.Attributes != nil {
+					t.Error("dropped spans must not include attributes")
+				}
+			}
+			if sampled != tt.sampled {
+				t.Errorf("expected %d spans sampled, got %d", tt.sampled, sampled)
+			}
+		})
+	}
+
+	t.Run("dropped spans don't consume the limit", func(t *testing.T) {
+		settings := samplingSettings{
+			Strategy:  SampleAlways,
+			RateLimit: 1,
+			Overrides: []samplingOverride{{Targets: []string{"Ping"}, Strategy: SampleNever}},
+		}
+		sm := settings.sampler()
+		_ = sm.ShouldSample(params(context.Background(), "/sample.v1.ServiceAPI/Ping"))
+		res := sm.ShouldSample(params(context.Background(), "/sample.v1.ServiceAPI/Echo"))
+		if res.Decision != sdkTrace.RecordAndSample {
+			t.Error("expected span to be sampled")
+		}
+	})
+}
+
+func TestSamplingValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings samplingSettings
+		issues   int
+	}{
+		{name: "defaults", issues: 0},
+		{name: "invalid strategy", settings: samplingSettings{Strategy: "sometimes"}, issues: 1},
+		{name: "invalid ratio", settings: samplingSettings{Strategy: SampleRatio, Ratio: 1.5}, issues: 1},
+		{name: "negative rate limit", settings: samplingSettings{RateLimit: -1}, issues: 1},
+		{
+			name: "invalid overrides",
+			settings: samplingSettings{Overrides: []samplingOverride{
+				{Strategy: SampleNever},
+				{Targets: []string{"FETCH /v1/ping", "/v1/[ping"}, Strategy: SampleNever},
+				{Targets: []string{"Ping"}, Strategy: SampleRatio, Ratio: -1},
+			}},
+			issues: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if issues := tt.settings.validate(); len(issues) != tt.issues {
+				t.Errorf("expected %d issues, got %v", tt.issues, issues)
+			}
+		})
+	}
+}
+
+func params(ctx context.Context, name string, attrs ...attribute.KeyValue) sdkTrace.SamplingParameters {
+	return sdkTrace.SamplingParameters{
+		ParentContext: ctx,
+		TraceID:       trace.TraceID{0x01},
+		Name:          name,
+		Kind:          trace.SpanKindServer,
+		Attributes:    attrs,
+	}
+}
+
+// parent returns a context including a parent span with the provided
+// settings.
+func parent(remote, sampled bool) context.Context {
+	sc := trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x01},
+		SpanID:  trace.SpanID{0x01},
+		Remote:  remote,
+	}
+	if sampled {
+		sc.TraceFlags = trace.FlagsSampled
+	}
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(sc))
+}
@@ -0,0 +1,47 @@
+/*
+Package policy provides a `dx` module to manage authorization policies.
+
+This module expects a configuration source like:
+
+	policy:
+		enabled: true
+		dry_run: false
+		default: deny
+		rules:
+			- name: public
+				targets:
+					- /sample.v1.ServiceAPI/Ping
+					- /sample.v1.ServiceAPI/Ready
+					- GET /v1/ping
+					- GET /v1/ready
+			- name: echo
+				targets: [/sample.v1.ServiceAPI/Echo]
+				scopes: [echo:write]
+			- name: admins
+				targets: [/sample.v1.ServiceAPI/*]
+				roles: [admin]
+				claims:
+					tenant: acme
+
+Policies are applied after authentication, using the identities verified
+for the request. Rules are evaluated in order and the first one covering
+the target is used; targets not covered by any rule use the `default`
+action. Denied requests are rejected with a `PermissionDenied` status, or
+a 403 HTTP status.
+
+A caller satisfies a rule if it has at least one of the roles, all the
+scopes and all the claims required. Roles and scopes are taken from the
+"roles" and "scope" token claims, or the scopes of an API key. A rule
+without requirements allows every caller.
+
+Targets match the full gRPC method name, the method name alone, or an HTTP
+route; patterns are supported, like "/sample.v1.ServiceAPI/*". HTTP routes
+can be restricted to a request method, like "POST /v1/echo". Method names
+don't match HTTP routes; when the policy is used as HTTP middleware, public
+routes must be listed explicitly.
+
+When `dry_run` is enabled decisions are only reported on the logs; use it
+to verify new policies before enforcing them. Policies can also be checked
+against sample identities using the `policy check` command.
+*/
+package policy
@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"github.com/bcessa/echo-service/internal/auth"
+	"github.com/bcessa/echo-service/internal/dx"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
+)
+
+// Module to manage authorization policy settings.
+// nolint: lll
+type Module struct {
+	Enabled bool        `json:"enabled" yaml:"enabled" mapstructure:"enabled" desc:"restrict the callers allowed to access each method or route"`
+	DryRun  bool        `json:"dry_run" yaml:"dry_run" mapstructure:"dry_run" desc:"only report decisions; requests are never rejected"`
+	Default string      `json:"default" yaml:"default" mapstructure:"default" desc:"action for targets not covered by any rule: allow or deny"`
+	Rules   []auth.Rule `json:"rules" yaml:"rules" mapstructure:"rules" desc:"access rules; the first one matching the target is applied"`
+}
+
+// Name returns the default module identifier: "policy".
+func (m *Module) Name() string {
+	return "policy"
+}
+
+// Load configuration settings from the provided viper instance.
+func (m *Module) Load(v *viper.Viper) error {
+	return v.Unmarshal(&m)
+}
+
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &Module{Default: auth.PolicyDeny}
+}
+
+// Flags returns no CLI options by default.
+func (m *Module) Flags(_ string) []cli.Param {
+	return []cli.Param{}
+}
+
+// Customize is not supported by the module.
+func (m *Module) Customize(_ any) error {
+	return errors.New("invalid operation on 'policy' module")
+}
+
+// Validate the policy settings.
+func (m *Module) Validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if !m.Enabled {
+		return issues
+	}
+	if err := m.Policy(nil).Validate(); err != nil {
+		issues = append(issues, dx.Issue{Key: "rules", Message: err.Error()})
+	}
+	return issues
+}
+
+// Policy returns an authorization policy based on the module settings.
+// Decisions are reported using `log`, if provided: denied requests as
+// warnings and allowed requests as debug messages.
+func (m *Module) Policy(log xlog.Logger) *auth.Policy {
+	p := &auth.Policy{
+		Rules:   m.Rules,
+		Default: m.Default,
+		DryRun:  m.DryRun,
+	}
+	if log != nil {
+		p.Report = func(d auth.Decision) { report(log, d) }
+	}
+	return p
+}
+
+func report(log xlog.Logger, d auth.Decision) {
+	fields := xlog.Fields{
+		"policy.target":  d.Target,
+		"policy.allowed": d.Allowed,
+		"policy.dry_run": d.DryRun,
+	}
+	if d.Rule != "" {
+		fields["policy.rule"] = d.Rule
+	}
+	if d.Subject != "" {
+		fields["policy.subject"] = d.Subject
+	}
+	switch {
+	case d.Allowed:
+		log.WithFields(fields).Debug("request allowed by policy")
+	case d.DryRun:
+		log.WithFields(fields).Warning("request would be denied by policy")
+	default:
+		log.WithFields(fields).Warning("request denied by policy")
+	}
+}
@@ -0,0 +1,49 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/bcessa/echo-service/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// peerUnary returns an interceptor that attaches the identity of clients
+// authenticated with x509 certificates to the request context. Must only
+// be used when client certificates are verified during the handshake.
+func peerUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withPeer(ctx), req)
+	}
+}
+
+// peerStream returns an interceptor that attaches the identity of clients
+// authenticated with x509 certificates to the stream context. Must only
+// be used when client certificates are verified during the handshake.
+func peerStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: withPeer(ss.Context())})
+	}
+}
+
+// withPeer returns a context including the identity of the client, if
+// a certificate was presented during the handshake.
+func withPeer(ctx context.Context) context.Context {
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+			return auth.WithIdentity(ctx, auth.FromCertificate(info.State.PeerCertificates[0]))
+		}
+	}
+	return ctx
+}
+
+// wrappedStream allows to adjust the context of a server stream.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ws *wrappedStream) Context() context.Context {
+	return ws.ctx
+}
@@ -13,9 +13,38 @@ This module expects a configuration source like:
 			connections: 1000
 			requests: 50
 			rate: 500
-		tls: {}
 		http:
 			enabled: true
-			middleware: {}
+			in_process: true
+			gateway_client:
+				server_name: ""
+				custom_ca: []
+				cert: ""
+				key: ""
+				passphrase: ""
+
+The module depends on the "log", "otel", "tls", "middleware" and "handler"
+modules. The server exposes the service provided by the "handler" module,
+logs using the "rpc" component logger provided by the "log" module, writes
+access logs to the same destination, and records telemetry using the
+providers of the "otel" module. When TLS is enabled the server uses the
+credentials provided by the "tls" module, and the HTTP gateway applies the
+middleware provided by the "middleware" module.
+
+By default the HTTP gateway connects to the server using an in-process
+channel; requests don't leave the process and TLS settings for the gateway
+are not required. Set `in_process` to false to connect through the server
+network listener instead.
+
+Requests forwarded by the in-process gateway are not included on the gRPC
+access logs, since they are logged as HTTP requests by the access logs of
+the "middleware" module.
+
+When connecting through the network listener with TLS enabled, the gateway
+verifies the server certificate. By default the server CAs and its own
+certificate are trusted, and the first name included on the certificate is
+expected; use the `gateway_client` section to adjust these values. When the
+server requires client certificates, a certificate for the gateway is
+required; its private key can be encrypted using `passphrase`.
 */
 package rpc
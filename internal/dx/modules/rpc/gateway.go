@@ -0,0 +1,240 @@
+package rpc
+
+import (
+	"context"
+	stdTLS "crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"slices"
+	"sync"
+
+	"github.com/bcessa/echo-service/internal/dx"
+	dxTLS "github.com/bcessa/echo-service/internal/dx/modules/tls"
+	"go.bryk.io/pkg/errors"
+	"google.golang.org/grpc/credentials"
+)
+
+// TLS settings for the gateway internal client.
+// nolint: lll
+type gwClientSettings struct {
+	ServerName string   `json:"server_name" yaml:"server_name" mapstructure:"server_name" desc:"name used to verify the server certificate; by default the first name included on it"`
+	CustomCA   []string `json:"custom_ca" yaml:"custom_ca" mapstructure:"custom_ca" desc:"CAs used to verify the server certificate; by default the server CAs and its own certificate"`
+	Cert       string   `json:"cert" yaml:"cert" mapstructure:"cert" desc:"client certificate; required when the server verifies client certificates"`
+	Key        string   `json:"key" yaml:"key" mapstructure:"key" desc:"client private key; path to a PEM file or base64-encoded PEM" secret:"true"`
+	Passphrase string   `json:"passphrase" yaml:"passphrase" mapstructure:"passphrase" desc:"passphrase for an encrypted client private key: env:NAME, file:PATH or secret:NAME" secret:"true"`
+}
+
+// Validate the gateway client settings.
+func (gc *gwClientSettings) Validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if gc == nil {
+		gc = new(gwClientSettings)
+	}
+	if (gc.Cert == "") != (gc.Key == "") {
+		issues = append(issues, dx.Issue{Key: "key", Message: "certificate and private key must be provided together"})
+	}
+	if gc.Key != "" {
+		if _, err := dxTLS.LoadKey(gc.Key, gc.Passphrase); err != nil {
+			issues = append(issues, dx.Issue{Key: "key", Message: err.Error()})
+		}
+	}
+	for key, list := range map[string][]string{
+		"cert":      {gc.Cert},
+		"custom_ca": gc.CustomCA,
+	} {
+		for _, value := range list {
+			if value == "" {
+				continue
+			}
+			if _, err := dxTLS.LoadPEM(value); err != nil {
+				issues = append(issues, dx.Issue{Key: key, Message: err.Error()})
+			}
+		}
+	}
+	return issues
+}
+
+// check the gateway client settings are compatible with the server TLS
+// settings `tc`.
+func (gc *gwClientSettings) check(tc *dxTLS.Settings) error {
+	if gc == nil {
+		gc = new(gwClientSettings)
+	}
+	required := tc.ClientAuth == stdTLS.RequireAnyClientCert || tc.ClientAuth == stdTLS.RequireAndVerifyClientCert
+	if required && gc.Cert == "" {
+		return errors.New("a gateway client certificate is required when the server requires client certificates")
+	}
+	return nil
+}
+
+// clientConfig returns the TLS configuration used by the gateway internal
+// client to securely connect to the server; `tc` are the server TLS
+// settings. The server TLS policy is also applied to the client.
+func (gc *gwClientSettings) clientConfig(tc *dxTLS.Settings) (*stdTLS.Config, error) {
+	if gc == nil {
+		gc = new(gwClientSettings)
+	}
+
+	// server verification
+	cs := *tc
+	if len(gc.CustomCA) > 0 {
+		cs.CustomCAs = [][]byte{}
+		for _, ca := range gc.CustomCA {
+			data, err := dxTLS.LoadPEM(ca)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to load gateway client CA")
+			}
+			cs.CustomCAs = append(cs.CustomCAs, data)
+		}
+	} else {
+		cs = trustServer(tc)
+	}
+	conf, err := cs.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf.ServerName = gc.ServerName
+	if conf.ServerName == "" {
+		conf.ServerName = serverName(tc.Certificate)
+	}
+
+	// client authentication
+	if gc.Cert != "" {
+		cert, err := dxTLS.LoadPEM(gc.Cert)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load gateway client certificate")
+		}
+		key, err := dxTLS.LoadKey(gc.Key, gc.Passphrase)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load gateway client private key")
+		}
+		pair, err := stdTLS.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid gateway client certificate")
+		}
+		conf.Certificates = []stdTLS.Certificate{pair}
+	}
+	return conf, nil
+}
+
+// credentials returns the credentials used by the gateway internal client
+// to support certificate rotation, based on `conf`. The client certificate
+// is provided by `client`, if any; unless custom CAs are set, the server
+// certificate is verified using the material currently used by `server`.
+func (gc *gwClientSettings) credentials(conf *stdTLS.Config, server, client *dxTLS.Watcher) credentials.TransportCredentials {
+	if gc == nil {
+		gc = new(gwClientSettings)
+	}
+	conf = conf.Clone()
+	if client != nil {
+		conf.Certificates = nil
+		conf.GetClientCertificate = client.GetClientCertificate
+	}
+	if len(gc.CustomCA) > 0 {
+		return credentials.NewTLS(conf)
+	}
+	return &serverCreds{base: conf, server: server}
+}
+
+// serverCreds verify the server certificate using the material currently
+// used by the server; the trusted CAs are only loaded again when the
+// server material is rotated.
+type serverCreds struct {
+	base   *stdTLS.Config
+	server *dxTLS.Watcher
+	loaded *dxTLS.Settings
+	creds  credentials.TransportCredentials
+	mu     sync.Mutex
+}
+
+func (sc *serverCreds) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	creds, err := sc.current()
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	return creds.ClientHandshake(ctx, authority, conn)
+}
+
+func (sc *serverCreds) ServerHandshake(_ net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("not supported by the gateway client")
+}
+
+func (sc *serverCreds) Info() credentials.ProtocolInfo {
+	return credentials.NewTLS(sc.base).Info()
+}
+
+func (sc *serverCreds) Clone() credentials.TransportCredentials {
+	return &serverCreds{base: sc.base.Clone(), server: sc.server}
+}
+
+func (sc *serverCreds) OverrideServerName(name string) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.base.ServerName = name
+	sc.loaded = nil
+	return nil
+}
+
+// current returns the credentials trusting the server material currently
+// in use.
+func (sc *serverCreds) current() (credentials.TransportCredentials, error) {
+	settings := sc.server.Settings()
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if settings == sc.loaded {
+		return sc.creds, nil
+	}
+	ts := trustServer(settings)
+	tc, err := ts.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf := sc.base.Clone()
+	conf.RootCAs = tc.RootCAs
+	sc.creds, sc.loaded = credentials.NewTLS(conf), settings
+	return sc.creds, nil
+}
+
+// module returns the TLS module used to watch the client certificate and
+// private key.
+func (gc *gwClientSettings) module() *dxTLS.Module {
+	return &dxTLS.Module{
+		Enabled:    true,
+		Cert:       gc.Cert,
+		Key:        gc.Key,
+		Passphrase: gc.Passphrase,
+	}
+}
+
+// trustServer returns a copy of the server settings trusting the server
+// certificate directly; this supports self-signed and temporary certificates.
+func trustServer(tc *dxTLS.Settings) dxTLS.Settings {
+	cs := *tc
+	cs.CustomCAs = append(slices.Clone(tc.CustomCAs), tc.Certificate)
+	return cs
+}
+
+// serverName returns the first name included on the PEM-encoded certificate;
+// "localhost" is used if no name is available.
+func serverName(cert []byte) string {
+	block, _ := pem.Decode(cert)
+	if block == nil {
+		return "localhost"
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "localhost"
+	}
+	switch {
+	case len(leaf.DNSNames) > 0:
+		return leaf.DNSNames[0]
+	case len(leaf.IPAddresses) > 0:
+		return leaf.IPAddresses[0].String()
+	case leaf.Subject.CommonName != "":
+		return leaf.Subject.CommonName
+	default:
+		return "localhost"
+	}
+}
@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"context"
+	stdTLS "crypto/tls"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dxTLS "github.com/bcessa/echo-service/internal/dx/modules/tls"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestGatewayClient(t *testing.T) {
+	server := selfSigned(t, "localhost")
+	other := selfSigned(t, "localhost")
+
+	tests := []struct {
+		name     string
+		client   gwClientSettings
+		server   *dxTLS.KeyPair // certificate presented by the server
+		accepted bool
+	}{
+		{name: "server certificate", server: server, accepted: true},
+		{name: "different server certificate", server: other},
+		{name: "wrong server name", client: gwClientSettings{ServerName: "example.com"}, server: server},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := tt.client.clientConfig(serverSettings(server))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = handshake(t, tt.server, credentials.NewTLS(conf))
+			if accepted := err == nil; accepted != tt.accepted {
+				t.Fatalf("expected accepted=%v, got %v", tt.accepted, err)
+			}
+		})
+	}
+}
+
+func TestGatewayClientRotation(t *testing.T) {
+	dir := t.TempDir()
+	cert, key := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	first := selfSigned(t, "localhost")
+	if err := first.Save(cert, key, false); err != nil {
+		t.Fatal(err)
+	}
+	w, err := dxTLS.NewWatcher(&dxTLS.Module{
+		Enabled: true,
+		Cert:    cert,
+		Key:     key,
+		Preset:  dxTLS.PresetIntermediate,
+	}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Close() }()
+
+	gc := new(gwClientSettings)
+	conf, err := gc.clientConfig(w.Settings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, ok := gc.credentials(conf, w, nil).(*serverCreds)
+	if !ok {
+		t.Fatal("expected credentials trusting the server material")
+	}
+	if err = handshake(t, first, sc); err != nil {
+		t.Fatal(err)
+	}
+	loaded, _ := sc.current()
+	if again, _ := sc.current(); again != loaded {
+		t.Error("trusted CAs loaded again without a rotation")
+	}
+
+	// rotate the server certificate
+	prev := w.Settings()
+	second := selfSigned(t, "localhost")
+	if err = second.Save(cert, key, true); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Settings() == prev {
+		if time.Now().After(deadline) {
+			t.Fatal("certificate not rotated")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err = handshake(t, second, sc); err != nil {
+		t.Fatalf("rotated certificate rejected: %s", err)
+	}
+	if rotated, _ := sc.current(); rotated == loaded {
+		t.Error("trusted CAs not loaded again after a rotation")
+	}
+	if err = handshake(t, first, sc); err == nil {
+		t.Error("previous certificate accepted after a rotation")
+	}
+}
+
+// handshake connects to a local TLS server presenting the `server`
+// certificate and returns the error reported by the client, if any.
+func handshake(t *testing.T, server *dxTLS.KeyPair, creds credentials.TransportCredentials) error {
+	t.Helper()
+	pair, err := stdTLS.X509KeyPair(server.Certificate, server.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := stdTLS.Listen("tcp", "127.0.0.1:0", &stdTLS.Config{
+		Certificates: []stdTLS.Certificate{pair},
+		NextProtos:   []string{"h2"}, // required by gRPC clients
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lis.Close() }()
+	go func() {
+		sc, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = sc.Close() }()
+		_ = sc.SetDeadline(time.Now().Add(5 * time.Second))
+		_ = sc.(*stdTLS.Conn).Handshake()
+	}()
+	cc, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := creds.ClientHandshake(ctx, lis.Addr().String(), cc)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// serverSettings returns the server TLS settings using the `kp` certificate.
+func serverSettings(kp *dxTLS.KeyPair) *dxTLS.Settings {
+	return &dxTLS.Settings{Certificate: kp.Certificate, PrivateKey: kp.PrivateKey}
+}
+
+// selfSigned returns a self-signed server certificate for `name`.
+func selfSigned(t *testing.T, name string) *dxTLS.KeyPair {
+	t.Helper()
+	kp, err := dxTLS.SelfSigned(dxTLS.CertificateOptions{
+		CommonName: name,
+		DNSNames:   []string{name},
+		Lifetime:   time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
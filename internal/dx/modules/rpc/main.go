@@ -1,15 +1,33 @@
 package rpc
 
 import (
+	stdTLS "crypto/tls"
 	"fmt"
+	"io"
+	"net"
+	"sync"
 
+	"github.com/bcessa/echo-service/internal/accesslog"
+	"github.com/bcessa/echo-service/internal/auth"
+	"github.com/bcessa/echo-service/internal/dx"
+	dxAccess "github.com/bcessa/echo-service/internal/dx/modules/accesslog"
+	dxKey "github.com/bcessa/echo-service/internal/dx/modules/apikey"
+	dxJWT "github.com/bcessa/echo-service/internal/dx/modules/jwt"
+	dxLog "github.com/bcessa/echo-service/internal/dx/modules/log"
+	dxMetrics "github.com/bcessa/echo-service/internal/dx/modules/metrics"
 	dxMW "github.com/bcessa/echo-service/internal/dx/modules/middleware"
+	dxOtel "github.com/bcessa/echo-service/internal/dx/modules/otel"
+	dxPolicy "github.com/bcessa/echo-service/internal/dx/modules/policy"
 	dxTLS "github.com/bcessa/echo-service/internal/dx/modules/tls"
+	"github.com/bcessa/echo-service/internal/metrics"
+	"github.com/bcessa/echo-service/internal/rpc"
 	"github.com/spf13/viper"
 	"go.bryk.io/pkg/cli"
 	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
 	mwRecovery "go.bryk.io/pkg/net/middleware/recovery"
-	"go.bryk.io/pkg/net/rpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
 )
 
 const (
@@ -19,9 +37,27 @@ const (
 
 // Module to manage the settings for a `rpc.Server` instance.
 type Module struct {
-	conf struct {
-		RPC *settings `json:"rpc" yaml:"rpc" mapstructure:"rpc"`
-	}
+	// Logger used to report policy decisions and certificate rotations;
+	// if not set, the "rpc" component logger provided by the "log" module
+	// is used.
+	Logger xlog.Logger
+
+	// Listener used by the server instead of the port or UNIX socket
+	// settings, if set; for example, an in-memory listener for tests. The
+	// listener is closed when the module is stopped.
+	Listener net.Listener
+
+	conf       config
+	extras     []rpc.ServerOption
+	logger     xlog.Logger
+	logs       io.Writer
+	telemetry  *dxOtel.Telemetry
+	service    rpc.ServiceProvider
+	creds      *dxTLS.Credentials
+	middleware []dxMW.Handler
+	server     *rpc.Server
+	gwWatcher  *dxTLS.Watcher
+	mu         sync.Mutex
 }
 
 // Name returns the default module identifier: "rpc".
@@ -29,6 +65,42 @@ func (m *Module) Name() string {
 	return "rpc"
 }
 
+// Depends on the "log" and "otel" modules, used to obtain the server
+// logger and instrumentation; on the "tls" and "middleware" modules, used
+// to secure the server and the HTTP gateway; and on the "handler" module,
+// which provides the service exposed by the server.
+func (m *Module) Depends() []string {
+	return []string{"log", "otel", "tls", "middleware", "handler"}
+}
+
+// Consume the resources provided by the module dependencies. The logger
+// and service are required; the instrumentation, TLS credentials and HTTP
+// middleware are optional.
+func (m *Module) Consume(r *dx.Registry) (err error) {
+	loggers, err := dx.Resolve[dxLog.Loggers](r)
+	if err != nil {
+		return err
+	}
+	m.logger = loggers.Component("rpc")
+	m.logs = loggers.Writer()
+	if m.service, err = dx.Resolve[rpc.ServiceProvider](r); err != nil {
+		return err
+	}
+	if m.telemetry, err = dx.Resolve[*dxOtel.Telemetry](r); err != nil && !errors.Is(err, dx.ErrNotProvided) {
+		return err
+	}
+	if m.creds, err = dx.Resolve[*dxTLS.Credentials](r); err != nil && !errors.Is(err, dx.ErrNotProvided) {
+		return err
+	}
+	if m.middleware, err = dx.Resolve[[]dxMW.Handler](r); err != nil && !errors.Is(err, dx.ErrNotProvided) {
+		return err
+	}
+	if gw := m.conf.RPC.HTTP; m.creds != nil && gw != nil && gw.Enabled && !gw.InProcess {
+		return gw.Client.check(m.creds.Settings())
+	}
+	return nil
+}
+
 // Load configuration settings from the provided viper instance.
 func (m *Module) Load(v *viper.Viper) error {
 	if m.conf.RPC == nil {
@@ -37,6 +109,12 @@ func (m *Module) Load(v *viper.Viper) error {
 	return v.Unmarshal(&m.conf)
 }
 
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &config{RPC: defaultSettings()}
+}
+
 // Flags exposes core server settings as CLI flags.
 func (m *Module) Flags(appName string) []cli.Param {
 	return []cli.Param{
@@ -54,33 +132,46 @@ func (m *Module) Flags(appName string) []cli.Param {
 			ByDefault: false,
 			Short:     "H",
 		},
-		{
-			Name:      "tls",
-			Usage:     "enable secure communications using TLS with provided credentials",
-			FlagKey:   "rpc.tls.enabled",
-			ByDefault: false,
-		},
-		{
-			Name:      "tls-ca",
-			Usage:     "TLS custom certificate authority (path to PEM file)",
-			FlagKey:   "rpc.tls.custom_ca",
-			ByDefault: "",
-		},
-		{
-			Name:      "tls-cert",
-			Usage:     "TLS certificate (path to PEM file)",
-			FlagKey:   "rpc.tls.cert",
-			ByDefault: fmt.Sprintf("/etc/%s/tls/tls.crt", appName),
-		},
-		{
-			Name:      "tls-key",
-			Usage:     "TLS private key (path to PEM file)",
-			FlagKey:   "rpc.tls.key",
-			ByDefault: fmt.Sprintf("/etc/%s/tls/tls.key", appName),
-		},
 	}
 }
 
+// Validate the server settings, including TLS and HTTP middleware.
+func (m *Module) Validate() []dx.Issue {
+	issues := []dx.Issue{}
+	conf := m.conf.RPC
+	if conf.Port != 0 && conf.UnixSocket != "" {
+		issues = append(issues, dx.Issue{
+			Key:     "rpc.unix_socket",
+			Message: "port and unix socket can't be used simultaneously",
+		})
+	}
+	if conf.Port == 0 && conf.UnixSocket == "" && m.Listener == nil {
+		issues = append(issues, dx.Issue{Key: "rpc.port", Message: "either port or unix socket is required"})
+	}
+	if conf.Port < 0 || conf.Port > 65535 {
+		issues = append(issues, dx.Issue{Key: "rpc.port", Message: fmt.Sprintf("invalid TCP port %d", conf.Port)})
+	}
+	if conf.JWT != nil {
+		issues = append(issues, dx.Nest("rpc.jwt", conf.JWT.Validate())...)
+	}
+	if conf.APIKey != nil {
+		issues = append(issues, dx.Nest("rpc.apikey", conf.APIKey.Validate())...)
+	}
+	if conf.Policy != nil {
+		issues = append(issues, dx.Nest("rpc.policy", conf.Policy.Validate())...)
+	}
+	if conf.AccessLog != nil {
+		issues = append(issues, dx.Nest("rpc.access_log", conf.AccessLog.Validate())...)
+	}
+	if conf.Metrics != nil {
+		issues = append(issues, dx.Nest("rpc.metrics", conf.Metrics.Validate())...)
+	}
+	if conf.HTTP != nil && conf.HTTP.Enabled && !conf.HTTP.InProcess {
+		issues = append(issues, dx.Nest("rpc.http.gateway_client", conf.HTTP.Client.Validate())...)
+	}
+	return issues
+}
+
 // Customize the provided `*[]rpc.ServerOption` target.
 func (m *Module) Customize(target any) error {
 	// ensure provide target is of correct type
@@ -92,13 +183,17 @@ func (m *Module) Customize(target any) error {
 	if m.conf.RPC.Port != 0 && m.conf.RPC.UnixSocket != "" {
 		return errors.New("port and unix socket can't be used simultaneously")
 	}
-	if m.conf.RPC.Port == 0 && m.conf.RPC.UnixSocket == "" {
+	if m.conf.RPC.Port == 0 && m.conf.RPC.UnixSocket == "" && m.Listener == nil {
 		return errors.New("either port or unix socket is required")
 	}
 
 	// expand internal module settings
 	nOpts := []rpc.ServerOption{
 		rpc.WithPanicRecovery(),
+		rpc.WithStatsHandler(otelgrpc.NewServerHandler(m.otelOptions()...)),
+	}
+	if m.service != nil {
+		nOpts = append(nOpts, rpc.WithServiceProvider(m.service))
 	}
 	if m.conf.RPC.Resources != nil {
 		nOpts = append(nOpts, rpc.WithResourceLimits(*m.conf.RPC.Resources))
@@ -109,36 +204,96 @@ func (m *Module) Customize(target any) error {
 	if m.conf.RPC.Reflection {
 		nOpts = append(nOpts, rpc.WithReflection())
 	}
-	if m.conf.RPC.Port != 0 {
+	switch {
+	case m.Listener != nil:
+		nOpts = append(nOpts, rpc.WithListener(m.Listener))
+	case m.conf.RPC.Port != 0:
 		nOpts = append(nOpts,
 			rpc.WithPort(m.conf.RPC.Port),
 			rpc.WithNetworkInterface(m.conf.RPC.NetInt),
 		)
-	} else {
+	default:
 		nOpts = append(nOpts, rpc.WithUnixSocket(m.conf.RPC.UnixSocket))
 	}
 
-	var (
-		tc  *dxTLS.Settings
-		err error
-	)
-	tlsConf := m.conf.RPC.TLS
-	if tlsConf != nil && tlsConf.Enabled {
-		tc, err = tlsConf.Provide()
+	// TLS credentials; when watched, certificates are rotated without
+	// restarting the server
+	var tc *dxTLS.Settings
+	if m.creds != nil {
+		tc = m.creds.Settings()
+		sc, err := m.creds.ServerConfig()
 		if err != nil {
 			return err
 		}
-		nOpts = append(nOpts, rpc.WithTLS(rpc.ServerTLSConfig{
-			Cert:             tc.Certificate,
-			PrivateKey:       tc.PrivateKey,
-			IncludeSystemCAs: tc.SystemCAs,
-			CustomCAs:        tc.CustomCAs,
-		}))
+		nOpts = append(nOpts, rpc.WithTLS(sc))
+	}
+
+	// request metrics; installed first to include the time spent on all
+	// other interceptors
+	if rm := m.conf.RPC.Metrics; rm != nil && rm.Enabled {
+		rec, err := rm.Recorder()
+		if err != nil {
+			return errors.Wrap(err, "failed to setup request metrics")
+		}
+		nOpts = append(nOpts,
+			rpc.WithUnaryMiddleware(rec.UnaryServerInterceptor()),
+			rpc.WithStreamMiddleware(rec.StreamServerInterceptor()),
+		)
+	}
+
+	// access logs; installed before authentication to report identities.
+	// Requests forwarded by the in-process gateway are logged as HTTP
+	// requests by the "middleware" module instead
+	if al := m.conf.RPC.AccessLog; al != nil && al.Enabled {
+		alOpts := al.Options(m.logs)
+		alOpts.Skip = rpc.FromGateway
+		logger, err := accesslog.New(alOpts)
+		if err != nil {
+			return errors.Wrap(err, "failed to setup access logs")
+		}
+		nOpts = append(nOpts,
+			rpc.WithUnaryMiddleware(logger.UnaryServerInterceptor()),
+			rpc.WithStreamMiddleware(logger.StreamServerInterceptor()),
+		)
+	}
+
+	// identity of clients with verified certificates; installed after the
+	// access logs to report it
+	if tc != nil && tc.ClientAuth == stdTLS.RequireAndVerifyClientCert {
+		nOpts = append(nOpts,
+			rpc.WithUnaryMiddleware(peerUnary()),
+			rpc.WithStreamMiddleware(peerStream()),
+		)
+	}
+
+	// request authentication
+	authList, err := m.authenticators()
+	if err != nil {
+		return err
+	}
+	if len(authList) > 0 {
+		nOpts = append(nOpts,
+			rpc.WithUnaryMiddleware(auth.UnaryServerInterceptor(authList...)),
+			rpc.WithStreamMiddleware(auth.StreamServerInterceptor(authList...)),
+		)
+	}
+
+	// authorization policy
+	if policy := m.conf.RPC.Policy; policy != nil && policy.Enabled {
+		p := policy.Policy(m.log())
+		nOpts = append(nOpts,
+			rpc.WithUnaryMiddleware(p.UnaryServerInterceptor()),
+			rpc.WithStreamMiddleware(p.StreamServerInterceptor()),
+		)
 	}
 
 	// setup HTTP gateway
-	if m.conf.RPC.HTTP.Enabled {
-		gw, err := rpc.NewGateway(m.gatewayOptions(tc)...)
+	if m.conf.RPC.HTTP != nil && m.conf.RPC.HTTP.Enabled {
+		gwOpts, err := m.gatewayOptions(tc)
+		if err != nil {
+			return errors.Wrap(err, "failed to setup HTTP gateway")
+		}
+		gw, err := rpc.NewGateway(gwOpts...)
 		if err != nil {
 			return errors.Wrap(err, "failed to setup HTTP gateway")
 		}
@@ -150,35 +305,156 @@ func (m *Module) Customize(target any) error {
 	return nil
 }
 
-func (m *Module) gatewayOptions(tc *dxTLS.Settings) []rpc.GatewayOption {
-	// gateway internal client options
-	clOpts := []rpc.ClientOption{
-		rpc.WithInsecureSkipVerify(), // accept any cert provided
+// Extend the server settings with additional options, for example to
+// register service providers. Extra options are applied on the next call
+// to `Start` and discarded when the module is stopped.
+func (m *Module) Extend(opts ...rpc.ServerOption) {
+	m.extras = append(m.extras, opts...)
+}
+
+// Start a new server instance based on the module's current settings.
+// The method returns once the server is ready to receive requests. If
+// the TLS credentials are watched, certificates are reloaded when their
+// files change and used by new connections, without restarting the server.
+func (m *Module) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.watch(); err != nil {
+		return errors.Wrap(err, "failed to watch TLS files")
 	}
-	if tc != nil {
-		clOpts = append(clOpts, rpc.WithClientTLS(rpc.ClientTLSConfig{
-			IncludeSystemCAs: tc.SystemCAs,
-			CustomCAs:        tc.CustomCAs,
-		}))
+	if err := m.start(); err != nil {
+		m.unwatch()
+		return err
 	}
+	return nil
+}
+
+// Stop the server gracefully and wait for it to close.
+func (m *Module) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := m.stop()
+	m.unwatch()
+	m.extras = nil
+	return err
+}
+
+// watch the TLS files used by the gateway client when connecting through
+// the network listener; if the server credentials are watched.
+func (m *Module) watch() (err error) {
+	if m.creds == nil || m.creds.Watcher() == nil {
+		return nil
+	}
+	gw := m.conf.RPC.HTTP
+	if gw == nil || !gw.Enabled || gw.InProcess || gw.Client == nil || gw.Client.Cert == "" {
+		return nil
+	}
+	m.gwWatcher, err = dxTLS.NewWatcher(gw.Client.module(), m.log(), nil)
+	return err
+}
+
+func (m *Module) unwatch() {
+	if m.gwWatcher != nil {
+		_ = m.gwWatcher.Close()
+		m.gwWatcher = nil
+	}
+}
+
+func (m *Module) log() xlog.Logger {
+	switch {
+	case m.Logger != nil:
+		return m.Logger
+	case m.logger != nil:
+		return m.logger
+	default:
+		return xlog.Discard()
+	}
+}
+
+// otelOptions returns the settings used to instrument the server and the
+// gateway client, based on the telemetry provided by the "otel" module.
+func (m *Module) otelOptions() []otelgrpc.Option {
+	if m.telemetry == nil {
+		return nil
+	}
+	return []otelgrpc.Option{
+		otelgrpc.WithTracerProvider(m.telemetry.TracerProvider),
+		otelgrpc.WithMeterProvider(m.telemetry.MeterProvider),
+	}
+}
+
+func (m *Module) start() (err error) {
+	opts := []rpc.ServerOption{}
+	if err = m.Customize(&opts); err != nil {
+		return err
+	}
+	srv, err := rpc.NewServer(append(opts, m.extras...)...)
+	if err != nil {
+		return err
+	}
+	if err = srv.Start(); err != nil {
+		return err
+	}
+	m.server = srv
+	return nil
+}
+
+func (m *Module) stop() (err error) {
+	if m.server != nil {
+		err = m.server.Stop(true)
+		m.server = nil
+	}
+	return err
+}
+
+// authenticators returns the enabled request authentication mechanisms.
+func (m *Module) authenticators() ([]auth.Authenticator, error) {
+	list := []auth.Authenticator{}
+	if jwtConf := m.conf.RPC.JWT; jwtConf != nil && jwtConf.Enabled {
+		verifier, err := jwtConf.Verifier()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to setup JWT authentication")
+		}
+		list = append(list, auth.Authenticator{Verifier: verifier, Exempt: jwtConf.Exempt})
+	}
+	if keyConf := m.conf.RPC.APIKey; keyConf != nil && keyConf.Enabled {
+		verifier, err := keyConf.Verifier()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to setup API key authentication")
+		}
+		list = append(list, auth.Authenticator{Verifier: verifier, Exempt: keyConf.Exempt})
+	}
+	return list, nil
+}
+
+func (m *Module) gatewayOptions(tc *dxTLS.Settings) ([]rpc.GatewayOption, error) {
+	// gateway internal client options
 	gwOpts := []rpc.GatewayOption{
-		rpc.WithClientOptions(clOpts...),
-		rpc.WithHandlerName("http-gateway"),
+		rpc.WithClientOptions(grpc.WithStatsHandler(otelgrpc.NewClientHandler(m.otelOptions()...))),
 		rpc.WithPrettyJSON("application/json+pretty"),
 	}
+	switch {
+	case m.conf.RPC.HTTP.InProcess:
+		gwOpts = append(gwOpts, rpc.WithInProcessClient())
+	case tc != nil:
+		conf, err := m.conf.RPC.HTTP.Client.clientConfig(tc)
+		if err != nil {
+			return nil, err
+		}
+		gwOpts = append(gwOpts, rpc.WithClientTLS(conf))
+		if w := m.creds.Watcher(); w != nil {
+			gwOpts = append(gwOpts, rpc.WithClientCredentials(m.conf.RPC.HTTP.Client.credentials(conf, w, m.gwWatcher)))
+		}
+	}
 
 	// gateway middleware
-	if m.conf.RPC.HTTP.Middleware != nil {
-		gm := []dxMW.Handler{}
-		if err := m.conf.RPC.HTTP.Middleware.Customize(&gm); err != nil {
-			return gwOpts
-		}
-		for _, mw := range gm {
+	if len(m.middleware) > 0 {
+		for _, mw := range m.middleware {
 			gwOpts = append(gwOpts, rpc.WithGatewayMiddleware(mw))
 		}
 		gwOpts = append(gwOpts, rpc.WithGatewayMiddleware(mwRecovery.Handler()))
 	}
-	return gwOpts
+	return gwOpts, nil
 }
 
 // apply minimal default settings.
@@ -186,22 +462,47 @@ func defaultSettings() *settings {
 	return &settings{
 		Port:   defaultPort,
 		NetInt: rpc.NetworkInterfaceLocal,
+		JWT: &dxJWT.Module{
+			Refresh:    dxJWT.DefaultRefresh,
+			Leeway:     dxJWT.DefaultLeeway,
+			Algorithms: auth.DefaultAlgorithms,
+			Exempt:     []string{"Ping", "Ready"},
+		},
+		APIKey: &dxKey.Module{
+			Header:  auth.DefaultAPIKeyHeader,
+			Refresh: dxKey.DefaultRefresh,
+			Exempt:  []string{"Ping", "Ready"},
+		},
+		Policy:    &dxPolicy.Module{Default: auth.PolicyDeny},
+		HTTP:      &gwSettings{InProcess: true},
+		AccessLog: &dxAccess.Module{Format: accesslog.FormatJSON, Sample: 1},
+		Metrics:   &dxMetrics.Module{Buckets: metrics.DefaultBuckets, MaxLabels: metrics.DefaultMaxLabels},
 	}
 }
 
+type config struct {
+	RPC *settings `json:"rpc" yaml:"rpc" mapstructure:"rpc" desc:"gRPC server and HTTP gateway"`
+}
+
 // nolint: lll
 type settings struct {
-	Port            int                 `json:"port" yaml:"port" mapstructure:"port"`
-	NetInt          string              `json:"network_interface" yaml:"network_interface" mapstructure:"network_interface"`
-	UnixSocket      string              `json:"unix_socket" yaml:"unix_socket" mapstructure:"unix_socket"`
-	InputValidation bool                `json:"input_validation" yaml:"input_validation" mapstructure:"input_validation"`
-	Reflection      bool                `json:"reflection" yaml:"reflection" mapstructure:"reflection"`
-	Resources       *rpc.ResourceLimits `json:"resource_limits" yaml:"resource_limits" mapstructure:"resource_limits"`
-	TLS             *dxTLS.Module       `json:"tls" yaml:"tls" mapstructure:"tls"`
-	HTTP            *gwSettings         `json:"http" yaml:"http" mapstructure:"http"`
+	Port            int                 `json:"port" yaml:"port" mapstructure:"port" desc:"TCP port to use for the server"`
+	NetInt          string              `json:"network_interface" yaml:"network_interface" mapstructure:"network_interface" desc:"network interface to listen on: local, all or a specific name"`
+	UnixSocket      string              `json:"unix_socket" yaml:"unix_socket" mapstructure:"unix_socket" desc:"UNIX socket to listen on; can't be used along a TCP port"`
+	InputValidation bool                `json:"input_validation" yaml:"input_validation" mapstructure:"input_validation" desc:"validate incoming messages using protovalidate annotations"`
+	Reflection      bool                `json:"reflection" yaml:"reflection" mapstructure:"reflection" desc:"enable the gRPC reflection service"`
+	Resources       *rpc.ResourceLimits `json:"resource_limits" yaml:"resource_limits" mapstructure:"resource_limits" desc:"limit connections, concurrent requests per connection and requests per second"`
+	JWT             *dxJWT.Module       `json:"jwt" yaml:"jwt" mapstructure:"jwt" desc:"JWT bearer authentication"`
+	APIKey          *dxKey.Module       `json:"apikey" yaml:"apikey" mapstructure:"apikey" desc:"API key authentication"`
+	Policy          *dxPolicy.Module    `json:"policy" yaml:"policy" mapstructure:"policy" desc:"authorization policy"`
+	AccessLog       *dxAccess.Module    `json:"access_log" yaml:"access_log" mapstructure:"access_log" desc:"access logs for gRPC requests"`
+	Metrics         *dxMetrics.Module   `json:"metrics" yaml:"metrics" mapstructure:"metrics" desc:"rate, errors and duration metrics for gRPC requests"`
+	HTTP            *gwSettings         `json:"http" yaml:"http" mapstructure:"http" desc:"HTTP gateway"`
 }
 
+// nolint: lll
 type gwSettings struct {
-	Enabled    bool         `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
-	Middleware *dxMW.Module `json:"middleware" yaml:"middleware" mapstructure:"middleware"`
+	Enabled   bool              `json:"enabled" yaml:"enabled" mapstructure:"enabled" desc:"expose the service through an HTTP gateway"`
+	InProcess bool              `json:"in_process" yaml:"in_process" mapstructure:"in_process" desc:"connect the gateway to the server using an in-process channel instead of the network listener"`
+	Client    *gwClientSettings `json:"gateway_client" yaml:"gateway_client" mapstructure:"gateway_client" desc:"TLS settings used by the gateway to connect to the server"`
 }
@@ -0,0 +1,68 @@
+package tls
+
+import (
+	"context"
+	stdTLS "crypto/tls"
+	"crypto/x509"
+
+	"go.bryk.io/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// counter for connections rejected due to missing or invalid client
+// certificates; instruments created before the telemetry operator is
+// started are automatically delegated to it.
+var rejections, _ = otel.Meter(scope).Int64Counter("tls.client.rejected",
+	metric.WithDescription("connections rejected due to missing or invalid client certificates"),
+	metric.WithUnit("{connection}"))
+
+// verifyClient returns a function to check the client certificates
+// presented during a handshake, according to the authentication `mode`;
+// `roots` returns the CAs currently used to verify certificates.
+// Certificates are checked on the `VerifyConnection` hook, instead of
+// relying on the `tls.Config.ClientAuth` verification, to report every
+// rejected connection.
+func verifyClient(mode stdTLS.ClientAuthType, roots func() *x509.CertPool) func(stdTLS.ConnectionState) error {
+	return func(cs stdTLS.ConnectionState) error {
+		if mode == stdTLS.NoClientCert || mode == stdTLS.RequestClientCert {
+			return nil
+		}
+		if len(cs.PeerCertificates) == 0 {
+			return reject("missing", errors.New("a client certificate is required"))
+		}
+		if mode == stdTLS.RequireAnyClientCert {
+			return nil
+		}
+		opts := x509.VerifyOptions{
+			Roots:         roots(),
+			Intermediates: x509.NewCertPool(),
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
+			return reject("invalid", errors.Wrap(err, "invalid client certificate"))
+		}
+		return nil
+	}
+}
+
+// reject reports a rejected connection and returns `err`.
+func reject(reason string, err error) error {
+	rejections.Add(context.Background(), 1, metric.WithAttributes(attribute.String("tls.rejection.reason", reason)))
+	return err
+}
+
+// authPool returns a pool with the CAs used to authenticate clients.
+func authPool(cas [][]byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, ca := range cas {
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid client authentication CA")
+		}
+	}
+	return pool, nil
+}
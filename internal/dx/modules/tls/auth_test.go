@@ -0,0 +1,197 @@
+package tls
+
+import (
+	"context"
+	stdTLS "crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestClientAuth(t *testing.T) {
+	ca := newCA(t, "test CA")
+	server := issue(t, ca, false)
+	trusted := issue(t, ca, true)
+	untrusted := issue(t, newCA(t, "untrusted CA"), true)
+
+	tests := []struct {
+		mode   stdTLS.ClientAuthType
+		client *KeyPair
+		reason string // empty if the connection must be accepted
+	}{
+		{mode: stdTLS.NoClientCert},
+		{mode: stdTLS.NoClientCert, client: untrusted},
+		{mode: stdTLS.RequestClientCert},
+		{mode: stdTLS.RequestClientCert, client: untrusted},
+		{mode: stdTLS.RequireAnyClientCert, reason: "missing"},
+		{mode: stdTLS.RequireAnyClientCert, client: untrusted},
+		{mode: stdTLS.RequireAndVerifyClientCert, reason: "missing"},
+		{mode: stdTLS.RequireAndVerifyClientCert, client: untrusted, reason: "invalid"},
+		{mode: stdTLS.RequireAndVerifyClientCert, client: trusted},
+	}
+	for _, tt := range tests {
+		name := tt.mode.String() + "/no certificate"
+		switch tt.client {
+		case trusted:
+			name = tt.mode.String() + "/trusted"
+		case untrusted:
+			name = tt.mode.String() + "/untrusted"
+		}
+		t.Run(name, func(t *testing.T) {
+			settings := &Settings{
+				Certificate: server.Certificate,
+				PrivateKey:  server.PrivateKey,
+				AuthCAs:     [][]byte{ca.Certificate},
+				ClientAuth:  tt.mode,
+				Policy:      presets[PresetIntermediate],
+			}
+			conf, err := settings.ServerConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := rejected(t)
+			err = handshake(t, conf, clientConfig(t, ca, tt.client))
+			if accepted := err == nil; accepted != (tt.reason == "") {
+				t.Fatalf("expected accepted=%v, got %v", tt.reason == "", err)
+			}
+			after := rejected(t)
+			for _, reason := range []string{"missing", "invalid"} {
+				want := before[reason]
+				if reason == tt.reason {
+					want++
+				}
+				if after[reason] != want {
+					t.Errorf("expected %d connections rejected as %s, got %d", want, reason, after[reason])
+				}
+			}
+		})
+	}
+}
+
+// metrics reader registered on the global meter provider; instruments
+// created before the provider is set are delegated to it only once.
+var (
+	reader     *sdkMetric.ManualReader
+	readerOnce sync.Once
+)
+
+func metricsReader() *sdkMetric.ManualReader {
+	readerOnce.Do(func() {
+		reader = sdkMetric.NewManualReader()
+		otel.SetMeterProvider(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)))
+	})
+	return reader
+}
+
+// rejected returns the number of connections rejected, by reason.
+func rejected(t *testing.T) map[string]int64 {
+	t.Helper()
+	res := map[string]int64{}
+	for _, m := range collect(t) {
+		if m.Name != "tls.client.rejected" {
+			continue
+		}
+		for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+			reason, _ := dp.Attributes.Value("tls.rejection.reason")
+			res[reason.AsString()] += dp.Value
+		}
+	}
+	return res
+}
+
+// collect returns the current value of the metrics on the package scope.
+func collect(t *testing.T) []metricdata.Metrics {
+	t.Helper()
+	data := metricdata.ResourceMetrics{}
+	if err := metricsReader().Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+	for _, sm := range data.ScopeMetrics {
+		if sm.Scope.Name == scope {
+			return sm.Metrics
+		}
+	}
+	return nil
+}
+
+// handshake runs a TLS handshake over a local connection and returns the
+// error reported by the server, if any.
+func handshake(t *testing.T, server, client *stdTLS.Config) error {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lis.Close() }()
+	go func() {
+		cc, err := net.Dial("tcp", lis.Addr().String())
+		if err != nil {
+			return
+		}
+		defer func() { _ = cc.Close() }()
+		_ = cc.SetDeadline(time.Now().Add(5 * time.Second))
+
+		// keep reading to receive the server response; with TLS 1.3 the
+		// client certificate is checked after the client handshake completes
+		_ = stdTLS.Client(cc, client).Handshake()
+		_, _ = io.Copy(io.Discard, cc)
+	}()
+	sc, err := lis.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = sc.Close() }()
+	_ = sc.SetDeadline(time.Now().Add(5 * time.Second))
+	return stdTLS.Server(sc, server).Handshake()
+}
+
+// clientConfig returns the settings for a client trusting `ca` and using
+// the `cert` certificate, if provided. The certificate is always presented,
+// even if not issued by one of the CAs requested by the server.
+func clientConfig(t *testing.T, ca, cert *KeyPair) *stdTLS.Config {
+	t.Helper()
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca.Certificate)
+	conf := &stdTLS.Config{RootCAs: pool, ServerName: "localhost"}
+	if cert != nil {
+		pair, err := stdTLS.X509KeyPair(cert.Certificate, cert.PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf.GetClientCertificate = func(_ *stdTLS.CertificateRequestInfo) (*stdTLS.Certificate, error) {
+			return &pair, nil
+		}
+	}
+	return conf
+}
+
+func newCA(t *testing.T, name string) *KeyPair {
+	t.Helper()
+	ca, err := NewCA(name, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca
+}
+
+// issue a certificate for "localhost"; for clients if `client` is set.
+func issue(t *testing.T, ca *KeyPair, client bool) *KeyPair {
+	t.Helper()
+	kp, err := ca.Issue(CertificateOptions{
+		CommonName: "localhost",
+		DNSNames:   []string{"localhost"},
+		Lifetime:   time.Hour,
+		Client:     client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
@@ -0,0 +1,241 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	stdTLS "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"go.bryk.io/pkg/errors"
+)
+
+// KeyPair holds a PEM-encoded x509 certificate and its private key.
+type KeyPair struct {
+	Certificate []byte
+	PrivateKey  []byte
+}
+
+// CertificateOptions adjust the certificates generated.
+type CertificateOptions struct {
+	// Common name used as subject of the certificate.
+	CommonName string
+
+	// Subject alternative names.
+	DNSNames []string
+	IPs      []net.IP
+	URIs     []*url.URL
+
+	// Period of time the certificate is valid for.
+	Lifetime time.Duration
+
+	// Issue a certificate used to authenticate clients instead of servers.
+	Client bool
+}
+
+// AddSAN registers subject alternative names; values are added as IP
+// addresses, URIs or DNS names based on their format.
+func (co *CertificateOptions) AddSAN(values ...string) error {
+	for _, value := range values {
+		if ip := net.ParseIP(value); ip != nil {
+			co.IPs = append(co.IPs, ip)
+			continue
+		}
+		if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+			co.URIs = append(co.URIs, u)
+			continue
+		}
+		if value == "" {
+			return errors.New("invalid empty SAN")
+		}
+		co.DNSNames = append(co.DNSNames, value)
+	}
+	return nil
+}
+
+// NewCA returns a new self-signed certificate authority; suitable for
+// development and testing environments.
+func NewCA(name string, lifetime time.Duration) (*KeyPair, error) {
+	tpl, err := template(name, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	tpl.IsCA = true
+	tpl.BasicConstraintsValid = true
+	tpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	return generate(tpl, nil, nil)
+}
+
+// LoadCA returns a certificate authority previously generated; `cert` and
+// `key` can be paths to PEM files or base64-encoded PEM values.
+func LoadCA(cert, key string) (*KeyPair, error) {
+	var (
+		ca  = new(KeyPair)
+		err error
+	)
+	if ca.Certificate, err = loadPem(cert); err != nil {
+		return nil, errors.Wrap(err, "failed to load CA certificate")
+	}
+	if ca.PrivateKey, err = loadPem(key); err != nil {
+		return nil, errors.Wrap(err, "failed to load CA private key")
+	}
+	if _, err = stdTLS.X509KeyPair(ca.Certificate, ca.PrivateKey); err != nil {
+		return nil, errors.Wrap(err, "invalid CA")
+	}
+	return ca, nil
+}
+
+// Issue a new certificate signed by the CA.
+func (kp *KeyPair) Issue(opts CertificateOptions) (*KeyPair, error) {
+	pair, err := stdTLS.X509KeyPair(kp.Certificate, kp.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid CA")
+	}
+	parent, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	if !parent.IsCA {
+		return nil, errors.New("certificate is not a CA")
+	}
+	signer, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported CA private key")
+	}
+	tpl, err := leafTemplate(opts)
+	if err != nil {
+		return nil, err
+	}
+	if tpl.NotAfter.After(parent.NotAfter) {
+		tpl.NotAfter = parent.NotAfter
+	}
+	return generate(tpl, parent, signer)
+}
+
+// SelfSigned returns a new self-signed certificate.
+func SelfSigned(opts CertificateOptions) (*KeyPair, error) {
+	tpl, err := leafTemplate(opts)
+	if err != nil {
+		return nil, err
+	}
+	return generate(tpl, nil, nil)
+}
+
+// Save the certificate and private key as PEM files. Existing files are
+// only replaced if `force` is set.
+func (kp *KeyPair) Save(cert, key string, force bool) error {
+	if !force {
+		for _, file := range []string{cert, key} {
+			if _, err := os.Stat(file); err == nil {
+				return errors.Errorf("file already exists: %s", file)
+			}
+		}
+	}
+	if err := os.WriteFile(cert, kp.Certificate, 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(key, kp.PrivateKey, 0600)
+}
+
+// temporary certificates are valid long enough to cover the process
+// lifetime; they are replaced if loaded when about to expire.
+const (
+	ephemeralLifetime = 365 * 24 * time.Hour
+	ephemeralRenewal  = 24 * time.Hour
+)
+
+// ephemeral returns a self-signed certificate valid for the local host.
+func ephemeral() (*KeyPair, error) {
+	opts := CertificateOptions{
+		CommonName: "localhost",
+		DNSNames:   []string{"localhost"},
+		IPs:        []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		Lifetime:   ephemeralLifetime,
+	}
+	if host, err := os.Hostname(); err == nil && host != "localhost" {
+		opts.DNSNames = append(opts.DNSNames, host)
+	}
+	return SelfSigned(opts)
+}
+
+// expiresWithin returns `true` if the certificate expires within the
+// provided period, or can't be decoded.
+func (kp *KeyPair) expiresWithin(period time.Duration) bool {
+	block, _ := pem.Decode(kp.Certificate)
+	if block == nil {
+		return true
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true
+	}
+	return time.Until(cert.NotAfter) < period
+}
+
+func leafTemplate(opts CertificateOptions) (*x509.Certificate, error) {
+	tpl, err := template(opts.CommonName, opts.Lifetime)
+	if err != nil {
+		return nil, err
+	}
+	tpl.DNSNames = opts.DNSNames
+	tpl.IPAddresses = opts.IPs
+	tpl.URIs = opts.URIs
+	tpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	if opts.Client {
+		tpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	return tpl, nil
+}
+
+func template(name string, lifetime time.Duration) (*x509.Certificate, error) {
+	if name == "" {
+		return nil, errors.New("common name is required")
+	}
+	if lifetime <= 0 {
+		return nil, errors.New("lifetime must be positive")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    now.Add(-5 * time.Minute), // tolerate clock skew
+		NotAfter:     now.Add(lifetime),
+	}, nil
+}
+
+// generate a new key pair based on `tpl`; if `parent` is nil the
+// certificate is self-signed.
+func generate(tpl, parent *x509.Certificate, parentKey crypto.Signer) (*KeyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		parent, parentKey = tpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create certificate")
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{
+		Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		PrivateKey:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}),
+	}, nil
+}
@@ -0,0 +1,123 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t, "test CA")
+	caCert, caKey := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := ca.Save(caCert, caKey, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := ca.Save(caCert, caKey, false); err == nil {
+		t.Error("existing files replaced without force")
+	}
+	loaded, err := LoadCA(caCert, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// certificates issued are trusted by the CA and limited to its lifetime
+	kp, err := loaded.Issue(CertificateOptions{
+		CommonName: "localhost",
+		DNSNames:   []string{"localhost"},
+		Lifetime:   24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, root := parse(t, kp.Certificate), parse(t, ca.Certificate)
+	pool := x509.NewCertPool()
+	pool.AddCert(root)
+	if _, err = leaf.Verify(x509.VerifyOptions{Roots: pool, DNSName: "localhost"}); err != nil {
+		t.Errorf("issued certificate not trusted: %s", err)
+	}
+	if leaf.NotAfter.After(root.NotAfter) {
+		t.Error("issued certificate outlives the CA")
+	}
+	if _, err = kp.Issue(CertificateOptions{CommonName: "other", Lifetime: time.Hour}); err == nil {
+		t.Error("certificate issued by a non-CA certificate")
+	}
+}
+
+func TestEphemeral(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	m := &Module{Enabled: true, Ephemeral: true, Cert: missing, Key: missing}
+	settings, err := m.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settings.Ephemeral {
+		t.Fatal("expected a temporary certificate")
+	}
+	cert := parse(t, settings.Certificate)
+	for _, host := range []string{"localhost", "127.0.0.1", "::1"} {
+		if err = cert.VerifyHostname(host); err != nil {
+			t.Errorf("temporary certificate not valid for %s: %s", host, err)
+		}
+	}
+	if remaining := time.Until(cert.NotAfter); remaining < ephemeralLifetime-time.Hour {
+		t.Errorf("temporary certificate expires too soon: %s", remaining)
+	}
+
+	// the certificate is kept while valid
+	again, err := m.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Certificate, settings.Certificate) {
+		t.Error("temporary certificate replaced while valid")
+	}
+
+	// and renewed when about to expire
+	m.temporary, err = SelfSigned(CertificateOptions{
+		CommonName: "localhost",
+		Lifetime:   ephemeralRenewal / 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiring := m.temporary.Certificate
+	renewed, err := m.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(renewed.Certificate, expiring) {
+		t.Error("temporary certificate not renewed before expiring")
+	}
+
+	// not used when the certificate files are available
+	dir := t.TempDir()
+	kp := issue(t, newCA(t, "test CA"), false)
+	m.Cert, m.Key = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err = kp.Save(m.Cert, m.Key, false); err != nil {
+		t.Fatal(err)
+	}
+	if settings, err = m.Settings(); err != nil {
+		t.Fatal(err)
+	}
+	if settings.Ephemeral || !bytes.Equal(settings.Certificate, kp.Certificate) {
+		t.Error("temporary certificate used with the certificate files available")
+	}
+}
+
+// parse returns the first certificate on the PEM value.
+func parse(t *testing.T, data []byte) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("invalid PEM value")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
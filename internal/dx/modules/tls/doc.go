@@ -8,7 +8,61 @@ This module expects a configuration source like:
 		system_ca: true
 		cert: testdata/server.sample_cer
 		key: testdata/server.sample_key
+		pkcs12: ""
+		passphrase: env:TLS_KEY_PASSPHRASE
 		custom_ca: []
 		auth_ca: []
+		# none, request, require or verify
+		client_auth: none
+		# modern, intermediate or legacy
+		preset: intermediate
+		min_version: ""
+		max_version: ""
+		cipher_suites: []
+		curves: []
+		disable_session_tickets: false
+		alpn: []
+		ephemeral: false
+		watch: false
+		expiry_warning: 720h
+
+The `client_auth` setting controls client certificates: "none" doesn't
+request them, "request" asks for them without requiring them, "require"
+rejects clients without a certificate, and "verify" also validates the
+certificate using the CAs provided in `auth_ca`. Rejected connections are
+reported using the "tls.client.rejected" metric.
+
+Private keys can be encrypted, using PKCS#8 or legacy PEM encryption; and
+the certificate and private key can also be provided as a PKCS#12 bundle
+using `pkcs12`. The passphrase is never set directly on the configuration, it
+must be a reference to an environment variable (env:NAME), a file (file:PATH)
+or a secret mounted on "/run/secrets" (secret:NAME).
+
+The policy applied to TLS connections starts from one of the named presets,
+based on the Mozilla server side TLS guidelines; `min_version`, `max_version`,
+`cipher_suites` and `curves` override the values of the preset when set. The
+policy is applied in full by both `ServerConfig` and `ClientConfig`. Custom
+cipher suites must include one of the AES-128-GCM suites required by HTTP/2.
+
+When `ephemeral` is enabled and the certificate or private key files are not
+available, a temporary self-signed certificate valid for the local host is
+used instead; useful for development environments. The temporary certificate
+is valid for a year, and a new one is generated if the settings are loaded
+less than a day before it expires. Certificate authorities and certificates
+for development can also be generated using `NewCA`, `Issue` and `SelfSigned`.
+
+The module provides the loaded settings to other modules, such as "rpc" or
+"server", as a `*Credentials` instance. When `watch` is enabled, the module
+runs a `Watcher` to reload the certificate, private key and CAs when their
+files change. New material is validated before replacing the one currently
+in use; invalid material is reported and ignored. The configuration returned
+by `Credentials.ServerConfig` uses `GetCertificate`, so new connections use
+the rotated material without restarting the server; use
+`Watcher.GetClientCertificate` the same way on clients. The time remaining
+before the certificate expires is reported using the "tls.certificate.expiry"
+metric, and a warning is logged when it's less than `expiry_warning`.
+
+Settings on the previous "rpc.tls" location are still supported, but
+reported as deprecated; settings on "tls" take precedence.
 */
 package tls
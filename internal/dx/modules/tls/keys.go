@@ -0,0 +1,116 @@
+package tls
+
+import (
+	stdTLS "crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/youmark/pkcs8"
+	"go.bryk.io/pkg/errors"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Directory used to resolve "secret:" passphrase references; this is
+// the location used by Docker and Kubernetes to mount secrets.
+const secretsDir = "/run/secrets"
+
+// supported secret reference formats.
+var secretKinds = []string{"env", "file", "secret"}
+
+// resolveSecret returns the value referenced by `ref`; supported formats
+// are "env:NAME", "file:PATH" and "secret:NAME".
+func resolveSecret(ref string) (string, error) {
+	if ref == "" {
+		return "", nil
+	}
+	// avoid including the reference on errors; it could be a literal value
+	kind, name, _ := strings.Cut(ref, ":")
+	if name == "" || !slices.Contains(secretKinds, kind) {
+		return "", errors.New("invalid secret reference; use 'env:NAME', 'file:PATH' or 'secret:NAME'")
+	}
+	var file string
+	switch kind {
+	case "env":
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", errors.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	case "file":
+		file = path.Clean(name)
+	default: // secret
+		file = filepath.Join(secretsDir, filepath.Base(name))
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", errors.Errorf("secret not available: %s", ref)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// decryptKey returns the unencrypted PEM representation of the private
+// key; both PKCS#8 and legacy encrypted PEM blocks are supported.
+func decryptKey(data []byte, passphrase string) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid private key: not a PEM value")
+	}
+	// nolint: staticcheck
+	legacy := x509.IsEncryptedPEMBlock(block)
+	if block.Type != "ENCRYPTED PRIVATE KEY" && !legacy {
+		return data, nil
+	}
+	if passphrase == "" {
+		return nil, errors.New("private key is encrypted but no passphrase was provided")
+	}
+	if legacy {
+		// nolint: staticcheck
+		der, err := x509.DecryptPEMBlock(block, []byte(passphrase))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decrypt private key")
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+	}
+	key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(passphrase))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt private key")
+	}
+	return encodeKey(key)
+}
+
+// decodeBundle returns the PEM-encoded certificate chain and private key
+// included in a PKCS#12 bundle.
+func decodeBundle(data []byte, passphrase string) (cert, key []byte, err error) {
+	pk, leaf, chain, err := pkcs12.DecodeChain(data, passphrase)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode PKCS#12 bundle")
+	}
+	for _, c := range append([]*x509.Certificate{leaf}, chain...) {
+		cert = append(cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	if key, err = encodeKey(pk); err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// checkPair verifies the private key corresponds to the certificate.
+func checkPair(cert, key []byte) error {
+	if _, err := stdTLS.X509KeyPair(cert, key); err != nil {
+		return errors.Wrap(err, "private key doesn't match the certificate")
+	}
+	return nil
+}
+
+func encodeKey(key any) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "unsupported private key")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,195 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t, "test CA")
+	kp := issue(t, ca, false)
+	key := parseKey(t, kp.PrivateKey)
+	cert := parse(t, kp.Certificate)
+	write := func(name string, data []byte) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
+	// encrypted PKCS#8 key
+	der, err := pkcs8.MarshalPrivateKey(key, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := write("pkcs8.key", pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}))
+
+	// legacy encrypted PEM key
+	ecDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nolint: staticcheck
+	block, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", ecDer, []byte("secret"), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := write("legacy.key", pem.EncodeToMemory(block))
+
+	// PKCS#12 bundle, including the CA certificate
+	pfx, err := pkcs12.Modern2023.Encode(key, cert, []*x509.Certificate{parse(t, ca.Certificate)}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := write("bundle.p12", pfx)
+
+	certFile := write("tls.crt", kp.Certificate)
+	passFile := write("passphrase", []byte("secret\n"))
+	t.Setenv("ECHO_TEST_PASSPHRASE", "secret")
+	t.Setenv("ECHO_TEST_WRONG", "wrong")
+	tests := []struct {
+		name   string
+		module Module
+		fail   bool
+	}{
+		{name: "unencrypted key", module: Module{Cert: certFile, Key: write("tls.key", kp.PrivateKey)}},
+		{name: "PKCS#8 key", module: Module{Cert: certFile, Key: encrypted, Passphrase: "env:ECHO_TEST_PASSPHRASE"}},
+		{name: "legacy PEM key", module: Module{Cert: certFile, Key: legacy, Passphrase: "file:" + passFile}},
+		{name: "missing passphrase", module: Module{Cert: certFile, Key: encrypted}, fail: true},
+		{name: "wrong passphrase", module: Module{Cert: certFile, Key: legacy, Passphrase: "env:ECHO_TEST_WRONG"}, fail: true},
+		{name: "PKCS#12 bundle", module: Module{Bundle: bundle, Passphrase: "env:ECHO_TEST_PASSPHRASE"}},
+		{name: "PKCS#12 wrong passphrase", module: Module{Bundle: bundle, Passphrase: "env:ECHO_TEST_WRONG"}, fail: true},
+		{
+			name:   "key not matching the certificate",
+			module: Module{Cert: write("other.crt", issue(t, ca, false).Certificate), Key: encrypted, Passphrase: "file:" + passFile},
+			fail:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.module.Enabled = true
+			settings, err := tt.module.Settings()
+			if tt.fail {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(settings.Certificate, kp.Certificate) {
+				t.Error("unexpected certificate")
+			}
+			if !parseKey(t, settings.PrivateKey).Equal(key) {
+				t.Error("unexpected private key")
+			}
+		})
+	}
+
+	// the CA certificate included on the bundle is part of the chain
+	settings, err := (&Module{Bundle: bundle, Passphrase: "env:ECHO_TEST_PASSPHRASE"}).Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(settings.Certificate, ca.Certificate) {
+		t.Error("CA certificate not included on the chain")
+	}
+
+	// keys loaded directly, from a file or a base64-encoded value
+	data, err := os.ReadFile(filepath.Clean(legacy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []string{legacy, base64.StdEncoding.EncodeToString(data)} {
+		pk, err := LoadKey(value, "env:ECHO_TEST_PASSPHRASE")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parseKey(t, pk).Equal(key) {
+			t.Error("unexpected private key")
+		}
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(file, []byte("from file\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ECHO_TEST_SECRET", "from env")
+	tests := []struct {
+		name  string
+		ref   string
+		value string
+		fail  bool
+	}{
+		{name: "empty"},
+		{name: "env", ref: "env:ECHO_TEST_SECRET", value: "from env"},
+		{name: "env not set", ref: "env:ECHO_TEST_MISSING", fail: true},
+		{name: "file", ref: "file:" + file, value: "from file"},
+		{name: "file not available", ref: "file:" + file + ".missing", fail: true},
+		{name: "secret not available", ref: "secret:echo-test-missing", fail: true},
+		{name: "missing name", ref: "env:", fail: true},
+		{name: "literal value", ref: "literal-passphrase", fail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := resolveSecret(tt.ref)
+			if tt.fail {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if strings.Contains(err.Error(), "literal-passphrase") {
+					t.Errorf("secret value included on error: %s", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != tt.value {
+				t.Errorf("expected %q, got %q", tt.value, value)
+			}
+		})
+	}
+}
+
+// parseKey returns the ECDSA private key on the PEM value; both PKCS#8
+// and SEC 1 encodings are supported.
+func parseKey(t *testing.T, data []byte) *ecdsa.PrivateKey {
+	t.Helper()
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("invalid PEM value")
+	}
+	if block.Type == "EC PRIVATE KEY" {
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		t.Fatal("unexpected private key type")
+	}
+	return ec
+}
@@ -1,25 +1,95 @@
 package tls
 
 import (
+	stdTLS "crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bcessa/echo-service/internal/dx"
 	"github.com/spf13/viper"
 	"go.bryk.io/pkg/cli"
 	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
 )
 
+// DefaultExpiryWarning is the period before expiration in which
+// certificates are reported.
+const DefaultExpiryWarning = 30 * 24 * time.Hour
+
 // Module to manage common TLS settings.
+// nolint: lll
 type Module struct {
-	Enabled  bool     `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
-	SystemCA bool     `json:"system_ca" yaml:"system_ca" mapstructure:"system_ca"`
-	Cert     string   `json:"cert" yaml:"cert" mapstructure:"cert"`
-	Key      string   `json:"key" yaml:"key" mapstructure:"key"`
-	CustomCA []string `json:"custom_ca" yaml:"custom_ca" mapstructure:"custom_ca"`
-	AuthCA   []string `json:"auth_ca" yaml:"auth_ca" mapstructure:"auth_ca"`
+	Enabled    bool     `json:"enabled" yaml:"enabled" mapstructure:"enabled" desc:"enable secure communications using TLS"`
+	SystemCA   bool     `json:"system_ca" yaml:"system_ca" mapstructure:"system_ca" desc:"include the CAs available in the local system"`
+	Cert       string   `json:"cert" yaml:"cert" mapstructure:"cert" desc:"x509 certificate; path to a PEM file or base64-encoded PEM"`
+	Key        string   `json:"key" yaml:"key" mapstructure:"key" desc:"private key; path to a PEM file or base64-encoded PEM" secret:"true"`
+	Bundle     string   `json:"pkcs12" yaml:"pkcs12" mapstructure:"pkcs12" desc:"PKCS#12 bundle with the certificate and private key; path to a file or base64-encoded; replaces cert and key" secret:"true"`
+	Passphrase string   `json:"passphrase" yaml:"passphrase" mapstructure:"passphrase" desc:"passphrase for encrypted private keys and PKCS#12 bundles: env:NAME, file:PATH or secret:NAME" secret:"true"`
+	CustomCA   []string `json:"custom_ca" yaml:"custom_ca" mapstructure:"custom_ca" desc:"custom certificate authorities"`
+	AuthCA     []string `json:"auth_ca" yaml:"auth_ca" mapstructure:"auth_ca" desc:"certificate authorities used to authenticate clients"`
+	Auth       string   `json:"client_auth" yaml:"client_auth" mapstructure:"client_auth" desc:"client certificate authentication: none, request, require or verify"`
+
+	// connection policy
+	Preset           string   `json:"preset" yaml:"preset" mapstructure:"preset" desc:"base TLS policy: modern, intermediate or legacy"`
+	MinVersion       string   `json:"min_version" yaml:"min_version" mapstructure:"min_version" desc:"minimum TLS version: 1.0, 1.1, 1.2 or 1.3; overrides the preset"`
+	MaxVersion       string   `json:"max_version" yaml:"max_version" mapstructure:"max_version" desc:"maximum TLS version: 1.0, 1.1, 1.2 or 1.3; overrides the preset"`
+	Ciphers          []string `json:"cipher_suites" yaml:"cipher_suites" mapstructure:"cipher_suites" desc:"cipher suites allowed for TLS 1.2 and older; for example: TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"`
+	Curves           []string `json:"curves" yaml:"curves" mapstructure:"curves" desc:"elliptic curves in preference order: X25519, P256, P384 or P521"`
+	NoSessionTickets bool     `json:"disable_session_tickets" yaml:"disable_session_tickets" mapstructure:"disable_session_tickets" desc:"disable session resumption using tickets"`
+	ALPN             []string `json:"alpn" yaml:"alpn" mapstructure:"alpn" desc:"application protocols in preference order; for example: h2"`
+
+	// generate a self-signed certificate if none is available
+	Ephemeral bool `json:"ephemeral" yaml:"ephemeral" mapstructure:"ephemeral" desc:"use a temporary self-signed certificate when the certificate files are not available"`
+
+	// certificate rotation
+	Watch         bool          `json:"watch" yaml:"watch" mapstructure:"watch" desc:"reload certificates and CAs when their files change"`
+	ExpiryWarning time.Duration `json:"expiry_warning" yaml:"expiry_warning" mapstructure:"expiry_warning" desc:"report certificates expiring within this period; for example: 720h"`
+
+	// Logger used to report certificate rotations, optional.
+	Logger xlog.Logger `json:"-" yaml:"-" mapstructure:"-"`
 
 	// private expanded values
 	cert      []byte
 	key       []byte
 	customCAs [][]byte
 	authCAs   [][]byte
+	temporary *KeyPair
+	watcher   *Watcher
+}
+
+// Credentials hand over the TLS settings managed by the module to other
+// modules. When `watch` is enabled, the material is kept up to date while
+// the module is running.
+type Credentials struct {
+	settings *Settings
+	watcher  *Watcher
+}
+
+// Settings returns the TLS material currently in use.
+func (c *Credentials) Settings() *Settings {
+	if c.watcher != nil {
+		return c.watcher.Settings()
+	}
+	return c.settings
+}
+
+// ServerConfig returns a standard TLS configuration for servers; when
+// `watch` is enabled, the configuration always uses the material currently
+// in use.
+func (c *Credentials) ServerConfig() (*stdTLS.Config, error) {
+	if c.watcher != nil {
+		return c.watcher.ServerConfig()
+	}
+	return c.settings.ServerConfig()
+}
+
+// Watcher used to keep the material up to date; `nil` if `watch` is not
+// enabled.
+func (c *Credentials) Watcher() *Watcher {
+	return c.watcher
 }
 
 // Settings loaded/managed by the module.
@@ -38,6 +108,90 @@ type Settings struct {
 
 	// Custom CAs used for client authentication, if any.
 	AuthCAs [][]byte
+
+	// Policy used to authenticate clients based on their certificates.
+	ClientAuth stdTLS.ClientAuthType
+
+	// Whether a temporary self-signed certificate is used.
+	Ephemeral bool
+
+	// Policy applied to TLS connections.
+	Policy Policy
+}
+
+// Client authentication modes.
+const (
+	// client certificates are not requested.
+	ClientAuthNone = "none"
+
+	// client certificates are requested but not required nor verified.
+	ClientAuthRequest = "request"
+
+	// client certificates are required but not verified.
+	ClientAuthRequire = "require"
+
+	// client certificates are required and verified using the
+	// authentication CAs.
+	ClientAuthVerify = "verify"
+)
+
+// supported client authentication modes.
+var clientAuthModes = map[string]stdTLS.ClientAuthType{
+	"":                stdTLS.NoClientCert,
+	ClientAuthNone:    stdTLS.NoClientCert,
+	ClientAuthRequest: stdTLS.RequestClientCert,
+	ClientAuthRequire: stdTLS.RequireAnyClientCert,
+	ClientAuthVerify:  stdTLS.RequireAndVerifyClientCert,
+}
+
+// ServerConfig returns a standard TLS configuration for servers based on
+// the settings, including client authentication. Client certificates are
+// always requested when client authentication is enabled, and checked
+// according to the authentication mode once the handshake completes;
+// connections rejected are reported using the "tls.client.rejected"
+// metric.
+func (s *Settings) ServerConfig() (*stdTLS.Config, error) {
+	cert, err := stdTLS.X509KeyPair(s.Certificate, s.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	conf := &stdTLS.Config{
+		Certificates: []stdTLS.Certificate{cert},
+		ClientAuth:   s.ClientAuth,
+	}
+	s.Policy.Apply(conf)
+	if s.ClientAuth != stdTLS.NoClientCert {
+		pool, err := authPool(s.AuthCAs)
+		if err != nil {
+			return nil, err
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = stdTLS.RequestClientCert
+		conf.VerifyConnection = verifyClient(s.ClientAuth, func() *x509.CertPool { return pool })
+	}
+	return conf, nil
+}
+
+// ClientConfig returns a standard TLS configuration for clients based on
+// the settings. Server certificates are verified using the system CAs,
+// if enabled, and the custom CAs.
+func (s *Settings) ClientConfig() (*stdTLS.Config, error) {
+	pool := x509.NewCertPool()
+	if s.SystemCAs {
+		sp, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load system CAs")
+		}
+		pool = sp
+	}
+	for _, ca := range s.CustomCAs {
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid custom CA")
+		}
+	}
+	conf := &stdTLS.Config{RootCAs: pool}
+	s.Policy.Apply(conf)
+	return conf, nil
 }
 
 // Name returns the default module identifier: "tls".
@@ -47,12 +201,54 @@ func (m *Module) Name() string {
 
 // Load configuration settings from the provided viper instance.
 func (m *Module) Load(v *viper.Viper) error {
-	return v.Unmarshal(&m)
+	if m.Preset == "" {
+		m.Preset = PresetIntermediate
+	}
+	if m.ExpiryWarning == 0 {
+		m.ExpiryWarning = DefaultExpiryWarning
+	}
+	return v.Unmarshal(&config{TLS: m})
+}
+
+// Moved reports the previous location of the module settings: "rpc.tls".
+func (m *Module) Moved() map[string]string {
+	return map[string]string{"rpc.tls": "tls"}
 }
 
-// Flags exposes core server settings as CLI flags.
-func (m *Module) Flags(_ string) []cli.Param {
-	return []cli.Param{}
+// Defaults returns the module's configuration structure populated with
+// default values.
+func (m *Module) Defaults() any {
+	return &config{TLS: defaultSettings()}
+}
+
+// Flags exposes core TLS settings as CLI flags.
+func (m *Module) Flags(appName string) []cli.Param {
+	return []cli.Param{
+		{
+			Name:      "tls",
+			Usage:     "enable secure communications using TLS with provided credentials",
+			FlagKey:   "tls.enabled",
+			ByDefault: false,
+		},
+		{
+			Name:      "tls-ca",
+			Usage:     "TLS custom certificate authority (path to PEM file)",
+			FlagKey:   "tls.custom_ca",
+			ByDefault: "",
+		},
+		{
+			Name:      "tls-cert",
+			Usage:     "TLS certificate (path to PEM file)",
+			FlagKey:   "tls.cert",
+			ByDefault: fmt.Sprintf("/etc/%s/tls/tls.crt", appName),
+		},
+		{
+			Name:      "tls-key",
+			Usage:     "TLS private key (path to PEM file)",
+			FlagKey:   "tls.key",
+			ByDefault: fmt.Sprintf("/etc/%s/tls/tls.key", appName),
+		},
+	}
 }
 
 // Customize is not supported by the module.
@@ -60,15 +256,157 @@ func (m *Module) Customize(_ any) error {
 	return errors.New("invalid operation on 'tls' module")
 }
 
-// Provide the TLS settings loaded by the module.
-func (m *Module) Provide() (*Settings, error) {
+// Validate the TLS settings. When enabled, a certificate and private key
+// are required and all PEM values must be available.
+func (m *Module) Validate() []dx.Issue {
+	return dx.Nest("tls", m.validate())
+}
+
+func (m *Module) validate() []dx.Issue {
+	issues := []dx.Issue{}
+	if !m.Enabled {
+		return issues
+	}
+	switch {
+	case m.Bundle != "" && (m.Cert != "" || m.Key != ""):
+		issues = append(issues, dx.Issue{Key: "pkcs12", Message: "can't be used along 'cert' and 'key'"})
+	case m.Bundle == "" && !m.Ephemeral:
+		if m.Cert == "" {
+			issues = append(issues, dx.Issue{Key: "cert", Message: "certificate is required"})
+		}
+		if m.Key == "" {
+			issues = append(issues, dx.Issue{Key: "key", Message: "private key is required"})
+		}
+	}
+	if kind, _, _ := strings.Cut(m.Passphrase, ":"); m.Passphrase != "" && !slices.Contains(secretKinds, kind) {
+		issues = append(issues, dx.Issue{
+			Key:     "passphrase",
+			Message: "must be a reference like 'env:NAME', 'file:PATH' or 'secret:NAME'",
+		})
+	}
+	if _, ok := clientAuthModes[m.Auth]; !ok {
+		issues = append(issues, dx.Issue{
+			Key:     "client_auth",
+			Message: fmt.Sprintf("invalid client authentication mode: %s", m.Auth),
+		})
+	}
+	if m.Auth == ClientAuthVerify && len(m.AuthCA) == 0 {
+		issues = append(issues, dx.Issue{Key: "auth_ca", Message: "required to verify client certificates"})
+	}
+	issues = append(issues, m.validatePolicy()...)
+	if m.ExpiryWarning < 0 {
+		issues = append(issues, dx.Issue{Key: "expiry_warning", Message: "must not be negative"})
+	}
+	values := map[string][]string{
+		"custom_ca": m.CustomCA,
+		"auth_ca":   m.AuthCA,
+	}
+	if !m.useEphemeral() {
+		// otherwise missing files are replaced by a temporary certificate
+		values["cert"] = []string{m.Cert}
+		values["key"] = []string{m.Key}
+		values["pkcs12"] = []string{m.Bundle}
+	}
+	for key, list := range values {
+		for _, value := range list {
+			if err := checkPem(value); err != nil {
+				issues = append(issues, dx.Issue{Key: key, Message: err.Error()})
+			}
+		}
+	}
+	if len(issues) > 0 {
+		return issues
+	}
+
+	// load the TLS material to detect encryption and mismatch problems
+	tmp := m.copy()
+	if err := expandTLS(&tmp); err != nil {
+		key := "key"
+		if m.Bundle != "" {
+			key = "pkcs12"
+		}
+		issues = append(issues, dx.Issue{Key: key, Message: err.Error()})
+	}
+	return issues
+}
+
+// Start watching the TLS files, if enabled.
+func (m *Module) Start() (err error) {
+	if !m.Enabled {
+		return nil
+	}
+	if m.useEphemeral() {
+		m.log().Warning("TLS certificate not available; using a temporary self-signed certificate")
+	}
+	if !m.Watch {
+		return nil
+	}
+	if m.watcher, err = NewWatcher(m, m.Logger, nil); err != nil {
+		return errors.Wrap(err, "failed to watch TLS files")
+	}
+	return nil
+}
+
+// Stop watching the TLS files.
+func (m *Module) Stop() error {
+	if m.watcher == nil {
+		return nil
+	}
+	err := m.watcher.Close()
+	m.watcher = nil
+	return err
+}
+
+// Provide the TLS settings loaded by the module as a `*Credentials`
+// instance; returns `nil` if TLS is not enabled.
+func (m *Module) Provide() (any, error) {
+	if !m.Enabled {
+		return nil, nil
+	}
+	settings, err := m.Settings()
+	if err != nil {
+		return nil, err
+	}
+	return &Credentials{settings: settings, watcher: m.watcher}, nil
+}
+
+// Settings returns the TLS settings loaded by the module.
+func (m *Module) Settings() (*Settings, error) {
 	if err := expandTLS(m); err != nil {
 		return nil, err
 	}
+	mode, ok := clientAuthModes[m.Auth]
+	if !ok {
+		return nil, errors.Errorf("invalid client authentication mode: %s", m.Auth)
+	}
+	policy, err := m.Policy()
+	if err != nil {
+		return nil, err
+	}
 	return &Settings{
 		SystemCAs:   m.SystemCA,
 		Certificate: m.cert,
 		PrivateKey:  m.key,
 		CustomCAs:   m.customCAs,
+		AuthCAs:     m.authCAs,
+		ClientAuth:  mode,
+		Ephemeral:   m.useEphemeral(),
+		Policy:      policy,
 	}, nil
 }
+
+func (m *Module) log() xlog.Logger {
+	if m.Logger == nil {
+		return xlog.Discard()
+	}
+	return m.Logger
+}
+
+// apply minimal default settings.
+func defaultSettings() *Module {
+	return &Module{Preset: PresetIntermediate, ExpiryWarning: DefaultExpiryWarning}
+}
+
+type config struct {
+	TLS *Module `json:"tls" yaml:"tls" mapstructure:"tls" desc:"TLS settings"`
+}
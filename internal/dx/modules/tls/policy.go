@@ -0,0 +1,230 @@
+package tls
+
+import (
+	stdTLS "crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bcessa/echo-service/internal/dx"
+)
+
+// Named TLS policies, based on the Mozilla server side TLS guidelines.
+const (
+	// TLS 1.3 only; for services with modern clients.
+	PresetModern = "modern"
+
+	// TLS 1.2 and 1.3 with AEAD cipher suites; recommended for most services.
+	PresetIntermediate = "intermediate"
+
+	// TLS 1.0 and newer; only for services supporting very old clients.
+	PresetLegacy = "legacy"
+)
+
+// Policy applied to TLS connections.
+type Policy struct {
+	// Range of TLS versions supported.
+	MinVersion uint16
+	MaxVersion uint16
+
+	// Cipher suites allowed; not configurable for TLS 1.3.
+	CipherSuites []uint16
+
+	// Elliptic curves used in ECDHE handshakes, in preference order.
+	Curves []stdTLS.CurveID
+
+	// Whether session ticket resumption is disabled.
+	SessionTicketsDisabled bool
+
+	// Application protocols supported, in preference order.
+	ALPN []string
+}
+
+// Apply the policy to the provided TLS configuration.
+func (p Policy) Apply(conf *stdTLS.Config) {
+	conf.MinVersion = p.MinVersion
+	conf.MaxVersion = p.MaxVersion
+	conf.CipherSuites = p.CipherSuites
+	conf.CurvePreferences = p.Curves
+	conf.SessionTicketsDisabled = p.SessionTicketsDisabled
+	conf.NextProtos = p.ALPN
+}
+
+var (
+	tlsVersions = map[string]uint16{
+		"1.0": stdTLS.VersionTLS10,
+		"1.1": stdTLS.VersionTLS11,
+		"1.2": stdTLS.VersionTLS12,
+		"1.3": stdTLS.VersionTLS13,
+	}
+
+	tlsCurves = map[string]stdTLS.CurveID{
+		"X25519": stdTLS.X25519,
+		"P256":   stdTLS.CurveP256,
+		"P384":   stdTLS.CurveP384,
+		"P521":   stdTLS.CurveP521,
+	}
+
+	// ECDHE key exchange with AEAD ciphers.
+	intermediateCiphers = []uint16{
+		stdTLS.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		stdTLS.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		stdTLS.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		stdTLS.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		stdTLS.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+		stdTLS.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	}
+
+	presets = map[string]Policy{
+		PresetModern: {
+			MinVersion: stdTLS.VersionTLS13,
+			Curves:     []stdTLS.CurveID{stdTLS.X25519, stdTLS.CurveP256, stdTLS.CurveP384},
+		},
+		PresetIntermediate: {
+			MinVersion:   stdTLS.VersionTLS12,
+			CipherSuites: intermediateCiphers,
+			Curves:       []stdTLS.CurveID{stdTLS.X25519, stdTLS.CurveP256, stdTLS.CurveP384},
+		},
+		PresetLegacy: {
+			MinVersion: stdTLS.VersionTLS10,
+			CipherSuites: append(slices.Clone(intermediateCiphers),
+				stdTLS.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+				stdTLS.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+				stdTLS.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+				stdTLS.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+				stdTLS.TLS_RSA_WITH_AES_128_GCM_SHA256,
+				stdTLS.TLS_RSA_WITH_AES_256_GCM_SHA384,
+				stdTLS.TLS_RSA_WITH_AES_128_CBC_SHA,
+				stdTLS.TLS_RSA_WITH_AES_256_CBC_SHA,
+			),
+			Curves: []stdTLS.CurveID{stdTLS.X25519, stdTLS.CurveP256, stdTLS.CurveP384, stdTLS.CurveP521},
+		},
+	}
+)
+
+// Policy returns the TLS policy defined by the module settings. Values
+// explicitly set take precedence over the ones in the selected preset.
+func (m *Module) Policy() (Policy, error) {
+	name := m.Preset
+	if name == "" {
+		name = PresetIntermediate
+	}
+	base, ok := presets[name]
+	if !ok {
+		return Policy{}, fmt.Errorf("invalid preset: %s", name)
+	}
+	p := Policy{
+		MinVersion:             base.MinVersion,
+		MaxVersion:             base.MaxVersion,
+		CipherSuites:           slices.Clone(base.CipherSuites),
+		Curves:                 slices.Clone(base.Curves),
+		SessionTicketsDisabled: m.NoSessionTickets,
+		ALPN:                   slices.Clone(m.ALPN),
+	}
+	var err error
+	if m.MinVersion != "" {
+		if p.MinVersion, err = parseVersion(m.MinVersion); err != nil {
+			return Policy{}, err
+		}
+	}
+	if m.MaxVersion != "" {
+		if p.MaxVersion, err = parseVersion(m.MaxVersion); err != nil {
+			return Policy{}, err
+		}
+	}
+	if p.MaxVersion != 0 && p.MinVersion > p.MaxVersion {
+		return Policy{}, fmt.Errorf("minimum version %s is greater than maximum version %s",
+			stdTLS.VersionName(p.MinVersion), stdTLS.VersionName(p.MaxVersion))
+	}
+	if len(m.Ciphers) > 0 {
+		p.CipherSuites = []uint16{}
+		for _, name := range m.Ciphers {
+			id, err := parseCipher(name)
+			if err != nil {
+				return Policy{}, err
+			}
+			p.CipherSuites = append(p.CipherSuites, id)
+		}
+	}
+	if len(m.Curves) > 0 {
+		p.Curves = []stdTLS.CurveID{}
+		for _, name := range m.Curves {
+			id, ok := tlsCurves[strings.ToUpper(name)]
+			if !ok {
+				return Policy{}, fmt.Errorf("invalid curve: %s", name)
+			}
+			p.Curves = append(p.Curves, id)
+		}
+	}
+	return p, nil
+}
+
+// validatePolicy returns the problems found on the TLS policy settings.
+func (m *Module) validatePolicy() []dx.Issue {
+	issues := []dx.Issue{}
+	if _, ok := presets[m.Preset]; m.Preset != "" && !ok {
+		issues = append(issues, dx.Issue{Key: "preset", Message: fmt.Sprintf("invalid preset: %s", m.Preset)})
+	}
+	versions := []struct {
+		key   string
+		value string
+	}{
+		{key: "min_version", value: m.MinVersion},
+		{key: "max_version", value: m.MaxVersion},
+	}
+	for _, v := range versions {
+		if _, err := parseVersion(v.value); v.value != "" && err != nil {
+			issues = append(issues, dx.Issue{Key: v.key, Message: err.Error()})
+		}
+	}
+	for _, name := range m.Ciphers {
+		if _, err := parseCipher(name); err != nil {
+			issues = append(issues, dx.Issue{Key: "cipher_suites", Message: err.Error()})
+		}
+	}
+	for _, name := range m.Curves {
+		if _, ok := tlsCurves[strings.ToUpper(name)]; !ok {
+			issues = append(issues, dx.Issue{Key: "curves", Message: fmt.Sprintf("invalid curve: %s", name)})
+		}
+	}
+	if len(issues) > 0 {
+		return issues
+	}
+	p, err := m.Policy()
+	if err != nil {
+		return append(issues, dx.Issue{Key: "min_version", Message: err.Error()})
+	}
+	if len(m.Ciphers) > 0 && p.MinVersion == stdTLS.VersionTLS13 {
+		issues = append(issues, dx.Issue{Key: "cipher_suites", Message: "cipher suites are not configurable for TLS 1.3"})
+	}
+	if len(p.CipherSuites) > 0 && !slices.ContainsFunc(p.CipherSuites, http2Cipher) {
+		issues = append(issues, dx.Issue{
+			Key:     "cipher_suites",
+			Message: "HTTP/2 requires TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 or TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+		})
+	}
+	return issues
+}
+
+func parseVersion(value string) (uint16, error) {
+	v, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(value), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("invalid TLS version: %s", value)
+	}
+	return v, nil
+}
+
+func parseCipher(name string) (uint16, error) {
+	for _, cs := range append(stdTLS.CipherSuites(), stdTLS.InsecureCipherSuites()...) {
+		if strings.EqualFold(cs.Name, name) {
+			return cs.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid cipher suite: %s", name)
+}
+
+// http2Cipher returns `true` for the cipher suites required by HTTP/2
+// servers; RFC 7540, section 9.2.2.
+func http2Cipher(id uint16) bool {
+	return id == stdTLS.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 || id == stdTLS.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+}
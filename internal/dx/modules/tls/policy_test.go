@@ -0,0 +1,141 @@
+package tls
+
+import (
+	stdTLS "crypto/tls"
+	"slices"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		module   Module
+		min, max uint16
+		ciphers  []uint16
+		curves   []stdTLS.CurveID
+	}{
+		{
+			name:    "default preset",
+			module:  Module{},
+			min:     stdTLS.VersionTLS12,
+			ciphers: intermediateCiphers,
+			curves:  []stdTLS.CurveID{stdTLS.X25519, stdTLS.CurveP256, stdTLS.CurveP384},
+		},
+		{
+			name:   "modern preset",
+			module: Module{Preset: PresetModern},
+			min:    stdTLS.VersionTLS13,
+			curves: []stdTLS.CurveID{stdTLS.X25519, stdTLS.CurveP256, stdTLS.CurveP384},
+		},
+		{
+			name:    "legacy preset",
+			module:  Module{Preset: PresetLegacy},
+			min:     stdTLS.VersionTLS10,
+			ciphers: presets[PresetLegacy].CipherSuites,
+			curves:  []stdTLS.CurveID{stdTLS.X25519, stdTLS.CurveP256, stdTLS.CurveP384, stdTLS.CurveP521},
+		},
+		{
+			name: "overrides",
+			module: Module{
+				Preset:     PresetIntermediate,
+				MinVersion: "TLS1.3",
+				MaxVersion: "1.3",
+				Ciphers:    []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+				Curves:     []string{"p256"},
+			},
+			min:     stdTLS.VersionTLS13,
+			max:     stdTLS.VersionTLS13,
+			ciphers: []uint16{stdTLS.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+			curves:  []stdTLS.CurveID{stdTLS.CurveP256},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.module.Policy()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.MinVersion != tt.min || p.MaxVersion != tt.max {
+				t.Errorf("unexpected versions: %x-%x", p.MinVersion, p.MaxVersion)
+			}
+			if !slices.Equal(p.CipherSuites, tt.ciphers) {
+				t.Errorf("unexpected cipher suites: %v", p.CipherSuites)
+			}
+			if !slices.Equal(p.Curves, tt.curves) {
+				t.Errorf("unexpected curves: %v", p.Curves)
+			}
+		})
+	}
+
+	// presets are not modified by overrides
+	m := Module{Ciphers: []string{"TLS_RSA_WITH_AES_128_GCM_SHA256"}}
+	if _, err := m.Policy(); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(presets[PresetIntermediate].CipherSuites, intermediateCiphers) {
+		t.Error("preset modified")
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		module Module
+		issues []string // expected issue keys, in order
+	}{
+		{name: "presets", module: Module{Preset: PresetModern}},
+		{name: "overrides", module: Module{MinVersion: "1.2", MaxVersion: "1.3", Curves: []string{"X25519"}}},
+		{name: "invalid preset", module: Module{Preset: "strict"}, issues: []string{"preset"}},
+		{
+			name:   "invalid versions",
+			module: Module{MinVersion: "1.4", MaxVersion: "ssl3"},
+			issues: []string{"min_version", "max_version"},
+		},
+		{
+			name:   "invalid ciphers and curves",
+			module: Module{Ciphers: []string{"TLS_NULL"}, Curves: []string{"P224"}},
+			issues: []string{"cipher_suites", "curves"},
+		},
+		{
+			name:   "minimum version greater than maximum",
+			module: Module{MinVersion: "1.3", MaxVersion: "1.2"},
+			issues: []string{"min_version"},
+		},
+		{
+			name:   "maximum version lower than preset minimum",
+			module: Module{Preset: PresetModern, MaxVersion: "1.2"},
+			issues: []string{"min_version"},
+		},
+		{
+			name: "cipher suites for TLS 1.3",
+			module: Module{
+				Preset:  PresetModern,
+				Ciphers: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			},
+			issues: []string{"cipher_suites"},
+		},
+		{
+			name:   "missing HTTP/2 required cipher",
+			module: Module{Ciphers: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}},
+			issues: []string{"cipher_suites"},
+		},
+		{
+			name: "HTTP/2 required cipher",
+			module: Module{Ciphers: []string{
+				"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+				"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := []string{}
+			for _, issue := range tt.module.validatePolicy() {
+				keys = append(keys, issue.Key)
+			}
+			if !slices.Equal(keys, tt.issues) {
+				t.Errorf("expected issues for %v, got %v", tt.issues, keys)
+			}
+		})
+	}
+}
@@ -2,22 +2,59 @@ package tls
 
 import (
 	"encoding/base64"
+	"fmt"
 	"os"
 	"path"
+	"slices"
+	"strings"
+
+	"go.bryk.io/pkg/errors"
 )
 
 func expandTLS(ts *Module) (err error) {
-	if ts.Cert != "" {
-		ts.cert, err = loadPem(ts.Cert)
+	switch {
+	case ts.useEphemeral():
+		if ts.temporary == nil || ts.temporary.expiresWithin(ephemeralRenewal) {
+			if ts.temporary, err = ephemeral(); err != nil {
+				return errors.Wrap(err, "failed to generate temporary certificate")
+			}
+		}
+		ts.cert, ts.key = ts.temporary.Certificate, ts.temporary.PrivateKey
+	case ts.Bundle != "":
+		pass, err := resolveSecret(ts.Passphrase)
 		if err != nil {
 			return err
 		}
-	}
-	if ts.Key != "" {
-		ts.key, err = loadPem(ts.Key)
+		data, err := loadPem(ts.Bundle)
 		if err != nil {
 			return err
 		}
+		if ts.cert, ts.key, err = decodeBundle(data, pass); err != nil {
+			return err
+		}
+	default:
+		if ts.Cert != "" {
+			if ts.cert, err = loadPem(ts.Cert); err != nil {
+				return err
+			}
+		}
+		if ts.Key != "" {
+			if ts.key, err = loadPem(ts.Key); err != nil {
+				return err
+			}
+			pass, err := resolveSecret(ts.Passphrase)
+			if err != nil {
+				return err
+			}
+			if ts.key, err = decryptKey(ts.key, pass); err != nil {
+				return err
+			}
+		}
+		if len(ts.cert) > 0 && len(ts.key) > 0 {
+			if err = checkPair(ts.cert, ts.key); err != nil {
+				return err
+			}
+		}
 	}
 	ts.customCAs = [][]byte{}
 	for _, ca := range ts.CustomCA {
@@ -33,11 +70,32 @@ func expandTLS(ts *Module) (err error) {
 		if err != nil {
 			return err
 		}
-		ts.customCAs = append(ts.authCAs, cp)
+		ts.authCAs = append(ts.authCAs, cp)
 	}
 	return nil
 }
 
+// LoadPEM returns the contents of a PEM value; `value` can be a path to a
+// PEM file or a base64-encoded PEM.
+func LoadPEM(value string) ([]byte, error) {
+	return loadPem(value)
+}
+
+// LoadKey returns the unencrypted contents of a PEM private key; `value`
+// is handled like in `LoadPEM`, and `passphrase` is a secret reference
+// used to decrypt the key, if required.
+func LoadKey(value, passphrase string) ([]byte, error) {
+	data, err := loadPem(value)
+	if err != nil {
+		return nil, err
+	}
+	pass, err := resolveSecret(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return decryptKey(data, pass)
+}
+
 func loadPem(value string) ([]byte, error) {
 	data, err := base64.StdEncoding.DecodeString(value)
 	if err == nil {
@@ -45,3 +103,71 @@ func loadPem(value string) ([]byte, error) {
 	}
 	return os.ReadFile(path.Clean(value))
 }
+
+// checkPem verifies a PEM value, as accepted by `loadPem`, is available.
+func checkPem(value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := base64.StdEncoding.DecodeString(value); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(path.Clean(value)); err != nil {
+		return fmt.Errorf("file not available: %s", value)
+	}
+	return nil
+}
+
+// useEphemeral returns `true` if a temporary certificate must be used in
+// place of the certificate and private key files.
+func (m *Module) useEphemeral() bool {
+	if !m.Ephemeral {
+		return false
+	}
+	if m.Bundle != "" {
+		return checkPem(m.Bundle) != nil
+	}
+	for _, value := range []string{m.Cert, m.Key} {
+		if checkPem(value) != nil || value == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// files returns the paths of all the PEM files used by the module; values
+// provided as base64-encoded PEM are ignored.
+func (m *Module) files() []string {
+	values := append([]string{}, append(m.CustomCA, m.AuthCA...)...)
+	if !m.useEphemeral() {
+		values = append(values, m.Cert, m.Key, m.Bundle)
+	}
+	if ref, ok := strings.CutPrefix(m.Passphrase, "file:"); ok {
+		values = append(values, ref)
+	}
+	list := []string{}
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if _, err := base64.StdEncoding.DecodeString(value); err == nil {
+			continue
+		}
+		list = append(list, value)
+	}
+	return list
+}
+
+// copy returns a new module instance with the same settings; the
+// temporary certificate, if any, is shared.
+func (m *Module) copy() Module {
+	c := *m
+	c.CustomCA = slices.Clone(m.CustomCA)
+	c.AuthCA = slices.Clone(m.AuthCA)
+	c.Ciphers = slices.Clone(m.Ciphers)
+	c.Curves = slices.Clone(m.Curves)
+	c.ALPN = slices.Clone(m.ALPN)
+	c.cert, c.key, c.customCAs, c.authCAs = nil, nil, nil, nil
+	c.watcher = nil
+	return c
+}
@@ -0,0 +1,269 @@
+package tls
+
+import (
+	"bytes"
+	"context"
+	stdTLS "crypto/tls"
+	"crypto/x509"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// instrumentation scope used by the watcher.
+	scope = "github.com/bcessa/echo-service/internal/dx/modules/tls"
+
+	// wait for file events to settle before reloading.
+	debounce = 500 * time.Millisecond
+
+	// how often to check for certificates about to expire.
+	expiryCheck = time.Hour
+)
+
+// Watcher keeps the TLS material loaded from files up to date. When any
+// of the files changes, the new material is loaded and validated before
+// replacing the current one; invalid material is reported and ignored.
+type Watcher struct {
+	mod      Module
+	current  atomic.Pointer[Settings]
+	cert     atomic.Pointer[stdTLS.Certificate]
+	authCAs  atomic.Pointer[x509.CertPool]
+	onChange func(*Settings)
+	log      xlog.Logger
+	fsw      *fsnotify.Watcher
+	reg      metric.Registration
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewWatcher starts monitoring the files used by the module settings.
+// `onChange` is called every time new valid material is loaded; `log` is
+// used to report problems and certificates about to expire.
+func NewWatcher(m *Module, log xlog.Logger, onChange func(*Settings)) (*Watcher, error) {
+	w := &Watcher{
+		mod:      m.copy(),
+		onChange: onChange,
+		log:      log,
+		done:     make(chan struct{}),
+	}
+	if w.log == nil {
+		w.log = xlog.Discard()
+	}
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	// monitor the directories containing the files, this supports files
+	// replaced using symlinks; for example on Kubernetes secrets
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	dirs := map[string]bool{}
+	for _, file := range w.mod.files() {
+		dirs[filepath.Dir(file)] = true
+	}
+	for dir := range dirs {
+		if err = fsw.Add(dir); err != nil {
+			_ = fsw.Close()
+			return nil, errors.Wrapf(err, "failed to watch %s", dir)
+		}
+	}
+	w.fsw = fsw
+
+	// expiry countdown
+	gauge, err := otel.Meter(scope).Float64ObservableGauge("tls.certificate.expiry",
+		metric.WithDescription("time remaining before the certificate expires"),
+		metric.WithUnit("s"))
+	if err == nil {
+		w.reg, _ = otel.Meter(scope).RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			if leaf := w.leaf(); leaf != nil {
+				attrs := attribute.String("tls.certificate.subject", leaf.Subject.String())
+				o.ObserveFloat64(gauge, time.Until(leaf.NotAfter).Seconds(), metric.WithAttributes(attrs))
+			}
+			return nil
+		}, gauge)
+	}
+
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Settings returns the TLS material currently in use.
+func (w *Watcher) Settings() *Settings {
+	return w.current.Load()
+}
+
+// GetCertificate returns the certificate currently in use; can be used
+// directly on a `tls.Config` instance to support certificate rotation.
+func (w *Watcher) GetCertificate(_ *stdTLS.ClientHelloInfo) (*stdTLS.Certificate, error) {
+	return w.cert.Load(), nil
+}
+
+// GetClientCertificate returns the certificate currently in use; can be
+// used directly on a `tls.Config` instance to support client certificate
+// rotation.
+func (w *Watcher) GetClientCertificate(_ *stdTLS.CertificateRequestInfo) (*stdTLS.Certificate, error) {
+	return w.cert.Load(), nil
+}
+
+// ServerConfig returns a standard TLS configuration for servers that
+// always uses the certificate and client authentication CAs currently in
+// use. The names of the authentication CAs are not advertised to clients,
+// since they can change.
+func (w *Watcher) ServerConfig() (*stdTLS.Config, error) {
+	settings := w.Settings()
+	conf, err := settings.ServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf.Certificates = nil
+	conf.GetCertificate = w.GetCertificate
+	if settings.ClientAuth != stdTLS.NoClientCert {
+		conf.ClientCAs = nil
+		conf.VerifyConnection = verifyClient(settings.ClientAuth, w.authCAs.Load)
+	}
+	return conf, nil
+}
+
+// Close the watcher and free related resources.
+func (w *Watcher) Close() error {
+	close(w.done)
+	w.wg.Wait()
+	if w.reg != nil {
+		_ = w.reg.Unregister()
+	}
+	return w.fsw.Close()
+}
+
+func (w *Watcher) run() {
+	defer w.wg.Done()
+	var (
+		files   = w.mod.files()
+		pending <-chan time.Time
+		expiry  = time.NewTicker(expiryCheck)
+	)
+	defer expiry.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			for _, file := range files {
+				if filepath.Clean(ev.Name) == filepath.Clean(file) || ev.Has(fsnotify.Create) {
+					pending = time.After(debounce)
+					break
+				}
+			}
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.log.WithField("error", err.Error()).Warning("TLS files watcher")
+		case <-pending:
+			pending = nil
+			prev := w.Settings()
+			if err := w.reload(); err != nil {
+				w.log.WithField("error", err.Error()).Error("invalid TLS material; keeping current certificate")
+				continue
+			}
+			if next := w.Settings(); !prev.equal(next) {
+				w.log.WithField("subject", w.leaf().Subject.String()).Info("TLS certificate rotated")
+				if w.onChange != nil {
+					w.onChange(next)
+				}
+			}
+		case <-expiry.C:
+			w.checkExpiry()
+		}
+	}
+}
+
+// reload and validate the TLS material.
+func (w *Watcher) reload() error {
+	mod := w.mod.copy()
+	settings, err := mod.Settings()
+	if err != nil {
+		return err
+	}
+	cert, err := stdTLS.X509KeyPair(settings.Certificate, settings.PrivateKey)
+	if err != nil {
+		return errors.Wrap(err, "certificate and private key don't match")
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	if now.Before(cert.Leaf.NotBefore) || now.After(cert.Leaf.NotAfter) {
+		return errors.Errorf("certificate is not valid at this time; valid from %s until %s",
+			cert.Leaf.NotBefore.Format(time.RFC3339), cert.Leaf.NotAfter.Format(time.RFC3339))
+	}
+	pool, err := authPool(settings.AuthCAs)
+	if err != nil {
+		return err
+	}
+	w.current.Store(settings)
+	w.cert.Store(&cert)
+	w.authCAs.Store(pool)
+	w.checkExpiry()
+	return nil
+}
+
+// checkExpiry logs a warning if the certificate is about to expire.
+func (w *Watcher) checkExpiry() {
+	leaf := w.leaf()
+	if leaf == nil || w.mod.ExpiryWarning <= 0 {
+		return
+	}
+	if remaining := time.Until(leaf.NotAfter); remaining < w.mod.ExpiryWarning {
+		w.log.WithFields(xlog.Fields{
+			"subject":    leaf.Subject.String(),
+			"expires_in": remaining.Round(time.Minute).String(),
+		}).Warning("TLS certificate about to expire")
+	}
+}
+
+func (w *Watcher) leaf() *x509.Certificate {
+	if cert := w.cert.Load(); cert != nil {
+		return cert.Leaf
+	}
+	return nil
+}
+
+// equal returns `true` if both settings use the same TLS material.
+func (s *Settings) equal(other *Settings) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	if !bytes.Equal(s.Certificate, other.Certificate) || !bytes.Equal(s.PrivateKey, other.PrivateKey) {
+		return false
+	}
+	return equalPEMs(s.CustomCAs, other.CustomCAs) && equalPEMs(s.AuthCAs, other.AuthCAs)
+}
+
+func equalPEMs(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package tls
+
+import (
+	"bytes"
+	stdTLS "crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestWatcher(t *testing.T) {
+	metricsReader()
+	ca := newCA(t, "test CA")
+	dir := t.TempDir()
+	cert, key := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	first := issueNamed(t, ca, "first.watcher.test")
+	if err := first.Save(cert, key, false); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan *Settings, 1)
+	w, err := NewWatcher(&Module{
+		Enabled: true,
+		Cert:    cert,
+		Key:     key,
+		Preset:  PresetIntermediate,
+	}, nil, func(s *Settings) { changes <- s })
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf, err := w.ServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	presented(t, w, first)
+	if err = handshake(t, conf, clientConfig(t, ca, nil)); err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if expiry(t)["CN=first.watcher.test"] <= 0 {
+		t.Error("expected expiry gauge for the certificate in use")
+	}
+
+	t.Run("rotation", func(t *testing.T) {
+		second := issueNamed(t, ca, "second.watcher.test")
+		if err := second.Save(cert, key, true); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case s := <-changes:
+			if !bytes.Equal(s.Certificate, second.Certificate) {
+				t.Error("unexpected certificate reported as rotated")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("certificate not rotated")
+		}
+
+		// configurations already in use present the new certificate
+		presented(t, w, second)
+		if err := handshake(t, conf, clientConfig(t, ca, nil)); err != nil {
+			t.Fatalf("handshake failed after rotation: %s", err)
+		}
+		gauge := expiry(t)
+		if _, ok := gauge["CN=first.watcher.test"]; ok {
+			t.Error("expiry reported for the previous certificate")
+		}
+		if gauge["CN=second.watcher.test"] <= 0 {
+			t.Error("expected expiry gauge for the rotated certificate")
+		}
+	})
+
+	t.Run("invalid material", func(t *testing.T) {
+		prev := w.Settings()
+		if err := os.WriteFile(cert, []byte("invalid"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(3 * debounce)
+		select {
+		case <-changes:
+			t.Error("invalid material reported as rotated")
+		default:
+		}
+		if w.Settings() != prev {
+			t.Error("invalid material replaced the current certificate")
+		}
+	})
+
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(expiry(t)) > 0 {
+		t.Error("expiry gauge reported after closing the watcher")
+	}
+}
+
+// presented verifies the watcher returns the `kp` certificate.
+func presented(t *testing.T, w *Watcher, kp *KeyPair) {
+	t.Helper()
+	expected, err := stdTLS.X509KeyPair(kp.Certificate, kp.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := w.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cert.Certificate[0], expected.Certificate[0]) {
+		t.Errorf("unexpected certificate presented: %s", cert.Leaf.Subject)
+	}
+}
+
+// expiry returns the values reported by the certificate expiry gauge, by
+// certificate subject.
+func expiry(t *testing.T) map[string]float64 {
+	t.Helper()
+	res := map[string]float64{}
+	for _, m := range collect(t) {
+		if m.Name != "tls.certificate.expiry" {
+			continue
+		}
+		for _, dp := range m.Data.(metricdata.Gauge[float64]).DataPoints {
+			subject, _ := dp.Attributes.Value("tls.certificate.subject")
+			res[subject.AsString()] = dp.Value
+		}
+	}
+	return res
+}
+
+// issueNamed issues a server certificate for "localhost" using `name` as
+// common name.
+func issueNamed(t *testing.T, ca *KeyPair, name string) *KeyPair {
+	t.Helper()
+	kp, err := ca.Issue(CertificateOptions{
+		CommonName: name,
+		DNSNames:   []string{"localhost"},
+		Lifetime:   time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
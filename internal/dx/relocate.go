@@ -0,0 +1,152 @@
+package dx
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// move describes settings relocated by a module.
+type move struct {
+	from string                  // previous location
+	to   string                  // current location
+	keys []string                // settings supported, relative to the location
+	conf map[string]reflect.Type // settings supported at the first level
+}
+
+// Deprecated returns an issue for every relocated setting still using its
+// previous location on the configuration managed by `v`. These settings
+// are still applied, but should be moved to their current location.
+func (r *Registry) Deprecated(v *viper.Viper) []Issue {
+	r.mu.Lock()
+	mods, err := r.sorted()
+	r.mu.Unlock()
+	if err != nil {
+		return nil
+	}
+	_, _, issues := relocate(v, moves(mods))
+	if file := v.ConfigFileUsed(); file != "" {
+		locate(file, issues)
+	}
+	return issues
+}
+
+// moves returns the settings relocated by the provided modules.
+func moves(mods []Module) []move {
+	list := []move{}
+	for _, mod := range mods {
+		rm, ok := mod.(Relocator)
+		if !ok {
+			continue
+		}
+		cm, ok := mod.(Configurable)
+		if !ok {
+			continue
+		}
+		for from, to := range rm.Moved() {
+			t, ok := settingType(reflect.TypeOf(cm.Defaults()), to)
+			if !ok {
+				continue
+			}
+			list = append(list, move{from: from, to: to, keys: leafKeys(t), conf: knownKeys(t)})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].from < list[j].from })
+	return list
+}
+
+// relocate returns a Viper instance where the settings still using their
+// previous location are available on the current one; settings already
+// using the current location take precedence. The previous key of every
+// setting applied is returned, indexed by its current key; and an issue
+// for every previous location in use. `v` is returned as-is if no relocated
+// setting needs to be applied.
+func relocate(v *viper.Viper, list []move) (*viper.Viper, map[string]string, []Issue) {
+	issues := []Issue{}
+	applied := map[string]string{}
+	for _, mv := range list {
+		used := false
+		for _, key := range mv.keys {
+			prev := mv.from + "." + key
+			if !v.IsSet(prev) {
+				continue
+			}
+			used = true
+			if cur := mv.to + "." + key; !v.IsSet(cur) {
+				applied[cur] = prev
+			}
+		}
+		if used {
+			issues = append(issues, Issue{
+				Key:     mv.from,
+				Message: fmt.Sprintf("deprecated location, use %q instead", mv.to),
+			})
+		}
+	}
+	if len(applied) == 0 {
+		return v, applied, issues
+	}
+	nv := viper.New()
+	if err := nv.MergeConfigMap(v.AllSettings()); err != nil {
+		return v, nil, issues
+	}
+	for cur, prev := range applied {
+		nv.Set(cur, v.Get(prev))
+	}
+	return nv, applied, issues
+}
+
+// pluck removes and returns the settings at the dot-separated `key` on
+// the provided values; parent entries left empty are also removed.
+func pluck(values map[string]any, key string) (map[string]any, bool) {
+	name, rest, nested := strings.Cut(key, ".")
+	if !nested {
+		res, ok := values[name].(map[string]any)
+		if ok {
+			delete(values, name)
+		}
+		return res, ok
+	}
+	sub, ok := values[name].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	res, ok := pluck(sub, rest)
+	if len(sub) == 0 {
+		delete(values, name)
+	}
+	return res, ok
+}
+
+// settingType returns the type of the setting at the dot-separated `key`
+// on the provided configuration structure type.
+func settingType(t reflect.Type, key string) (reflect.Type, bool) {
+	for _, k := range strings.Split(key, ".") {
+		ft, ok := knownKeys(t)[k]
+		if !ok {
+			return nil, false
+		}
+		t = ft
+	}
+	return t, true
+}
+
+// leafKeys returns the dot-separated path of every setting on the provided
+// configuration structure type, excluding nested structures.
+func leafKeys(t reflect.Type) []string {
+	list := []string{}
+	for key, ft := range knownKeys(t) {
+		if !isStruct(ft) {
+			list = append(list, key)
+			continue
+		}
+		for _, sub := range leafKeys(ft) {
+			list = append(list, key+"."+sub)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,105 @@
+package dx
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/errors"
+)
+
+func TestRegistryRelocate(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		env        map[string]string
+		settings   fakeSettings
+		deprecated bool
+		unknown    []string
+	}{
+		{
+			name:     "current location",
+			config:   "fake:\n  enabled: true\n  name: current\n",
+			settings: fakeSettings{Enabled: true, Name: "current"},
+		},
+		{
+			name:       "previous location",
+			config:     "old:\n  fake:\n    enabled: true\n    name: previous\n",
+			settings:   fakeSettings{Enabled: true, Name: "previous"},
+			deprecated: true,
+		},
+		{
+			name:       "current location takes precedence",
+			config:     "fake:\n  name: current\nold:\n  fake:\n    enabled: true\n    name: previous\n",
+			settings:   fakeSettings{Enabled: true, Name: "current"},
+			deprecated: true,
+		},
+		{
+			name:       "previous location on ENV",
+			config:     "fake:\n  enabled: true\n",
+			env:        map[string]string{"TEST_OLD_FAKE_NAME": "env"},
+			settings:   fakeSettings{Enabled: true, Name: "env"},
+			deprecated: true,
+		},
+		{
+			name:       "unknown setting on previous location",
+			config:     "old:\n  fake:\n    enabled: true\n    nmae: previous\n",
+			settings:   fakeSettings{Enabled: true},
+			deprecated: true,
+			unknown:    []string{"old.fake.nmae"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, val := range tt.env {
+				t.Setenv(k, val)
+			}
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(file, []byte(tt.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			v := viper.New()
+			v.SetEnvPrefix("test")
+			v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+			v.AutomaticEnv()
+			v.SetConfigFile(file)
+			if err := v.ReadInConfig(); err != nil {
+				t.Fatal(err)
+			}
+			mod := &fakeModule{name: "fake", moved: map[string]string{"old.fake": "fake"}}
+			reg := NewRegistry("test", mod)
+			if err := reg.Load(v); err != nil {
+				t.Fatal(err)
+			}
+			if mod.Settings == nil || *mod.Settings != tt.settings {
+				t.Errorf("expected settings %+v, got %+v", tt.settings, mod.Settings)
+			}
+
+			deprecated := reg.Deprecated(v)
+			if (len(deprecated) > 0) != tt.deprecated {
+				t.Fatalf("expected deprecated=%v, got %v", tt.deprecated, deprecated)
+			}
+			for _, issue := range deprecated {
+				if issue.Key != "old.fake" {
+					t.Errorf("unexpected deprecated setting: %s", issue)
+				}
+				if _, ok := tt.env["TEST_OLD_FAKE_NAME"]; !ok && issue.Line == 0 {
+					t.Errorf("missing position for issue: %s", issue)
+				}
+			}
+
+			keys := []string{}
+			if ve := new(ValidationError); errors.As(reg.Validate(v), &ve) {
+				for _, issue := range ve.Issues {
+					keys = append(keys, issue.Key)
+				}
+			}
+			if !slices.Equal(keys, tt.unknown) {
+				t.Errorf("expected issues for %v, got %v", tt.unknown, keys)
+			}
+		})
+	}
+}
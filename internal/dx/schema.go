@@ -0,0 +1,340 @@
+package dx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// JSON Schema dialect used for generated documents.
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Value used in place of secret settings.
+const redacted = "[redacted]"
+
+// Durations are represented as text; for example: "1h30m".
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Schema returns a JSON Schema document describing the configuration
+// accepted by all configurable modules in the registry. Unknown keys are
+// not allowed by the generated schema.
+func (r *Registry) Schema() ([]byte, error) {
+	defaults, err := r.defaults()
+	if err != nil {
+		return nil, err
+	}
+	root := map[string]any{
+		"$schema":              schemaDialect,
+		"title":                fmt.Sprintf("%s configuration", r.name),
+		"type":                 "object",
+		"additionalProperties": false,
+	}
+	props := map[string]any{}
+	for _, conf := range defaults {
+		sc := typeSchema(reflect.TypeOf(conf), reflect.ValueOf(conf))
+		if mp, ok := sc["properties"].(map[string]any); ok {
+			for k, v := range mp {
+				props[k] = v
+			}
+		}
+	}
+	root["properties"] = props
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// DefaultConfig returns a YAML document with the default configuration of
+// all configurable modules in the registry. Every setting is annotated with
+// its description, when available.
+func (r *Registry) DefaultConfig() ([]byte, error) {
+	defaults, err := r.defaults()
+	if err != nil {
+		return nil, err
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	opts := &nodeOptions{describe: true}
+	for _, conf := range defaults {
+		node := yamlNode(reflect.TypeOf(conf), reflect.ValueOf(conf), "", opts)
+		root.Content = append(root.Content, node.Content...)
+	}
+	return encodeYAML(&yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: fmt.Sprintf("%s configuration", r.name),
+		Content:     []*yaml.Node{root},
+	})
+}
+
+// EffectiveConfig returns a YAML document with the configuration of all
+// configurable modules, as loaded from the provided Viper instance. Every
+// setting is annotated with the value returned by `origin` for its key;
+// and the values of secret settings are redacted. Relocated settings still
+// using their previous location are annotated using the previous key.
+func (r *Registry) EffectiveConfig(v *viper.Viper, origin func(key string) string) ([]byte, error) {
+	r.mu.Lock()
+	mods, err := r.sorted()
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	v, applied, _ := relocate(v, moves(mods))
+	confs, err := r.decode(func(_ Module) *viper.Viper { return v })
+	if err != nil {
+		return nil, err
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	opts := &nodeOptions{origin: func(key string) string {
+		if prev, ok := applied[key]; ok {
+			return origin(prev)
+		}
+		return origin(key)
+	}, redact: true}
+	for _, conf := range confs {
+		node := yamlNode(reflect.TypeOf(conf), reflect.ValueOf(conf), "", opts)
+		root.Content = append(root.Content, node.Content...)
+	}
+	return encodeYAML(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}})
+}
+
+// defaults returns the default configuration structure of every configurable
+// module, in dependency order. Default values declared by CLI flags are
+// applied on top of the values provided by each module.
+func (r *Registry) defaults() ([]any, error) {
+	return r.decode(func(mod Module) *viper.Viper {
+		v := viper.New()
+		for _, param := range mod.Flags(r.name) {
+			v.SetDefault(param.FlagKey, param.ByDefault)
+		}
+		return v
+	})
+}
+
+// decode the configuration structure of every configurable module, in
+// dependency order, using the Viper instance returned by `src`.
+func (r *Registry) decode(src func(mod Module) *viper.Viper) ([]any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mods, err := r.sorted()
+	if err != nil {
+		return nil, err
+	}
+	list := []any{}
+	for _, mod := range mods {
+		cm, ok := mod.(Configurable)
+		if !ok {
+			continue
+		}
+		conf := cm.Defaults()
+		if err := src(mod).Unmarshal(conf); err != nil {
+			return nil, errors.Wrapf(err, "failed decoding settings for module %s", mod.Name())
+		}
+		list = append(list, conf)
+	}
+	return list, nil
+}
+
+// settingField describes a single configuration entry on a settings
+// structure.
+type settingField struct {
+	key    string
+	desc   string
+	secret bool
+	idx    int
+}
+
+// settingFields returns the configuration entries available on the
+// provided structure type, as decoded by `mapstructure`.
+func settingFields(t reflect.Type) []settingField {
+	list := []settingField{}
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() || !supportedKind(sf.Type) {
+			continue
+		}
+		key, _, _ := strings.Cut(sf.Tag.Get("mapstructure"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(sf.Name)
+		}
+		list = append(list, settingField{
+			key:    key,
+			desc:   sf.Tag.Get("desc"),
+			secret: sf.Tag.Get("secret") == "true",
+			idx:    i,
+		})
+	}
+	return list
+}
+
+// supportedKind returns `false` for types that can't be expressed on a
+// configuration file, like functions or channels.
+func supportedKind(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return false
+	default:
+		return true
+	}
+}
+
+// typeSchema returns the JSON Schema for the provided type; if `v` is
+// valid, it is used to report default values.
+// nolint: gocyclo
+func typeSchema(t reflect.Type, v reflect.Value) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if v.IsValid() && !v.IsNil() {
+			v = v.Elem()
+		} else {
+			v = reflect.Value{}
+		}
+	}
+	var sc map[string]any
+	if t == durationType {
+		sc = map[string]any{"type": []string{"string", "integer"}}
+		if v.IsValid() && !v.IsZero() {
+			sc["default"] = time.Duration(v.Int()).String()
+		}
+		return sc
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		sc = map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sc = map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		sc = map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		sc = map[string]any{"type": "number"}
+	case reflect.String:
+		sc = map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		sc = map[string]any{"type": "array", "items": typeSchema(t.Elem(), reflect.Value{})}
+	case reflect.Map:
+		// maps can also be provided as a list of single-entry objects
+		obj := map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), reflect.Value{})}
+		sc = map[string]any{"anyOf": []any{obj, map[string]any{"type": "array", "items": obj}}}
+	case reflect.Struct:
+		props := map[string]any{}
+		for _, sf := range settingFields(t) {
+			var fv reflect.Value
+			if v.IsValid() {
+				fv = v.Field(sf.idx)
+			}
+			fs := typeSchema(t.Field(sf.idx).Type, fv)
+			if sf.desc != "" {
+				fs["description"] = sf.desc
+			}
+			props[sf.key] = fs
+		}
+		return map[string]any{"type": "object", "additionalProperties": false, "properties": props}
+	default:
+		// any value is accepted
+		return map[string]any{}
+	}
+	if v.IsValid() && !v.IsZero() {
+		sc["default"] = v.Interface()
+	}
+	return sc
+}
+
+// nodeOptions adjust the YAML representation of settings.
+type nodeOptions struct {
+	// annotate settings with their description
+	describe bool
+
+	// replace the value of secret settings
+	redact bool
+
+	// annotate settings with the value returned for their key
+	origin func(key string) string
+}
+
+// yamlNode returns a YAML representation for the provided type; if `v` is
+// valid, it is used to set the node value. `key` is the dot-separated path
+// of the setting.
+func yamlNode(t reflect.Type, v reflect.Value, key string, opts *nodeOptions) *yaml.Node {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if v.IsValid() && !v.IsNil() {
+			v = v.Elem()
+		} else {
+			v = reflect.Value{}
+		}
+	}
+	if !v.IsValid() {
+		v = reflect.Zero(t)
+	}
+	if t == durationType {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: time.Duration(v.Int()).String()}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, sf := range settingFields(t) {
+			fk := strings.TrimPrefix(key+"."+sf.key, ".")
+			kn := &yaml.Node{Kind: yaml.ScalarNode, Value: sf.key}
+			if opts.describe {
+				kn.HeadComment = sf.desc
+			}
+			var vn *yaml.Node
+			if sf.secret && opts.redact && !v.Field(sf.idx).IsZero() {
+				vn = &yaml.Node{Kind: yaml.ScalarNode, Value: redacted}
+			} else {
+				vn = yamlNode(t.Field(sf.idx).Type, v.Field(sf.idx), fk, opts)
+			}
+			if opts.origin != nil && !isStruct(t.Field(sf.idx).Type) {
+				// block collections are annotated on the key
+				if vn.Kind == yaml.ScalarNode || vn.Style == yaml.FlowStyle {
+					vn.LineComment = opts.origin(fk)
+				} else {
+					kn.LineComment = opts.origin(fk)
+				}
+			}
+			node.Content = append(node.Content, kn, vn)
+		}
+		return node
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		}
+	case reflect.Map:
+		if v.Len() == 0 {
+			return &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+		}
+	default:
+	}
+	node := new(yaml.Node)
+	if err := node.Encode(v.Interface()); err != nil {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	return node
+}
+
+// encodeYAML returns the text representation of a YAML document.
+func encodeYAML(doc *yaml.Node) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
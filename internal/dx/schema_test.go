@@ -0,0 +1,50 @@
+package dx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRegistryEffectiveConfig(t *testing.T) {
+	config := "fake:\n  name: sample\n  token: file-token\nold:\n  fake:\n    port: 8080\n"
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	reg := NewRegistry("test", &fakeModule{name: "fake", moved: map[string]string{"old.fake": "fake"}})
+	res, err := reg.EffectiveConfig(v, func(key string) string {
+		if v.InConfig(key) {
+			return "file:" + key
+		}
+		return "default"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(res)
+	if strings.Contains(doc, "file-token") {
+		t.Errorf("secret value included:\n%s", doc)
+	}
+
+	// relocated settings are attributed to their previous location
+	expected := []string{
+		"enabled: false # default",
+		"name: sample # file:fake.name",
+		"port: 8080 # file:old.fake.port",
+		"token: '[redacted]' # file:fake.token",
+	}
+	for _, line := range expected {
+		if !strings.Contains(doc, line) {
+			t.Errorf("expected '%s' on document:\n%s", line, doc)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package dx
+
+import (
+	"strings"
+
+	"go.bryk.io/pkg/errors"
+)
+
+// visit states used while sorting modules.
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+// sortModules returns the provided modules sorted in dependency order.
+// Modules without a dependency relation between them retain the order
+// in which they were registered.
+func sortModules(order []string, modules map[string]Module) ([]Module, error) {
+	var (
+		list  = make([]Module, 0, len(order))
+		state = make(map[string]int, len(order))
+		path  []string
+		visit func(name string) error
+	)
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			// report the cycle starting from its first occurrence
+			for i, el := range path {
+				if el == name {
+					cycle := append(append([]string{}, path[i:]...), name)
+					return errors.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+				}
+			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		mod := modules[name]
+		if dm, ok := mod.(Dependent); ok {
+			for _, dep := range dm.Depends() {
+				if _, ok := modules[dep]; !ok {
+					return errors.Errorf("module %s depends on unknown module %s", name, dep)
+				}
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		list = append(list, mod)
+		return nil
+	}
+	for _, name := range order {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
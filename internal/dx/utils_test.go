@@ -0,0 +1,87 @@
+package dx
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSortModules(t *testing.T) {
+	tests := []struct {
+		name  string
+		mods  []*fakeModule
+		order []string
+		err   string
+	}{
+		{
+			name:  "registration order",
+			mods:  []*fakeModule{{name: "a"}, {name: "b"}, {name: "c"}},
+			order: []string{"a", "b", "c"},
+		},
+		{
+			name: "dependencies first",
+			mods: []*fakeModule{
+				{name: "rpc", deps: []string{"otel", "tls"}},
+				{name: "tls"},
+				{name: "otel", deps: []string{"log"}},
+				{name: "log"},
+			},
+			order: []string{"log", "otel", "tls", "rpc"},
+		},
+		{
+			name: "shared dependency",
+			mods: []*fakeModule{
+				{name: "a", deps: []string{"c"}},
+				{name: "b", deps: []string{"c"}},
+				{name: "c"},
+			},
+			order: []string{"c", "a", "b"},
+		},
+		{
+			name: "unknown dependency",
+			mods: []*fakeModule{{name: "a", deps: []string{"missing"}}},
+			err:  "module a depends on unknown module missing",
+		},
+		{
+			name: "cycle",
+			mods: []*fakeModule{
+				{name: "a", deps: []string{"b"}},
+				{name: "b", deps: []string{"c"}},
+				{name: "c", deps: []string{"b"}},
+			},
+			err: "dependency cycle detected: b -> c -> b",
+		},
+		{
+			name: "self dependency",
+			mods: []*fakeModule{{name: "a", deps: []string{"a"}}},
+			err:  "dependency cycle detected: a -> a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := []string{}
+			modules := map[string]Module{}
+			for _, mod := range tt.mods {
+				order = append(order, mod.name)
+				modules[mod.name] = mod
+			}
+			list, err := sortModules(order, modules)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got: %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			names := []string{}
+			for _, mod := range list {
+				names = append(names, mod.Name())
+			}
+			if !slices.Equal(names, tt.order) {
+				t.Errorf("expected order %v, got %v", tt.order, names)
+			}
+		})
+	}
+}
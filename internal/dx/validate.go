@@ -0,0 +1,284 @@
+package dx
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Issue describes a single configuration problem.
+type Issue struct {
+	// Setting the problem relates to, as a dot-separated path;
+	// for example: "middleware.gzip".
+	Key string
+
+	// Problem description.
+	Message string
+
+	// Configuration file and position of the setting, if available.
+	File   string
+	Line   int
+	Column int
+}
+
+// String returns a readable representation of the issue.
+func (i Issue) String() string {
+	switch {
+	case i.File == "":
+		return fmt.Sprintf("%s: %s", i.Key, i.Message)
+	case i.Line == 0:
+		return fmt.Sprintf("%s: %s: %s", i.File, i.Key, i.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", i.File, i.Line, i.Column, i.Key, i.Message)
+}
+
+// Nest returns the provided issues with their keys prefixed by `key`;
+// useful for modules embedding the settings of other modules.
+func Nest(key string, issues []Issue) []Issue {
+	for i := range issues {
+		issues[i].Key = strings.Trim(key+"."+issues[i].Key, ".")
+	}
+	return issues
+}
+
+// ValidationError is returned when the configuration is not valid; it
+// collects all the problems found.
+type ValidationError struct {
+	Issues []Issue
+}
+
+// Error returns a summary of all the problems found.
+func (e *ValidationError) Error() string {
+	list := make([]string, len(e.Issues))
+	for i, is := range e.Issues {
+		list[i] = is.String()
+	}
+	return fmt.Sprintf("invalid configuration:\n  %s", strings.Join(list, "\n  "))
+}
+
+// Validate the configuration currently loaded by the registry modules.
+// The configuration file used by the provided Viper instance, if any, is
+// checked for unknown settings, including those on the previous location
+// of relocated settings; and every module implementing the
+// `Validator` interface is asked to check its settings. All problems found
+// are returned as a `*ValidationError`.
+func (r *Registry) Validate(v *viper.Viper) error {
+	defaults, err := r.defaults()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	mods, err := r.sorted()
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// unknown settings
+	issues := []Issue{}
+	file := v.ConfigFileUsed()
+	if file != "" {
+		fv := viper.New()
+		fv.SetConfigFile(file)
+		if err = fv.ReadInConfig(); err != nil {
+			return errors.Wrapf(err, "failed to read configuration file %s", file)
+		}
+		known := map[string]reflect.Type{}
+		for _, conf := range defaults {
+			for k, t := range knownKeys(reflect.TypeOf(conf)) {
+				known[k] = t
+			}
+		}
+		settings := fv.AllSettings()
+		for _, mv := range moves(mods) {
+			if prev, ok := pluck(settings, mv.from); ok {
+				issues = append(issues, unknownKeys(mv.from, prev, mv.conf)...)
+			}
+		}
+		issues = append(issues, unknownKeys("", settings, known)...)
+	}
+
+	// module-specific checks
+	for _, mod := range mods {
+		if vm, ok := mod.(Validator); ok {
+			issues = append(issues, vm.Validate()...)
+		}
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+
+	// attach positions
+	if file != "" {
+		locate(file, issues)
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Key < issues[j].Key
+	})
+	return &ValidationError{Issues: issues}
+}
+
+// loadIssues returns an issue for every setting that failed to load on
+// the `mod` module. Decoding errors are reported using the key of the
+// setting; any other error is reported using the module name.
+func loadIssues(mod string, err error) []Issue {
+	var me mapstructure.Error
+	if !errors.As(err, &me) {
+		return []Issue{{Key: mod, Message: fmt.Sprintf("failed loading module: %s", err)}}
+	}
+	switch e := err.(type) {
+	case *mapstructure.DecodeError:
+		return []Issue{{Key: e.Name(), Message: e.Unwrap().Error()}}
+	case interface{ Unwrap() []error }:
+		issues := []Issue{}
+		for _, inner := range e.Unwrap() {
+			issues = append(issues, loadIssues(mod, inner)...)
+		}
+		return issues
+	}
+	return loadIssues(mod, errors.Unwrap(err))
+}
+
+// knownKeys returns the settings supported by the provided structure type,
+// indexed by their lowercase key.
+func knownKeys(t reflect.Type) map[string]reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	known := map[string]reflect.Type{}
+	if t.Kind() != reflect.Struct {
+		return known
+	}
+	for _, sf := range settingFields(t) {
+		known[strings.ToLower(sf.key)] = t.Field(sf.idx).Type
+	}
+	return known
+}
+
+// unknownKeys returns an issue for every entry in `values` not included
+// in the `known` settings.
+func unknownKeys(prefix string, values map[string]any, known map[string]reflect.Type) []Issue {
+	issues := []Issue{}
+	for k, val := range values {
+		key := strings.TrimPrefix(prefix+"."+k, ".")
+		ft, ok := known[strings.ToLower(k)]
+		if !ok {
+			issues = append(issues, Issue{Key: key, Message: "unknown setting"})
+			continue
+		}
+		switch sub := val.(type) {
+		case map[string]any:
+			if isStruct(ft) {
+				issues = append(issues, unknownKeys(key, sub, knownKeys(ft))...)
+			}
+		case []any:
+			et, ok := elemType(ft)
+			if !ok || !isStruct(et) {
+				continue
+			}
+			for i, item := range sub {
+				if entry, ok := item.(map[string]any); ok {
+					issues = append(issues, unknownKeys(fmt.Sprintf("%s[%d]", key, i), entry, knownKeys(et))...)
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// elemType returns the type of the elements of a slice or array type.
+func elemType(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return nil, false
+	}
+	return t.Elem(), true
+}
+
+// isStruct returns `true` if `t` is a structure or a pointer to one.
+func isStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// locate attaches the position of the setting on the configuration
+// `file` to every issue.
+func locate(file string, issues []Issue) {
+	positions := yamlPositions(file)
+	for i := range issues {
+		issues[i].File = file
+		issues[i].Line, issues[i].Column = lookupPosition(positions, issues[i].Key)
+	}
+}
+
+// yamlPositions returns the position of every setting on a YAML file,
+// indexed by its lowercase dot-separated path; list entries are indexed
+// by their position, like "key[0]". An empty index is returned
+// if the file is not a valid YAML document.
+func yamlPositions(file string) map[string][2]int {
+	index := map[string][2]int{}
+	if ext := strings.ToLower(path.Ext(file)); ext != ".yaml" && ext != ".yml" {
+		return index
+	}
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return index
+	}
+	doc := new(yaml.Node)
+	if err = yaml.Unmarshal(data, doc); err != nil || len(doc.Content) == 0 {
+		return index
+	}
+	var walk func(prefix string, node *yaml.Node)
+	walk = func(prefix string, node *yaml.Node) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := strings.TrimPrefix(prefix+"."+strings.ToLower(node.Content[i].Value), ".")
+				index[key] = [2]int{node.Content[i].Line, node.Content[i].Column}
+				walk(key, node.Content[i+1])
+			}
+		case yaml.SequenceNode:
+			// entries are indexed as "key[i]"
+			for i, item := range node.Content {
+				key := fmt.Sprintf("%s[%d]", prefix, i)
+				index[key] = [2]int{item.Line, item.Column}
+				walk(key, item)
+			}
+		}
+	}
+	walk("", doc.Content[0])
+	return index
+}
+
+// lookupPosition returns the position of the setting `key`, or of its
+// closest parent available on the index.
+func lookupPosition(index map[string][2]int, key string) (line, col int) {
+	key = strings.ToLower(key)
+	for key != "" {
+		if pos, ok := index[key]; ok {
+			return pos[0], pos[1]
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return 0, 0
+}
@@ -0,0 +1,77 @@
+package dx
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/errors"
+)
+
+func TestRegistryValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		issues []Issue
+		keys   []string
+	}{
+		{
+			name:   "valid",
+			config: "fake:\n  enabled: true\n  name: sample\n",
+		},
+		{
+			name:   "unknown settings",
+			config: "fake:\n  enabled: true\n  nmae: sample\nother: 1\n",
+			keys:   []string{"fake.nmae", "other"},
+		},
+		{
+			name:   "unknown settings on list entries",
+			config: "fake:\n  enabled: true\n  name: sample\nrules:\n  - name: first\n  - nmae: second\n",
+			keys:   []string{"rules[1].nmae"},
+		},
+		{
+			name:   "module issues",
+			config: "fake:\n  enabled: true\n",
+			issues: []Issue{{Key: "fake.name", Message: "required"}},
+			keys:   []string{"fake.name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(file, []byte(tt.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			v := viper.New()
+			v.SetConfigFile(file)
+			if err := v.ReadInConfig(); err != nil {
+				t.Fatal(err)
+			}
+			reg := NewRegistry("test", &fakeModule{name: "fake", issues: tt.issues})
+			err := reg.Validate(v)
+			if len(tt.keys) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			ve := new(ValidationError)
+			if !errors.As(err, &ve) {
+				t.Fatalf("expected a validation error, got: %v", err)
+			}
+			keys := []string{}
+			for _, issue := range ve.Issues {
+				keys = append(keys, issue.Key)
+				if issue.File != file || issue.Line == 0 {
+					t.Errorf("missing position for issue: %s", issue)
+				}
+			}
+			slices.Sort(keys)
+			if !slices.Equal(keys, tt.keys) {
+				t.Errorf("expected issues for %v, got %v", tt.keys, keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+/*
+Package harness runs the full server stack in-process for end-to-end tests.
+
+A harness builds the module registry, service operator and RPC server from a
+settings map, using the same structure as the configuration file; modules are
+set up and started the same way as by the "server" command. The server
+listens on an in-memory channel, so tests don't need free TCP ports and can
+run in parallel. Clients for both the gRPC server and the HTTP gateway are
+connected and ready to use.
+
+	func TestEcho(t *testing.T) {
+		h := harness.New(t, map[string]any{
+			"rpc": map[string]any{"input_validation": true},
+		})
+
+		// gRPC
+		res, err := h.Client.Echo(context.Background(), &protov1.EchoRequest{Value: "hi"})
+		...
+
+		// HTTP gateway
+		hr, err := h.HTTP.Post(h.URL("/v1/echo/request"), "application/json", strings.NewReader(`{"value":"hi"}`))
+		...
+	}
+
+Telemetry is disabled unless enabled on the settings, or a recorder is
+attached using `WithTelemetry` to assert on the spans, metrics and logs
+produced. Log messages are discarded unless other "log" settings or a
+logger are provided. Use `Start` to create a harness outside of a test and
+`Close` to release it.
+*/
+package harness
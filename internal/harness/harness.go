@@ -0,0 +1,247 @@
+package harness
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/bcessa/echo-service/handler"
+	"github.com/bcessa/echo-service/internal"
+	"github.com/bcessa/echo-service/internal/dx"
+	dxHandler "github.com/bcessa/echo-service/internal/dx/modules/handler"
+	dxLog "github.com/bcessa/echo-service/internal/dx/modules/log"
+	dxOtel "github.com/bcessa/echo-service/internal/dx/modules/otel"
+	dxRpc "github.com/bcessa/echo-service/internal/dx/modules/rpc"
+	"github.com/bcessa/echo-service/internal/rpc"
+	"github.com/bcessa/echo-service/internal/telemetrytest"
+	protov1 "github.com/bcessa/echo-service/proto/sample/v1"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/errors"
+	xlog "go.bryk.io/pkg/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// host used on the URLs of the HTTP gateway; requests are always routed
+// to the harness listener.
+const host = "harness.local"
+
+// size of the in-memory listener buffer.
+const bufferSize = 1 << 20
+
+// Option adjusts the settings of a harness instance.
+type Option func(h *Harness)
+
+// WithLogger sets the logger used by the server components. By default
+// the components use the loggers provided by the log module, which
+// discards all messages unless other settings are provided.
+func WithLogger(log xlog.Logger) Option {
+	return func(h *Harness) {
+		h.log = log
+	}
+}
+
+// WithHandlerOptions adjusts the service operator used by the server;
+// options take precedence over the "handler" settings.
+func WithHandlerOptions(opts ...handler.Option) Option {
+	return func(h *Harness) {
+		h.handlerOpts = append(h.handlerOpts, opts...)
+	}
+}
+
+// WithServerOptions registers additional options on the RPC server, for
+// example to include other service providers or middleware.
+func WithServerOptions(opts ...rpc.ServerOption) Option {
+	return func(h *Harness) {
+		h.serverOpts = append(h.serverOpts, opts...)
+	}
+}
+
+// WithTelemetry enables the otel module and attaches the provided recorder
+// to it, to capture the spans, metrics and logs produced by the server.
+func WithTelemetry(rec *telemetrytest.Recorder) Option {
+	return func(h *Harness) {
+		h.recorder = rec
+	}
+}
+
+// WithModules registers additional modules on the registry.
+func WithModules(mods ...dx.Module) Option {
+	return func(h *Harness) {
+		h.modules = append(h.modules, mods...)
+	}
+}
+
+// Harness runs the full server stack in-process and provides clients
+// connected to it.
+type Harness struct {
+	// Registry with all the modules used by the server.
+	Registry *dx.Registry
+
+	// Service operator handling the requests.
+	Handler *handler.ServiceOperator
+
+	// Client connected to the gRPC server.
+	Client protov1.ServiceAPIClient
+
+	// Connection used by `Client`; can be used to create clients for
+	// other services registered on the server.
+	Conn *grpc.ClientConn
+
+	// Client connected to the HTTP gateway; use `URL` to build the
+	// address for a request.
+	HTTP *http.Client
+
+	log         xlog.Logger
+	logMod      *dxLog.Module
+	handlerMod  *dxHandler.Module
+	recorder    *telemetrytest.Recorder
+	handlerOpts []handler.Option
+	serverOpts  []rpc.ServerOption
+	modules     []dx.Module
+	lis         *bufconn.Listener
+}
+
+// New starts a harness for the test `t` using the provided settings. The
+// test fails immediately if the harness can't be started, and the harness
+// is closed automatically once the test completes.
+func New(t testing.TB, settings map[string]any, opts ...Option) *Harness {
+	t.Helper()
+	h, err := Start(settings, opts...)
+	if err != nil {
+		t.Fatalf("failed to start harness: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := h.Close(); err != nil {
+			t.Errorf("failed to close harness: %s", err)
+		}
+	})
+	return h
+}
+
+// Start a new harness using the provided settings. Settings use the same
+// structure as the configuration file, for example:
+//
+//	map[string]any{
+//		"rpc": map[string]any{
+//			"http": map[string]any{"enabled": true},
+//		},
+//	}
+//
+// The server always listens on an in-memory channel, so any port or socket
+// on the settings is ignored; TLS is not supported.
+func Start(settings map[string]any, opts ...Option) (*Harness, error) {
+	h := &Harness{logMod: new(dxLog.Module), handlerMod: new(dxHandler.Module)}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	// load settings
+	v := viper.New()
+	v.SetDefault("rpc.http.enabled", true)
+	v.SetDefault("log.level", "debug")
+	v.SetDefault("log.output", "discard")
+	if h.recorder != nil {
+		v.SetDefault("otel.enabled", true)
+		v.SetDefault("otel.service_name", "harness")
+	}
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, errors.Wrap(err, "invalid settings")
+	}
+	if v.GetBool("tls.enabled") {
+		return nil, errors.New("TLS is not supported by the harness")
+	}
+	v.Set("rpc.port", 0)
+	v.Set("rpc.unix_socket", "")
+	v.Set("rpc.http.in_process", true) // the listener has no network address
+	h.lis = bufconn.Listen(bufferSize)
+
+	// start modules
+	mods := append(internal.ServerModules(h.logMod, h.handlerMod), h.modules...)
+	h.Registry = dx.NewRegistry("harness", mods...)
+	rpcMod, _ := h.Registry.Get("rpc").(*dxRpc.Module)
+	rpcMod.Listener = h.lis
+	if err := h.Registry.Load(v); err != nil {
+		_ = h.lis.Close()
+		return nil, err
+	}
+	if err := h.Registry.Validate(v); err != nil {
+		_ = h.lis.Close()
+		return nil, err
+	}
+	if h.recorder != nil {
+		otelMod, _ := h.Registry.Get("otel").(*dxOtel.Module)
+		h.recorder.Attach(otelMod)
+	}
+	if h.log != nil {
+		// the rpc and handler modules use the log module loggers otherwise
+		rpcMod.Logger = h.log
+		h.handlerMod.Extend(handler.WithLogger(h.log))
+	}
+	h.handlerMod.Extend(h.handlerOpts...)
+	rpcMod.Extend(h.serverOpts...)
+	if err := internal.StartServer(h.Registry, h.logger); err != nil {
+		_ = h.lis.Close() // not closed if the rpc module wasn't started
+		_ = h.handlerMod.Close()
+		_ = h.logMod.Close()
+		return nil, err
+	}
+	h.Handler = h.handlerMod.Operator()
+
+	// connect clients
+	var err error
+	h.Conn, err = grpc.NewClient("passthrough:///"+host,
+		grpc.WithContextDialer(h.dial),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		_ = h.Close()
+		return nil, err
+	}
+	h.Client = protov1.NewServiceAPIClient(h.Conn)
+	h.HTTP = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return h.dial(ctx, "")
+			},
+		},
+	}
+	return h, nil
+}
+
+// URL returns the address of `path` on the HTTP gateway, to be used with
+// the `HTTP` client.
+func (h *Harness) URL(path string) string {
+	return "http://" + host + path
+}
+
+// Close the clients, and stop all modules and the service operator.
+func (h *Harness) Close() error {
+	if h.Conn != nil {
+		_ = h.Conn.Close()
+	}
+	if h.HTTP != nil {
+		h.HTTP.CloseIdleConnections()
+	}
+	h.logMod.Export(nil) // stop exporting logs before telemetry is drained
+	err := h.Registry.Stop()
+	if cErr := h.handlerMod.Close(); cErr != nil && err == nil {
+		err = cErr
+	}
+	_ = h.logMod.Close()
+	return err
+}
+
+// logger returns the logger used by a server component.
+func (h *Harness) logger(component string) xlog.Logger {
+	if h.log != nil {
+		return h.log
+	}
+	return h.logMod.Component(component)
+}
+
+// dial opens a new connection to the server.
+func (h *Harness) dial(ctx context.Context, _ string) (net.Conn, error) {
+	return h.lis.DialContext(ctx)
+}
@@ -0,0 +1,274 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bcessa/echo-service/handler"
+	"github.com/bcessa/echo-service/internal/auth"
+	"github.com/bcessa/echo-service/internal/dx"
+	dxRpc "github.com/bcessa/echo-service/internal/dx/modules/rpc"
+	protov1 "github.com/bcessa/echo-service/proto/sample/v1"
+	"github.com/spf13/viper"
+	"go.bryk.io/pkg/cli"
+	"go.bryk.io/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestServiceAPI(t *testing.T) {
+	h := New(t, map[string]any{
+		"log": map[string]any{"admin_path": "/admin/log"},
+	}, WithHandlerOptions(
+		handler.WithLatency(handler.Latency{Distribution: handler.LatencyFixed, Mean: time.Millisecond}),
+		handler.WithErrorRate(0, 1),
+	))
+	ctx := context.Background()
+
+	t.Run("grpc", func(t *testing.T) {
+		tests := []struct {
+			method string
+			call   func() (any, error)
+			check  func(res any) bool
+			code   codes.Code
+		}{
+			{
+				method: "Ping",
+				call:   func() (any, error) { return h.Client.Ping(ctx, &emptypb.Empty{}) },
+				check:  func(res any) bool { return res.(*protov1.PingResponse).Ok },
+			},
+			{
+				method: "Ready",
+				call:   func() (any, error) { return h.Client.Ready(ctx, &emptypb.Empty{}) },
+				check:  func(res any) bool { return res.(*protov1.ReadyResponse).Ok },
+			},
+			{
+				method: "Echo",
+				call:   func() (any, error) { return h.Client.Echo(ctx, &protov1.EchoRequest{Value: "hi"}) },
+				check:  func(res any) bool { return res.(*protov1.EchoResponse).Result == "you said: hi" },
+			},
+			{
+				method: "Slow",
+				call:   func() (any, error) { return h.Client.Slow(ctx, &emptypb.Empty{}) },
+				check:  func(res any) bool { return res.(*protov1.DummyResponse).Ok },
+			},
+			{
+				method: "Faulty",
+				call:   func() (any, error) { return h.Client.Faulty(ctx, &emptypb.Empty{}) },
+				code:   codes.Internal,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.method, func(t *testing.T) {
+				res, err := tt.call()
+				if code := status.Code(err); code != tt.code {
+					t.Fatalf("expected code %s, got %s", tt.code, code)
+				}
+				if err == nil && !tt.check(res) {
+					t.Errorf("unexpected response: %v", res)
+				}
+			})
+		}
+	})
+
+	t.Run("http", func(t *testing.T) {
+		tests := []struct {
+			method string
+			verb   string
+			path   string
+			body   string
+			status int
+			result map[string]any
+		}{
+			{
+				method: "Ping",
+				verb:   http.MethodGet,
+				path:   "/v1/ping",
+				status: http.StatusOK,
+				result: map[string]any{"ok": true},
+			},
+			{
+				method: "Ready",
+				verb:   http.MethodGet,
+				path:   "/v1/ready",
+				status: http.StatusOK,
+				result: map[string]any{"ok": true},
+			},
+			{
+				method: "Echo",
+				verb:   http.MethodPost,
+				path:   "/v1/echo/request",
+				body:   `{"value":"hi"}`,
+				status: http.StatusOK,
+				result: map[string]any{"result": "you said: hi"},
+			},
+			{
+				method: "Slow",
+				verb:   http.MethodPost,
+				path:   "/v1/echo/slow",
+				status: http.StatusOK,
+				result: map[string]any{"ok": true},
+			},
+			{
+				method: "Faulty",
+				verb:   http.MethodPost,
+				path:   "/v1/echo/faulty",
+				status: http.StatusInternalServerError,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.method, func(t *testing.T) {
+				req, err := http.NewRequestWithContext(ctx, tt.verb, h.URL(tt.path), strings.NewReader(tt.body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				res, err := h.HTTP.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer func() { _ = res.Body.Close() }()
+				if res.StatusCode != tt.status {
+					t.Fatalf("expected status %d, got %d", tt.status, res.StatusCode)
+				}
+				if _, ok := res.Header["X-App-Version"]; !ok {
+					t.Error("build details not included on the response")
+				}
+				if tt.result == nil {
+					return
+				}
+				body, _ := io.ReadAll(res.Body)
+				result := map[string]any{}
+				if err = json.Unmarshal(body, &result); err != nil {
+					t.Fatalf("invalid response: %s", body)
+				}
+				for k, v := range tt.result {
+					if result[k] != v {
+						t.Errorf("expected %s=%v, got %s", k, v, body)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("log admin", func(t *testing.T) {
+		// HTTP authentication is not enabled
+		res, err := h.HTTP.Get(h.URL("/admin/log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, res.StatusCode)
+		}
+	})
+}
+
+func TestLogAdmin(t *testing.T) {
+	store := filepath.Join(t.TempDir(), "keys.json")
+	keys, err := auth.OpenAPIKeyStore(store, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _, err := keys.Create("admin", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(t, map[string]any{
+		"log": map[string]any{"admin_path": "/admin/log"},
+		"middleware": map[string]any{
+			"apikey": map[string]any{"enabled": true, "store": store},
+		},
+	})
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{name: "without key", status: http.StatusUnauthorized},
+		{name: "invalid key", key: "invalid", status: http.StatusUnauthorized},
+		{name: "valid key", key: key, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPut, h.URL("/admin/log"), strings.NewReader(`{"level":"debug"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.key != "" {
+				req.Header.Set(auth.DefaultAPIKeyHeader, tt.key)
+			}
+			res, err := h.HTTP.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Body.Close()
+			if res.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestStartErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]any
+	}{
+		{
+			name:     "TLS",
+			settings: map[string]any{"tls": map[string]any{"enabled": true}},
+		},
+		{
+			name:     "invalid settings",
+			settings: map[string]any{"middleware": map[string]any{"gzip": 50}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := Start(tt.settings)
+			if err == nil {
+				_ = h.Close()
+				t.Fatal("expected an error")
+			}
+		})
+	}
+
+	t.Run("failed start", func(t *testing.T) {
+		mod := new(failingModule)
+		if _, err := Start(nil, WithModules(mod)); err == nil {
+			t.Fatal("expected an error")
+		}
+		if mod.rpc == nil {
+			t.Fatal("rpc module not started")
+		}
+		if _, err := mod.rpc.Listener.(*bufconn.Listener).Dial(); err == nil {
+			t.Error("rpc server not stopped")
+		}
+	})
+}
+
+// module failing to start after the rpc module.
+type failingModule struct {
+	rpc *dxRpc.Module
+}
+
+func (m *failingModule) Name() string               { return "failing" }
+func (m *failingModule) Load(_ *viper.Viper) error  { return nil }
+func (m *failingModule) Flags(_ string) []cli.Param { return nil }
+func (m *failingModule) Customize(_ any) error      { return nil }
+func (m *failingModule) Depends() []string          { return []string{"rpc"} }
+func (m *failingModule) Stop() error                { return nil }
+func (m *failingModule) Start() error               { return errors.New("failed to start") }
+func (m *failingModule) Consume(r *dx.Registry) error {
+	m.rpc, _ = r.Get("rpc").(*dxRpc.Module)
+	return nil
+}
@@ -0,0 +1,25 @@
+/*
+Package metrics provides RED (rate, errors and duration) metrics for gRPC and
+HTTP requests.
+
+Every request increments a counter and records its duration on a histogram.
+gRPC requests are labeled by full method name and status code; HTTP requests
+by method, route and response status code. Error rates can be derived by
+filtering on the status code.
+
+	rec, _ := metrics.New(metrics.Options{
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1},
+		MaxLabels: 50,
+		Routes:    []string{"/v1/users/*"},
+		Omit:      []string{"Ping", "/metrics"},
+	})
+	grpc.ChainUnaryInterceptor(rec.UnaryServerInterceptor())
+	handler = rec.Handler()(handler)
+
+To prevent unbounded cardinality, the number of distinct methods and routes
+recorded is limited by `MaxLabels`; additional values, unknown HTTP methods
+and requests to paths not served (404 and 405 responses) are reported using
+the `Overflow` label. Use `Routes` to group paths including identifiers or
+other variable segments.
+*/
+package metrics
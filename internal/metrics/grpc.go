@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor records every unary request.
+func (r *Recorder) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if r.omit(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		res, err := handler(ctx, req)
+		r.recordRPC(ctx, info.FullMethod, err, time.Since(start))
+		return res, err
+	}
+}
+
+// StreamServerInterceptor records every stream once closed.
+func (r *Recorder) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if r.omit(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		start := time.Now()
+		err := handler(srv, ss)
+		r.recordRPC(ss.Context(), info.FullMethod, err, time.Since(start))
+		return err
+	}
+}
+
+func (r *Recorder) recordRPC(ctx context.Context, method string, err error, latency time.Duration) {
+	attrs := metric.WithAttributes(
+		attribute.String("rpc.method", r.methods.value(method)),
+		attribute.String("rpc.grpc.status_code", status.Code(err).String()),
+	)
+	r.rpcCount.Add(ctx, 1, attrs)
+	r.rpcTime.Record(ctx, latency.Seconds(), attrs)
+}
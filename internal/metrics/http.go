@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bcessa/echo-service/internal/request"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Handler records every HTTP request.
+func (r *Recorder) Handler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if r.omit(req.URL.Path) {
+				next.ServeHTTP(w, req)
+				return
+			}
+			start := time.Now()
+			rw := request.NewRecorder(w)
+			next.ServeHTTP(rw, req)
+			latency := time.Since(start)
+
+			// paths not served by the gateway don't count against the
+			// limit of distinct routes
+			route := Overflow
+			if rw.Code() != http.StatusNotFound && rw.Code() != http.StatusMethodNotAllowed {
+				route = r.route(req.URL.Path)
+			}
+			verb := req.Method
+			if !request.IsMethod(verb) {
+				verb = Overflow
+			}
+			attrs := metric.WithAttributes(
+				attribute.String("http.request.method", verb),
+				attribute.String("http.route", route),
+				attribute.Int("http.response.status_code", rw.Code()),
+			)
+			r.gwCount.Add(req.Context(), 1, attrs)
+			r.gwTime.Record(req.Context(), latency.Seconds(), attrs)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/bcessa/echo-service/internal/request"
+	"go.bryk.io/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// instrumentation scope used by the recorder.
+const scope = "github.com/bcessa/echo-service/internal/metrics"
+
+// Overflow is the label value used once the limit of distinct methods or
+// routes is reached.
+const Overflow = "_other"
+
+// DefaultBuckets are the histogram boundaries used to record request
+// durations, in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultMaxLabels is the default limit of distinct methods or routes.
+const DefaultMaxLabels = 100
+
+// Options available when creating a metrics recorder.
+type Options struct {
+	// Histogram boundaries used to record request durations, in seconds;
+	// defaults to `DefaultBuckets`.
+	Buckets []float64
+
+	// Maximum number of distinct methods or routes recorded; additional
+	// values are reported as `Overflow`. Defaults to `DefaultMaxLabels`.
+	MaxLabels int
+
+	// HTTP route templates used to group request paths, for example
+	// "/v1/users/*". Paths not matching a route are recorded as-is, subject
+	// to `MaxLabels`.
+	Routes []string
+
+	// gRPC methods or HTTP paths never recorded; either the method name,
+	// the full method name or path, or a pattern like "/v1/admin/*".
+	Omit []string
+}
+
+// Recorder produces request rate, error and duration metrics for gRPC and
+// HTTP requests.
+type Recorder struct {
+	opts     Options
+	rpcCount metric.Int64Counter
+	rpcTime  metric.Float64Histogram
+	gwCount  metric.Int64Counter
+	gwTime   metric.Float64Histogram
+	methods  *guard
+	routes   *guard
+}
+
+// New returns a metrics recorder using the provided options. Instruments
+// are created using the global meter provider.
+func New(opts Options) (*Recorder, error) {
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = DefaultBuckets
+	}
+	for i := 1; i < len(opts.Buckets); i++ {
+		if opts.Buckets[i] <= opts.Buckets[i-1] {
+			return nil, errors.New("buckets must be in increasing order")
+		}
+	}
+	if opts.MaxLabels < 0 {
+		return nil, errors.New("max labels can't be negative")
+	}
+	if opts.MaxLabels == 0 {
+		opts.MaxLabels = DefaultMaxLabels
+	}
+	for _, pattern := range slices.Concat(opts.Routes, opts.Omit) {
+		if err := request.Validate(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	meter := otel.Meter(scope)
+	rec := &Recorder{
+		opts:    opts,
+		methods: &guard{max: opts.MaxLabels, seen: map[string]struct{}{}},
+		routes:  &guard{max: opts.MaxLabels, seen: map[string]struct{}{}},
+	}
+	rec.rpcCount, err = meter.Int64Counter("rpc.server.requests",
+		metric.WithDescription("gRPC requests handled, by method and status code"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return nil, err
+	}
+	rec.rpcTime, err = meter.Float64Histogram("rpc.server.request.duration",
+		metric.WithDescription("time spent handling gRPC requests, by method and status code"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(opts.Buckets...))
+	if err != nil {
+		return nil, err
+	}
+	rec.gwCount, err = meter.Int64Counter("http.server.requests",
+		metric.WithDescription("HTTP requests handled, by route and status code"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return nil, err
+	}
+	rec.gwTime, err = meter.Float64Histogram("http.server.request.duration",
+		metric.WithDescription("time spent handling HTTP requests, by route and status code"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(opts.Buckets...))
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// omit returns `true` if requests to `target` must not be recorded.
+func (r *Recorder) omit(target string) bool {
+	for _, pattern := range r.opts.Omit {
+		if request.Match(pattern, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// route returns the label used to record requests to `target`.
+func (r *Recorder) route(target string) string {
+	for _, pattern := range r.opts.Routes {
+		if request.Match(pattern, target) {
+			return pattern
+		}
+	}
+	return r.routes.value(target)
+}
+
+// guard limits the number of distinct values used for a label.
+type guard struct {
+	max  int
+	seen map[string]struct{}
+	mu   sync.RWMutex
+}
+
+// value returns `v` if already known or if the limit is not reached yet;
+// `Overflow` otherwise.
+func (g *guard) value(v string) string {
+	g.mu.RLock()
+	_, ok := g.seen[v]
+	g.mu.RUnlock()
+	if ok {
+		return v
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok = g.seen[v]; ok {
+		return v
+	}
+	if len(g.seen) >= g.max {
+		return Overflow
+	}
+	g.seen[v] = struct{}{}
+	return v
+}
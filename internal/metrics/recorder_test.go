@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+)
+
+func TestGuard(t *testing.T) {
+	tests := []struct {
+		name   string
+		max    int
+		values []string
+		labels []string
+	}{
+		{
+			name:   "within limit",
+			max:    3,
+			values: []string{"a", "b", "a", "c"},
+			labels: []string{"a", "b", "a", "c"},
+		},
+		{
+			name:   "overflow",
+			max:    2,
+			values: []string{"a", "b", "c", "d"},
+			labels: []string{"a", "b", Overflow, Overflow},
+		},
+		{
+			name:   "known values after overflow",
+			max:    2,
+			values: []string{"a", "b", "c", "b", "a", "c"},
+			labels: []string{"a", "b", Overflow, "b", "a", Overflow},
+		},
+		{
+			name:   "single value",
+			max:    1,
+			values: []string{"a", "b", "a"},
+			labels: []string{"a", Overflow, "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &guard{max: tt.max, seen: map[string]struct{}{}}
+			labels := make([]string, len(tt.values))
+			for i, v := range tt.values {
+				labels[i] = g.value(v)
+			}
+			if !slices.Equal(labels, tt.labels) {
+				t.Errorf("expected %v, got %v", tt.labels, labels)
+			}
+		})
+	}
+
+	t.Run("concurrent use", func(t *testing.T) {
+		g := &guard{max: 10, seen: map[string]struct{}{}}
+		wg := sync.WaitGroup{}
+		labels := make(chan string, 100)
+		for i := range 100 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				labels <- g.value(fmt.Sprintf("v%d", i%25))
+			}()
+		}
+		wg.Wait()
+		close(labels)
+		known := map[string]struct{}{}
+		for l := range labels {
+			if l != Overflow {
+				known[l] = struct{}{}
+			}
+		}
+		if len(known) != 10 {
+			t.Errorf("expected 10 distinct labels, got %d", len(known))
+		}
+	})
+}
+
+func TestRecorderOverflow(t *testing.T) {
+	reader := sdkMetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+
+	rec, err := New(Options{
+		MaxLabels: 2,
+		Routes:    []string{"/v1/users/*"},
+		Omit:      []string{"/v1/ping", "Ping"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// HTTP requests
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/", func(_ http.ResponseWriter, _ *http.Request) {})
+	handler := rec.Handler()(mux)
+	requests := []struct{ method, path string }{
+		{http.MethodGet, "/v1/a"},
+		{http.MethodGet, "/v1/b"},
+		{http.MethodGet, "/v1/c"},       // route limit reached
+		{http.MethodGet, "/v1/a"},       // known route
+		{http.MethodGet, "/v1/users/1"}, // route template
+		{http.MethodGet, "/v1/users/2"},
+		{http.MethodGet, "/v1/ping"}, // omitted
+		{http.MethodGet, "/unknown"}, // not served
+		{"PROPFIND", "/v1/a"},        // unknown method
+	}
+	for _, r := range requests {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(r.method, r.path, nil))
+	}
+
+	// gRPC requests
+	interceptor := rec.UnaryServerInterceptor()
+	noop := func(_ context.Context, _ any) (any, error) { return nil, nil }
+	for _, method := range []string{"/s/Echo", "/s/Slow", "/s/Faulty", "/s/Echo", "/s/Ping"} {
+		_, _ = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, noop)
+	}
+
+	data := metricdata.ResourceMetrics{}
+	if err = reader.Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+	routes := counts(t, data, "http.server.requests", "http.route")
+	expected := map[string]int64{"/v1/a": 3, "/v1/b": 1, "/v1/users/*": 2, Overflow: 2}
+	if !maps.Equal(routes, expected) {
+		t.Errorf("unexpected routes: %v", routes)
+	}
+	verbs := counts(t, data, "http.server.requests", "http.request.method")
+	if verbs[Overflow] != 1 || verbs[http.MethodGet] != 7 {
+		t.Errorf("unexpected methods: %v", verbs)
+	}
+	methods := counts(t, data, "rpc.server.requests", "rpc.method")
+	expected = map[string]int64{"/s/Echo": 2, "/s/Slow": 1, Overflow: 1}
+	if !maps.Equal(methods, expected) {
+		t.Errorf("unexpected methods: %v", methods)
+	}
+}
+
+// counts returns the value of a counter for each value of an attribute.
+func counts(t *testing.T, data metricdata.ResourceMetrics, name, attr string) map[string]int64 {
+	t.Helper()
+	res := map[string]int64{}
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				t.Fatalf("unexpected data for %s: %T", name, m.Data)
+			}
+			for _, dp := range sum.DataPoints {
+				v, _ := dp.Attributes.Value(attribute.Key(attr))
+				res[v.AsString()] += dp.Value
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,170 @@
+package otlptest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"go.bryk.io/pkg/errors"
+	collectorLogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectorMetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	collectorTrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip" // support compressed exports
+)
+
+// Option adjusts the settings of a collector instance.
+type Option func(c *Collector)
+
+// WithGRPC sets the address used by the gRPC receiver; an empty value
+// disables it. By default a random local port is used.
+func WithGRPC(addr string) Option {
+	return func(c *Collector) {
+		c.grpcAddr = addr
+	}
+}
+
+// WithHTTP sets the address used by the HTTP receiver; an empty value
+// disables it. By default a random local port is used.
+func WithHTTP(addr string) Option {
+	return func(c *Collector) {
+		c.httpAddr = addr
+	}
+}
+
+// OnReceive registers a function called with every batch received, after
+// it is recorded by the collector.
+func OnReceive(fn func(b Batch)) Option {
+	return func(c *Collector) {
+		c.listeners = append(c.listeners, fn)
+	}
+}
+
+// Collector is a fake OTLP receiver that keeps in memory all the telemetry
+// data exported to it, over gRPC or HTTP.
+type Collector struct {
+	grpcAddr  string
+	httpAddr  string
+	grpcSrv   *grpc.Server
+	grpcLis   net.Listener
+	httpSrv   *http.Server
+	httpLis   net.Listener
+	listeners []func(b Batch)
+	batches   []Batch
+	stats     Stats
+	updated   chan struct{}
+	mu        sync.Mutex
+}
+
+// New starts a collector for the test `t`. The test fails immediately if
+// the collector can't be started, and the collector is closed
+// automatically once the test completes.
+func New(t testing.TB, opts ...Option) *Collector {
+	t.Helper()
+	c, err := Start(opts...)
+	if err != nil {
+		t.Fatalf("failed to start collector: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	return c
+}
+
+// Start a new collector instance with the provided options.
+func Start(opts ...Option) (*Collector, error) {
+	c := &Collector{
+		grpcAddr: "127.0.0.1:0",
+		httpAddr: "127.0.0.1:0",
+		updated:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.grpcAddr == "" && c.httpAddr == "" {
+		return nil, errors.New("at least one receiver is required")
+	}
+
+	// gRPC receiver
+	if c.grpcAddr != "" {
+		lis, err := net.Listen("tcp", c.grpcAddr)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start gRPC receiver")
+		}
+		c.grpcLis = lis
+		c.grpcSrv = grpc.NewServer()
+		collectorTrace.RegisterTraceServiceServer(c.grpcSrv, &traceService{c: c})
+		collectorMetrics.RegisterMetricsServiceServer(c.grpcSrv, &metricsService{c: c})
+		collectorLogs.RegisterLogsServiceServer(c.grpcSrv, &logsService{c: c})
+		go func() {
+			_ = c.grpcSrv.Serve(lis)
+		}()
+	}
+
+	// HTTP receiver
+	if c.httpAddr != "" {
+		lis, err := net.Listen("tcp", c.httpAddr)
+		if err != nil {
+			_ = c.Close()
+			return nil, errors.Wrap(err, "failed to start HTTP receiver")
+		}
+		c.httpLis = lis
+		c.httpSrv = &http.Server{
+			Handler:           c,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			_ = c.httpSrv.Serve(lis)
+		}()
+	}
+	return c, nil
+}
+
+// Endpoint returns the address of the receiver for `protocol`, either
+// "grpc" or "http"; returns an empty string if the receiver is disabled.
+func (c *Collector) Endpoint(protocol string) string {
+	switch {
+	case protocol == "grpc" && c.grpcLis != nil:
+		return c.grpcLis.Addr().String()
+	case protocol == "http" && c.httpLis != nil:
+		return c.httpLis.Addr().String()
+	default:
+		return ""
+	}
+}
+
+// Settings returns the "otel.collector" configuration section required
+// to export telemetry to the collector using `protocol`.
+//
+//	map[string]any{
+//		"otel": map[string]any{
+//			"enabled":      true,
+//			"service_name": "echo-test",
+//			"collector":    col.Settings("http"),
+//		},
+//	}
+func (c *Collector) Settings(protocol string) map[string]any {
+	return map[string]any{
+		"endpoint": c.Endpoint(protocol),
+		"protocol": protocol,
+	}
+}
+
+// Close both receivers. Data already received remains available.
+func (c *Collector) Close() error {
+	if c.grpcSrv != nil {
+		c.grpcSrv.Stop()
+		c.grpcSrv = nil
+	}
+	if c.httpSrv != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := c.httpSrv.Shutdown(ctx)
+		c.httpSrv = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package otlptest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bcessa/echo-service/handler"
+	"github.com/bcessa/echo-service/internal/harness"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestCollector(t *testing.T) {
+	for _, protocol := range []string{"grpc", "http"} {
+		t.Run(protocol, func(t *testing.T) {
+			col := New(t)
+			h, err := harness.Start(map[string]any{
+				"otel": map[string]any{
+					"enabled":      true,
+					"service_name": "echo-test",
+					"logs":         true,
+					"collector":    col.Settings(protocol),
+				},
+			}, harness.WithHandlerOptions(
+				handler.WithLatency(handler.Latency{Distribution: handler.LatencyFixed, Mean: time.Millisecond}),
+			))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = h.Client.Slow(context.Background(), &emptypb.Empty{}); err != nil {
+				t.Fatal(err)
+			}
+			if err = h.Close(); err != nil { // flush pending data
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err = col.Wait(ctx, func(s Stats) bool { return s.Spans > 0 && s.Logs > 0 })
+			if err != nil {
+				t.Fatalf("data not received: %+v", col.Stats())
+			}
+			for _, b := range col.Batches("") {
+				if b.Protocol != protocol {
+					t.Errorf("expected protocol %s, got %s", protocol, b.Protocol)
+				}
+			}
+			for _, res := range col.Resources("") {
+				if name, _ := Attribute(res.GetAttributes(), "service.name"); name != "echo-test" {
+					t.Errorf("unexpected service name: %q", name)
+				}
+			}
+			spans := col.Spans("slow handler")
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(spans))
+			}
+			logs := col.Logs("waiting for slow operation")
+			if len(logs) != 1 {
+				t.Fatalf("expected 1 log record, got %d", len(logs))
+			}
+			if string(logs[0].GetSpanId()) != string(spans[0].GetSpanId()) {
+				t.Error("log record not correlated with the span")
+			}
+		})
+	}
+}
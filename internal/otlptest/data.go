@@ -0,0 +1,265 @@
+package otlptest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	otlpCommon "go.opentelemetry.io/proto/otlp/common/v1"
+	otlpLogs "go.opentelemetry.io/proto/otlp/logs/v1"
+	otlpMetrics "go.opentelemetry.io/proto/otlp/metrics/v1"
+	otlpResource "go.opentelemetry.io/proto/otlp/resource/v1"
+	otlpTrace "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// Telemetry signals supported by the collector.
+const (
+	SignalTraces  = "traces"
+	SignalMetrics = "metrics"
+	SignalLogs    = "logs"
+)
+
+// Batch of telemetry data received on a single export request. Only the
+// field matching the signal is set.
+type Batch struct {
+	// Telemetry signal: "traces", "metrics" or "logs".
+	Signal string
+
+	// Protocol used to submit the data: "grpc" or "http".
+	Protocol string
+
+	// Time the batch was received.
+	Received time.Time
+
+	// Spans received, grouped by resource.
+	Traces []*otlpTrace.ResourceSpans
+
+	// Metrics received, grouped by resource.
+	Metrics []*otlpMetrics.ResourceMetrics
+
+	// Log records received, grouped by resource.
+	Logs []*otlpLogs.ResourceLogs
+}
+
+// Resources returns the resources included in the batch.
+func (b Batch) Resources() []*otlpResource.Resource {
+	list := []*otlpResource.Resource{}
+	for _, rs := range b.Traces {
+		list = append(list, rs.GetResource())
+	}
+	for _, rm := range b.Metrics {
+		list = append(list, rm.GetResource())
+	}
+	for _, rl := range b.Logs {
+		list = append(list, rl.GetResource())
+	}
+	return list
+}
+
+// Size returns the number of spans, metrics or log records on the batch.
+func (b Batch) Size() int {
+	size := 0
+	for _, rs := range b.Traces {
+		for _, ss := range rs.GetScopeSpans() {
+			size += len(ss.GetSpans())
+		}
+	}
+	for _, rm := range b.Metrics {
+		for _, sm := range rm.GetScopeMetrics() {
+			size += len(sm.GetMetrics())
+		}
+	}
+	for _, rl := range b.Logs {
+		for _, sl := range rl.GetScopeLogs() {
+			size += len(sl.GetLogRecords())
+		}
+	}
+	return size
+}
+
+// Stats summarizes the data received by the collector.
+type Stats struct {
+	// Number of export requests received.
+	Requests int
+
+	// Number of spans received.
+	Spans int
+
+	// Number of metrics received; a metric is counted every time it is
+	// exported.
+	Metrics int
+
+	// Number of log records received.
+	Logs int
+}
+
+func (s *Stats) add(b Batch) {
+	s.Requests++
+	switch b.Signal {
+	case SignalTraces:
+		s.Spans += b.Size()
+	case SignalMetrics:
+		s.Metrics += b.Size()
+	case SignalLogs:
+		s.Logs += b.Size()
+	}
+}
+
+// Stats returns a summary of the data received so far.
+func (c *Collector) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Wait until `cond` returns `true` for the data received, or the context
+// is done. Exporters submit data asynchronously, so use it before
+// inspecting the data received.
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	err := col.Wait(ctx, func(s otlptest.Stats) bool { return s.Spans > 0 })
+func (c *Collector) Wait(ctx context.Context, cond func(s Stats) bool) error {
+	for {
+		c.mu.Lock()
+		stats, updated := c.stats, c.updated
+		c.mu.Unlock()
+		if cond(stats) {
+			return nil
+		}
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Batches returns all the batches received for `signal`, in the order
+// they were received; if `signal` is empty all batches are returned.
+func (c *Collector) Batches(signal string) []Batch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := []Batch{}
+	for _, b := range c.batches {
+		if signal == "" || b.Signal == signal {
+			list = append(list, b)
+		}
+	}
+	return list
+}
+
+// Resources returns the resources reported for `signal`, one per batch
+// received; if `signal` is empty resources for all signals are returned.
+func (c *Collector) Resources(signal string) []*otlpResource.Resource {
+	list := []*otlpResource.Resource{}
+	for _, b := range c.Batches(signal) {
+		list = append(list, b.Resources()...)
+	}
+	return list
+}
+
+// Spans returns all the spans received with the provided name; if `name`
+// is empty all spans are returned.
+func (c *Collector) Spans(name string) []*otlpTrace.Span {
+	list := []*otlpTrace.Span{}
+	for _, b := range c.Batches(SignalTraces) {
+		for _, rs := range b.Traces {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, s := range ss.GetSpans() {
+					if name == "" || s.GetName() == name {
+						list = append(list, s)
+					}
+				}
+			}
+		}
+	}
+	return list
+}
+
+// Metrics returns all the exports received for the metric with the
+// provided name; if `name` is empty all metrics are returned.
+func (c *Collector) Metrics(name string) []*otlpMetrics.Metric {
+	list := []*otlpMetrics.Metric{}
+	for _, b := range c.Batches(SignalMetrics) {
+		for _, rm := range b.Metrics {
+			for _, sm := range rm.GetScopeMetrics() {
+				for _, m := range sm.GetMetrics() {
+					if name == "" || m.GetName() == name {
+						list = append(list, m)
+					}
+				}
+			}
+		}
+	}
+	return list
+}
+
+// Logs returns all the log records received with the provided body; if
+// `body` is empty all records are returned.
+func (c *Collector) Logs(body string) []*otlpLogs.LogRecord {
+	list := []*otlpLogs.LogRecord{}
+	for _, b := range c.Batches(SignalLogs) {
+		for _, rl := range b.Logs {
+			for _, sl := range rl.GetScopeLogs() {
+				for _, rec := range sl.GetLogRecords() {
+					if body == "" || Value(rec.GetBody()) == body {
+						list = append(list, rec)
+					}
+				}
+			}
+		}
+	}
+	return list
+}
+
+// Reset discards all the data received.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	c.batches = nil
+	c.stats = Stats{}
+	c.mu.Unlock()
+}
+
+// Attribute returns the value of the attribute `key`, using its string
+// representation; for example to inspect resource attributes:
+//
+//	name, ok := otlptest.Attribute(res.GetAttributes(), "service.name")
+func Attribute(attrs []*otlpCommon.KeyValue, key string) (string, bool) {
+	for _, kv := range attrs {
+		if kv.GetKey() == key {
+			return Value(kv.GetValue()), true
+		}
+	}
+	return "", false
+}
+
+// Value returns the string representation of an OTLP value.
+func Value(v *otlpCommon.AnyValue) string {
+	switch val := v.GetValue().(type) {
+	case *otlpCommon.AnyValue_StringValue:
+		return val.StringValue
+	case *otlpCommon.AnyValue_BoolValue:
+		return fmt.Sprint(val.BoolValue)
+	case *otlpCommon.AnyValue_IntValue:
+		return fmt.Sprint(val.IntValue)
+	case *otlpCommon.AnyValue_DoubleValue:
+		return fmt.Sprint(val.DoubleValue)
+	case *otlpCommon.AnyValue_BytesValue:
+		return fmt.Sprintf("%x", val.BytesValue)
+	case *otlpCommon.AnyValue_ArrayValue:
+		list := []string{}
+		for _, item := range val.ArrayValue.GetValues() {
+			list = append(list, Value(item))
+		}
+		return fmt.Sprint(list)
+	case *otlpCommon.AnyValue_KvlistValue:
+		list := []string{}
+		for _, kv := range val.KvlistValue.GetValues() {
+			list = append(list, kv.GetKey()+"="+Value(kv.GetValue()))
+		}
+		return fmt.Sprint(list)
+	default:
+		return ""
+	}
+}
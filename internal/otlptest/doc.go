@@ -0,0 +1,36 @@
+/*
+Package otlptest provides a fake OTLP collector for tests.
+
+The collector receives telemetry data over gRPC and HTTP, using either
+protobuf or JSON encoding, and keeps everything in memory. Unlike the
+recorder in `telemetrytest`, the data goes through the actual OTLP
+exporters, so it can be used to verify the `otel.collector` settings,
+the resource attributes reported and that pending data is flushed when
+the service is reloaded or closed.
+
+	col := otlptest.New(t)
+	h := harness.New(t, map[string]any{
+		"otel": map[string]any{
+			"enabled":      true,
+			"service_name": "echo-test",
+			"collector":    col.Settings("http"),
+		},
+	})
+	_, _ = h.Client.Ping(context.Background(), &emptypb.Empty{})
+	_ = h.Close() // flush pending data
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := col.Wait(ctx, func(s otlptest.Stats) bool { return s.Spans > 0 }); err != nil {
+		t.Fatal("no spans received")
+	}
+	for _, res := range col.Resources(otlptest.SignalTraces) {
+		name, _ := otlptest.Attribute(res.GetAttributes(), "service.name")
+		...
+	}
+
+Exporters submit data asynchronously; use `Wait` before inspecting the
+data received. The same collector can be started outside of tests using
+`Start`, or with the `echoctl dev collector` command.
+*/
+package otlptest
@@ -0,0 +1,145 @@
+package otlptest
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.bryk.io/pkg/errors"
+	collectorLogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectorMetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	collectorTrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// maximum size accepted for an HTTP export request.
+const maxRequestSize = 32 << 20
+
+type traceService struct {
+	collectorTrace.UnimplementedTraceServiceServer
+	c *Collector
+}
+
+func (s *traceService) Export(_ context.Context, req *collectorTrace.ExportTraceServiceRequest) (*collectorTrace.ExportTraceServiceResponse, error) { // nolint: lll
+	s.c.record(Batch{Signal: SignalTraces, Protocol: "grpc", Traces: req.GetResourceSpans()})
+	return new(collectorTrace.ExportTraceServiceResponse), nil
+}
+
+type metricsService struct {
+	collectorMetrics.UnimplementedMetricsServiceServer
+	c *Collector
+}
+
+func (s *metricsService) Export(_ context.Context, req *collectorMetrics.ExportMetricsServiceRequest) (*collectorMetrics.ExportMetricsServiceResponse, error) { // nolint: lll
+	s.c.record(Batch{Signal: SignalMetrics, Protocol: "grpc", Metrics: req.GetResourceMetrics()})
+	return new(collectorMetrics.ExportMetricsServiceResponse), nil
+}
+
+type logsService struct {
+	collectorLogs.UnimplementedLogsServiceServer
+	c *Collector
+}
+
+func (s *logsService) Export(_ context.Context, req *collectorLogs.ExportLogsServiceRequest) (*collectorLogs.ExportLogsServiceResponse, error) { // nolint: lll
+	s.c.record(Batch{Signal: SignalLogs, Protocol: "grpc", Logs: req.GetResourceLogs()})
+	return new(collectorLogs.ExportLogsServiceResponse), nil
+}
+
+// ServeHTTP handles OTLP/HTTP export requests on the default paths, using
+// either binary protobuf or JSON encoding.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var (
+		req, res proto.Message
+		batch    Batch
+	)
+	switch r.URL.Path {
+	case "/v1/traces":
+		req, res = new(collectorTrace.ExportTraceServiceRequest), new(collectorTrace.ExportTraceServiceResponse)
+	case "/v1/metrics":
+		req, res = new(collectorMetrics.ExportMetricsServiceRequest), new(collectorMetrics.ExportMetricsServiceResponse)
+	case "/v1/logs":
+		req, res = new(collectorLogs.ExportLogsServiceRequest), new(collectorLogs.ExportLogsServiceResponse)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	asJSON := strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	if err := decode(r, req, asJSON); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch msg := req.(type) {
+	case *collectorTrace.ExportTraceServiceRequest:
+		batch = Batch{Signal: SignalTraces, Traces: msg.GetResourceSpans()}
+	case *collectorMetrics.ExportMetricsServiceRequest:
+		batch = Batch{Signal: SignalMetrics, Metrics: msg.GetResourceMetrics()}
+	case *collectorLogs.ExportLogsServiceRequest:
+		batch = Batch{Signal: SignalLogs, Logs: msg.GetResourceLogs()}
+	}
+	batch.Protocol = "http"
+	c.record(batch)
+
+	// reply using the same encoding as the request
+	var (
+		data []byte
+		err  error
+	)
+	if asJSON {
+		w.Header().Set("Content-Type", "application/json")
+		data, err = protojson.Marshal(res)
+	} else {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		data, err = proto.Marshal(res)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write(data)
+}
+
+// decode the body of an export request into `msg`.
+func decode(r *http.Request, msg proto.Message, asJSON bool) error {
+	var body io.Reader = io.LimitReader(r.Body, maxRequestSize)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return errors.Wrap(err, "invalid compressed content")
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		body = io.LimitReader(gz, maxRequestSize)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return protojson.Unmarshal(data, msg)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// record a batch received and notify any listeners.
+func (c *Collector) record(b Batch) {
+	b.Received = time.Now()
+	c.mu.Lock()
+	c.batches = append(c.batches, b)
+	c.stats.add(b)
+	close(c.updated)
+	c.updated = make(chan struct{})
+	listeners := c.listeners
+	c.mu.Unlock()
+	for _, fn := range listeners {
+		fn(b)
+	}
+}
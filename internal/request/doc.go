@@ -0,0 +1,21 @@
+/*
+Package request provides utilities shared by the interceptors and middleware
+handling gRPC and HTTP requests.
+
+Targets identify the requests covered by a setting; they can be a gRPC method
+name, like "Ping", a full method name, like "/sample.v1.ServiceAPI/Ping", or
+an HTTP route, optionally restricted to a request method, like "GET /v1/ping".
+Patterns are supported, like "/sample.v1.ServiceAPI/*".
+
+	if request.Match("GET /v1/status/*", r.Method+" "+r.URL.Path) {
+		// ...
+	}
+
+A `Recorder` retains the status code and size of HTTP responses, to report
+them once the request is handled.
+
+	rw := request.NewRecorder(w)
+	next.ServeHTTP(rw, r)
+	fmt.Println(rw.Code(), rw.Size())
+*/
+package request
@@ -0,0 +1,65 @@
+package request
+
+import (
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"go.bryk.io/pkg/errors"
+)
+
+// HTTP methods accepted on targets.
+var httpVerbs = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// IsMethod returns `true` if `verb` is a standard HTTP method.
+func IsMethod(verb string) bool {
+	return slices.Contains(httpVerbs, verb)
+}
+
+// Validate returns an error if `pattern` is not a valid target.
+func Validate(pattern string) error {
+	verb, name := Split(pattern)
+	if verb != "" && !IsMethod(verb) {
+		return errors.Errorf("invalid HTTP method: %s", verb)
+	}
+	if _, err := path.Match(name, ""); err != nil {
+		return errors.Errorf("invalid pattern: %s", pattern)
+	}
+	return nil
+}
+
+// Match returns `true` if `target` is covered by `pattern`. The pattern
+// can match either the last path segment or the full target; patterns
+// without an HTTP method match requests using any method.
+func Match(pattern, target string) bool {
+	pVerb, pPath := Split(pattern)
+	verb, name := Split(target)
+	if pVerb != "" && pVerb != verb {
+		return false
+	}
+	if pPath == name || pPath == path.Base(name) {
+		return true
+	}
+	ok, _ := path.Match(pPath, name)
+	return ok
+}
+
+// Split returns the HTTP method, if any, and path of a target.
+func Split(target string) (string, string) {
+	verb, name, ok := strings.Cut(strings.TrimSpace(target), " ")
+	if !ok {
+		return "", verb
+	}
+	return strings.ToUpper(verb), strings.TrimSpace(name)
+}
@@ -0,0 +1,100 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		target  string
+		ok      bool
+	}{
+		{pattern: "Ping", target: "/sample.v1.ServiceAPI/Ping", ok: true},
+		{pattern: "/sample.v1.ServiceAPI/Ping", target: "/sample.v1.ServiceAPI/Ping", ok: true},
+		{pattern: "/sample.v1.ServiceAPI/*", target: "/sample.v1.ServiceAPI/Echo", ok: true},
+		{pattern: "/sample.v1.ServiceAPI/*", target: "/grpc.health.v1.Health/Check"},
+		{pattern: "Ping", target: "/sample.v1.ServiceAPI/Ready"},
+		{pattern: "/v1/ping", target: "/v1/ping", ok: true},
+		{pattern: "/v1/ping", target: "GET /v1/ping", ok: true},
+		{pattern: "GET /v1/ping", target: "GET /v1/ping", ok: true},
+		{pattern: "get /v1/ping", target: "GET /v1/ping", ok: true},
+		{pattern: "GET /v1/ping", target: "POST /v1/ping"},
+		{pattern: "GET /v1/ping", target: "/v1/ping"},
+		{pattern: "POST /v1/status/*", target: "POST /v1/status/db", ok: true},
+		{pattern: "/v1/status/*", target: "/v1/status/db/primary"},
+		{pattern: "Ping", target: "GET /v1/ping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.target, func(t *testing.T) {
+			if ok := Match(tt.pattern, tt.target); ok != tt.ok {
+				t.Errorf("expected %v, got %v", tt.ok, ok)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ok      bool
+	}{
+		{pattern: "Ping", ok: true},
+		{pattern: "/sample.v1.ServiceAPI/*", ok: true},
+		{pattern: "GET /v1/ping", ok: true},
+		{pattern: "FETCH /v1/ping"},
+		{pattern: "/v1/[ping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if err := Validate(tt.pattern); (err == nil) != tt.ok {
+				t.Errorf("unexpected result: %v", err)
+			}
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		code    int
+		size    int64
+	}{
+		{
+			name:    "implicit status",
+			handler: func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("pong")) },
+			code:    http.StatusOK,
+			size:    4,
+		},
+		{
+			name: "explicit status",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte("not found"))
+			},
+			code: http.StatusNotFound,
+			size: 9,
+		},
+		{
+			name: "status after body is ignored",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			code: http.StatusOK,
+			size: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := NewRecorder(httptest.NewRecorder())
+			tt.handler(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rw.Code() != tt.code || rw.Size() != tt.size {
+				t.Errorf("expected (%d, %d), got (%d, %d)", tt.code, tt.size, rw.Code(), rw.Size())
+			}
+		})
+	}
+}
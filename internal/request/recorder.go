@@ -0,0 +1,57 @@
+package request
+
+import (
+	"net/http"
+)
+
+// Recorder retains the status code and size of an HTTP response.
+type Recorder struct {
+	http.ResponseWriter
+	code        int
+	size        int64
+	wroteHeader bool
+}
+
+// NewRecorder returns a recorder wrapping `w`; the status code defaults
+// to 200 if not set explicitly by the handler.
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, code: http.StatusOK}
+}
+
+// Code returns the response status code.
+func (rw *Recorder) Code() int {
+	return rw.code
+}
+
+// Size returns the number of bytes written on the response body.
+func (rw *Recorder) Size() int64 {
+	return rw.size
+}
+
+// WriteHeader records the response status code.
+func (rw *Recorder) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.code, rw.wroteHeader = code, true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the size of the response body.
+func (rw *Recorder) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += int64(n)
+	return n, err
+}
+
+// Flush sends any buffered data to the client, if supported.
+func (rw *Recorder) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original response writer.
+func (rw *Recorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,30 @@
+/*
+Package rpc provides a gRPC server able to expose its services through an
+HTTP gateway on the same listener.
+
+Requests using the gRPC protocol are handled by the gRPC server, all other
+requests are handled by the HTTP gateway, if enabled. Both share the same
+network listener and TLS configuration; the TLS settings are provided as a
+standard `tls.Config`, so certificates can be rotated using `GetCertificate`
+and client certificates can be verified as required.
+
+	srv, err := rpc.NewServer(
+		rpc.WithPort(9090),
+		rpc.WithTLS(conf),
+		rpc.WithServiceProvider(service),
+		rpc.WithHTTPGateway(gw),
+	)
+	if err != nil {
+		panic(err)
+	}
+	if err = srv.Start(); err != nil {
+		panic(err)
+	}
+	defer srv.Stop(true)
+
+The HTTP gateway acts as a client of the gRPC server. It can connect using
+the network listener, verifying the server certificate and presenting its
+own when required; or, using `WithInProcessClient`, through an in-process
+channel that doesn't leave the process and skips TLS altogether.
+*/
+package rpc
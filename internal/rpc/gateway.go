@@ -0,0 +1,170 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// metadata key used to mark the requests forwarded by an in-process
+// gateway.
+const gatewayKey = "x-rpc-gateway"
+
+// GatewayRegisterFunc registers the services of a provider on the HTTP
+// gateway; `conn` is the client connection to the gRPC server.
+type GatewayRegisterFunc func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+// GatewayOption allows adjusting gateway settings following a functional
+// pattern.
+type GatewayOption func(gw *Gateway) error
+
+// Gateway exposes the services registered on a gRPC server as a
+// RESTful HTTP API.
+type Gateway struct {
+	muxOpts    []runtime.ServeMuxOption
+	middleware []func(http.Handler) http.Handler
+	dialOpts   []grpc.DialOption
+	clientTLS  *tls.Config
+	clientCred credentials.TransportCredentials
+	inProcess  bool
+	mux        *runtime.ServeMux
+}
+
+// NewGateway returns a new HTTP gateway instance; to be used with the
+// `WithHTTPGateway` server option.
+func NewGateway(opts ...GatewayOption) (*Gateway, error) {
+	gw := &Gateway{}
+	for _, opt := range opts {
+		if err := opt(gw); err != nil {
+			return nil, err
+		}
+	}
+	return gw, nil
+}
+
+// WithGatewayMiddleware registers a middleware function on the HTTP
+// gateway; middleware functions are applied in the order they are
+// registered, the first one is the outermost.
+func WithGatewayMiddleware(mw func(http.Handler) http.Handler) GatewayOption {
+	return func(gw *Gateway) error {
+		gw.middleware = append(gw.middleware, mw)
+		return nil
+	}
+}
+
+// WithPrettyJSON returns indented JSON responses when requested using
+// the provided MIME type on the "Accept" header; for example:
+// "application/json+pretty".
+func WithPrettyJSON(mime string) GatewayOption {
+	return func(gw *Gateway) error {
+		gw.muxOpts = append(gw.muxOpts, runtime.WithMarshalerOption(mime, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				Indent:          "  ",
+				EmitUnpopulated: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
+		}))
+		return nil
+	}
+}
+
+// WithClientTLS sets the TLS settings used by the gateway to connect to
+// a server using TLS; required in that case.
+func WithClientTLS(conf *tls.Config) GatewayOption {
+	return func(gw *Gateway) error {
+		gw.clientTLS = conf
+		return nil
+	}
+}
+
+// WithClientCredentials sets the credentials used by the gateway to
+// connect to a server using TLS; takes precedence over `WithClientTLS`.
+// Useful when the client settings change over time, for example to
+// rotate certificates.
+func WithClientCredentials(creds credentials.TransportCredentials) GatewayOption {
+	return func(gw *Gateway) error {
+		gw.clientCred = creds
+		return nil
+	}
+}
+
+// WithInProcessClient connects the gateway to the server using an
+// in-process channel instead of the server network listener. Requests
+// don't leave the process, and TLS settings for the client are not
+// required.
+func WithInProcessClient() GatewayOption {
+	return func(gw *Gateway) error {
+		gw.inProcess = true
+		return nil
+	}
+}
+
+// WithClientOptions adjusts the client connection used by the gateway;
+// for example to add OpenTelemetry instrumentation.
+func WithClientOptions(opts ...grpc.DialOption) GatewayOption {
+	return func(gw *Gateway) error {
+		gw.dialOpts = append(gw.dialOpts, opts...)
+		return nil
+	}
+}
+
+// Handler returns the HTTP handler for the gateway, including all its
+// middleware.
+func (gw *Gateway) Handler() http.Handler {
+	var handler http.Handler = gw.serveMux()
+	for i := len(gw.middleware) - 1; i >= 0; i-- {
+		handler = gw.middleware[i](handler)
+	}
+	return handler
+}
+
+// register the services of a provider on the gateway.
+func (gw *Gateway) register(fn GatewayRegisterFunc, conn *grpc.ClientConn) error {
+	return fn(context.Background(), gw.serveMux(), conn)
+}
+
+// FromGateway returns `true` if the request on `ctx` was forwarded by an
+// HTTP gateway using the in-process channel; see `WithInProcessClient`.
+// Useful to avoid handling a request twice, for example on access logs.
+func FromGateway(ctx context.Context) bool {
+	// the mark is only trusted on the in-process channel, which can't be
+	// reached from outside the process
+	if p, ok := peer.FromContext(ctx); !ok || p.Addr == nil || p.Addr.Network() != "bufconn" {
+		return false
+	}
+	return len(metadata.ValueFromIncomingContext(ctx, gatewayKey)) > 0
+}
+
+// markForwarded returns the client options to mark the requests forwarded
+// by the gateway.
+func markForwarded() []grpc.DialOption {
+	mark := func(ctx context.Context) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, gatewayKey, "true")
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any,
+			cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(mark(ctx), method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+			method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(mark(ctx), desc, cc, method, opts...)
+		}),
+	}
+}
+
+func (gw *Gateway) serveMux() *runtime.ServeMux {
+	if gw.mux == nil {
+		gw.mux = runtime.NewServeMux(gw.muxOpts...)
+	}
+	return gw.mux
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	protov1 "github.com/bcessa/echo-service/proto/sample/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestFromGateway(t *testing.T) {
+	forwarded := make(chan bool, 1)
+	gw, err := NewGateway(WithInProcessClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewServer(
+		WithPort(0),
+		WithServiceProvider(new(service)),
+		WithHTTPGateway(gw),
+		WithInProcessChannel(),
+		WithUnaryMiddleware(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			forwarded <- FromGateway(ctx)
+			return handler(ctx, req)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Stop(true) }()
+	_, port, _ := net.SplitHostPort(srv.Addr().String())
+	addr := net.JoinHostPort("localhost", port)
+
+	ping := func(t *testing.T, ctx context.Context, conn *grpc.ClientConn) {
+		t.Helper()
+		defer func() { _ = conn.Close() }()
+		if _, err := protov1.NewServiceAPIClient(conn).Ping(ctx, &emptypb.Empty{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name      string
+		request   func(t *testing.T)
+		forwarded bool
+	}{
+		{
+			name: "HTTP request",
+			request: func(t *testing.T) {
+				res, err := http.Get("http://" + addr + "/v1/ping")
+				if err != nil {
+					t.Fatal(err)
+				}
+				_ = res.Body.Close()
+			},
+			forwarded: true,
+		},
+		{
+			name: "gRPC request",
+			request: func(t *testing.T) {
+				conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				// the mark is ignored outside the in-process channel
+				ping(t, metadata.AppendToOutgoingContext(context.Background(), gatewayKey, "true"), conn)
+			},
+		},
+		{
+			name: "in-process request",
+			request: func(t *testing.T) {
+				conn, err := srv.InProcessConn()
+				if err != nil {
+					t.Fatal(err)
+				}
+				ping(t, context.Background(), conn)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.request(t)
+			if res := <-forwarded; res != tt.forwarded {
+				t.Errorf("expected forwarded=%v, got %v", tt.forwarded, res)
+			}
+		})
+	}
+}
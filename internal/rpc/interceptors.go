@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/bufbuild/protovalidate-go"
+	"go.bryk.io/pkg/errors"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// unaryInterceptors returns the interceptors for unary requests; panic
+// recovery and rate limits are applied first, and input validation is
+// applied right before the request is handled.
+func (s *Server) unaryInterceptors() []grpc.UnaryServerInterceptor {
+	list := []grpc.UnaryServerInterceptor{}
+	if s.recovery {
+		list = append(list, func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+			defer recoverPanic(&err)
+			return handler(ctx, req)
+		})
+	}
+	if rl := s.rl; rl != nil {
+		list = append(list, func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if !rl.Allow() {
+				return nil, errRateLimit
+			}
+			return handler(ctx, req)
+		})
+	}
+	list = append(list, s.unary...)
+	if validate := s.validate; validate != nil {
+		list = append(list, func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := validate(req); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		})
+	}
+	return list
+}
+
+// streamInterceptors returns the interceptors for streaming requests,
+// in the same order used for unary requests.
+func (s *Server) streamInterceptors() []grpc.StreamServerInterceptor {
+	list := []grpc.StreamServerInterceptor{}
+	if s.recovery {
+		list = append(list, func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+			defer recoverPanic(&err)
+			return handler(srv, ss)
+		})
+	}
+	if rl := s.rl; rl != nil {
+		list = append(list, func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if !rl.Allow() {
+				return errRateLimit
+			}
+			return handler(srv, ss)
+		})
+	}
+	list = append(list, s.stream...)
+	if validate := s.validate; validate != nil {
+		list = append(list, func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(srv, &validatedStream{ServerStream: ss, validate: validate})
+		})
+	}
+	return list
+}
+
+// error returned when the rate limit is exceeded.
+var errRateLimit = status.Error(codes.ResourceExhausted, "rate limit exceeded")
+
+// limiter returns the rate limiter shared by all requests; `nil` if no
+// limit is set.
+func limiter(limits *ResourceLimits) *rate.Limiter {
+	if limits == nil || limits.Rate == 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(limits.Rate), int(limits.Rate))
+}
+
+// validator returns the function used to validate incoming messages.
+func validator() (func(msg any) error, error) {
+	pv, err := protovalidate.New()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to setup input validation")
+	}
+	return func(msg any) error {
+		pm, ok := msg.(proto.Message)
+		if !ok {
+			return nil
+		}
+		if err := pv.Validate(pm); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil
+	}, nil
+}
+
+// recoverPanic reports a `panic` as an internal error.
+func recoverPanic(err *error) {
+	if v := recover(); v != nil {
+		*err = status.Errorf(codes.Internal, "panic: %v", v)
+	}
+}
+
+// validatedStream validates all the messages received.
+type validatedStream struct {
+	grpc.ServerStream
+	validate func(msg any) error
+}
+
+func (vs *validatedStream) RecvMsg(msg any) error {
+	if err := vs.ServerStream.RecvMsg(msg); err != nil {
+		return err
+	}
+	return vs.validate(msg)
+}
@@ -0,0 +1,165 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"net"
+
+	"go.bryk.io/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+)
+
+// ServerOption allows adjusting server settings following a functional
+// pattern.
+type ServerOption func(srv *Server) error
+
+// WithPort sets the TCP port used by the server.
+func WithPort(port int) ServerOption {
+	return func(srv *Server) error {
+		if port < 0 || port > 65535 {
+			return errors.Errorf("invalid TCP port %d", port)
+		}
+		srv.port = port
+		return nil
+	}
+}
+
+// WithNetworkInterface sets the network interface used to listen on a TCP
+// port: "local", "all" or a specific interface name. Defaults to "local".
+func WithNetworkInterface(name string) ServerOption {
+	return func(srv *Server) error {
+		srv.netInt = name
+		return nil
+	}
+}
+
+// WithUnixSocket sets the server to listen on a UNIX socket instead of a
+// TCP port.
+func WithUnixSocket(socket string) ServerOption {
+	return func(srv *Server) error {
+		srv.socket = socket
+		return nil
+	}
+}
+
+// WithListener sets the server to use the provided network listener;
+// port and UNIX socket settings are ignored. The listener is closed when
+// the server stops.
+func WithListener(lis net.Listener) ServerOption {
+	return func(srv *Server) error {
+		srv.custom = lis
+		return nil
+	}
+}
+
+// WithTLS enables secure communications using the provided settings.
+// Client certificates are handled according to `conf.ClientAuth`; use
+// `conf.GetCertificate` to rotate certificates without restarting the
+// server.
+func WithTLS(conf *tls.Config) ServerOption {
+	return func(srv *Server) error {
+		if conf == nil {
+			return errors.New("TLS settings are required")
+		}
+		srv.tls = conf
+		return nil
+	}
+}
+
+// WithResourceLimits applies limits to the connections and requests
+// handled by the server.
+func WithResourceLimits(limits ResourceLimits) ServerOption {
+	return func(srv *Server) error {
+		srv.limits = &limits
+		return nil
+	}
+}
+
+// WithPanicRecovery returns an internal error to the client instead of
+// crashing the server if a `panic` occurs while handling a request.
+func WithPanicRecovery() ServerOption {
+	return func(srv *Server) error {
+		srv.recovery = true
+		return nil
+	}
+}
+
+// WithInputValidation validates incoming messages using the protovalidate
+// annotations on their definitions; invalid requests are rejected.
+func WithInputValidation() ServerOption {
+	return func(srv *Server) error {
+		srv.validation = true
+		return nil
+	}
+}
+
+// WithReflection enables the gRPC reflection service.
+func WithReflection() ServerOption {
+	return func(srv *Server) error {
+		srv.reflection = true
+		return nil
+	}
+}
+
+// WithUnaryMiddleware registers an interceptor for unary requests;
+// interceptors run in the order they are registered.
+func WithUnaryMiddleware(entry grpc.UnaryServerInterceptor) ServerOption {
+	return func(srv *Server) error {
+		srv.unary = append(srv.unary, entry)
+		return nil
+	}
+}
+
+// WithStreamMiddleware registers an interceptor for streaming requests;
+// interceptors run in the order they are registered.
+func WithStreamMiddleware(entry grpc.StreamServerInterceptor) ServerOption {
+	return func(srv *Server) error {
+		srv.stream = append(srv.stream, entry)
+		return nil
+	}
+}
+
+// WithStatsHandler registers a handler to monitor the server activity;
+// for example for OpenTelemetry instrumentation.
+func WithStatsHandler(sh stats.Handler) ServerOption {
+	return func(srv *Server) error {
+		srv.stats = append(srv.stats, sh)
+		return nil
+	}
+}
+
+// WithServiceProvider registers the services of the provider on the
+// server; and on the HTTP gateway, if enabled and supported by the
+// provider.
+func WithServiceProvider(sp ServiceProvider) ServerOption {
+	return func(srv *Server) error {
+		srv.providers = append(srv.providers, sp)
+		return nil
+	}
+}
+
+// WithHTTPGateway exposes the services through the provided HTTP gateway.
+func WithHTTPGateway(gw *Gateway) ServerOption {
+	return func(srv *Server) error {
+		srv.gateway = gw
+		return nil
+	}
+}
+
+// WithHTTPGatewayOptions adjusts the settings of the HTTP gateway, if
+// enabled; ignored otherwise.
+func WithHTTPGatewayOptions(opts ...GatewayOption) ServerOption {
+	return func(srv *Server) error {
+		srv.gwOpts = append(srv.gwOpts, opts...)
+		return nil
+	}
+}
+
+// WithInProcessChannel enables a channel to connect to the server without
+// leaving the process; use `InProcessConn` to get a client connection.
+func WithInProcessChannel() ServerOption {
+	return func(srv *Server) error {
+		srv.inProcess = true
+		return nil
+	}
+}
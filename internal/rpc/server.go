@@ -0,0 +1,430 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.bryk.io/pkg/errors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/net/netutil"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	// NetworkInterfaceLocal listens on the loopback interface only.
+	NetworkInterfaceLocal = "local"
+
+	// NetworkInterfaceAll listens on all available network interfaces.
+	NetworkInterfaceAll = "all"
+
+	// maximum time to wait for in-flight requests when stopping gracefully.
+	shutdownTimeout = 10 * time.Second
+
+	// buffer size used by the in-process channel.
+	inProcessBuffer = 1024 * 1024
+)
+
+// ServiceProvider elements register services on the gRPC server.
+type ServiceProvider interface {
+	// ServerSetup registers the services on the provided server.
+	ServerSetup(server *grpc.Server)
+}
+
+// HTTPServiceProvider elements can also expose their services through
+// the HTTP gateway.
+type HTTPServiceProvider interface {
+	ServiceProvider
+
+	// GatewaySetup returns the function used to register the services
+	// on the HTTP gateway.
+	GatewaySetup() GatewayRegisterFunc
+}
+
+// ResourceLimits applied by the server.
+// nolint: lll
+type ResourceLimits struct {
+	// Maximum number of simultaneous connections.
+	Connections uint32 `json:"connections" yaml:"connections" mapstructure:"connections" desc:"maximum number of simultaneous connections; 0 for no limit"`
+
+	// Maximum number of concurrent requests per connection.
+	Requests uint32 `json:"requests" yaml:"requests" mapstructure:"requests" desc:"maximum number of concurrent requests per connection; 0 for no limit"`
+
+	// Maximum number of requests per second.
+	Rate uint32 `json:"rate" yaml:"rate" mapstructure:"rate" desc:"maximum number of requests per second; 0 for no limit"`
+}
+
+// Server instances handle gRPC requests and, optionally, HTTP requests
+// through a gateway; both on the same network listener.
+type Server struct {
+	port       int
+	netInt     string
+	socket     string
+	custom     net.Listener
+	tls        *tls.Config
+	limits     *ResourceLimits
+	recovery   bool
+	validation bool
+	reflection bool
+	inProcess  bool
+	unary      []grpc.UnaryServerInterceptor
+	stream     []grpc.StreamServerInterceptor
+	stats      []stats.Handler
+	providers  []ServiceProvider
+	gateway    *Gateway
+	gwOpts     []GatewayOption
+	rl         *rate.Limiter
+	validate   func(msg any) error
+
+	grpc     *grpc.Server
+	http     *http.Server
+	listener net.Listener
+	local    *bufconn.Listener
+	gwConn   *grpc.ClientConn
+	handler  http.Handler
+	inflight atomic.Int64
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	err      error
+	errMu    sync.Mutex
+}
+
+// NewServer returns a new server instance based on the provided options.
+// The server doesn't listen for requests until `Start` is called.
+func NewServer(opts ...ServerOption) (*Server, error) {
+	srv := &Server{netInt: NetworkInterfaceLocal}
+	for _, opt := range opts {
+		if err := opt(srv); err != nil {
+			return nil, err
+		}
+	}
+	if srv.port != 0 && srv.socket != "" {
+		return nil, errors.New("port and unix socket can't be used simultaneously")
+	}
+	if srv.gateway == nil && len(srv.gwOpts) > 0 {
+		// gateway options are only used if the gateway is enabled
+		srv.gwOpts = nil
+	}
+	if srv.gateway != nil {
+		for _, opt := range srv.gwOpts {
+			if err := opt(srv.gateway); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// gRPC server
+	var err error
+	srv.rl = limiter(srv.limits)
+	if srv.validation {
+		if srv.validate, err = validator(); err != nil {
+			return nil, err
+		}
+	}
+	gOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(srv.unaryInterceptors()...),
+		grpc.ChainStreamInterceptor(srv.streamInterceptors()...),
+	}
+	for _, sh := range srv.stats {
+		gOpts = append(gOpts, grpc.StatsHandler(sh))
+	}
+	if srv.limits != nil && srv.limits.Requests > 0 {
+		gOpts = append(gOpts, grpc.MaxConcurrentStreams(srv.limits.Requests))
+	}
+	srv.grpc = grpc.NewServer(gOpts...)
+	for _, sp := range srv.providers {
+		sp.ServerSetup(srv.grpc)
+	}
+	if srv.reflection {
+		reflection.Register(srv.grpc)
+	}
+	return srv, nil
+}
+
+// Start listening for requests; the method returns once the server is
+// ready to receive requests.
+func (s *Server) Start() (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.http != nil {
+		return errors.New("server already started")
+	}
+	lis, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	// in-process channel; also used by the gateway if required
+	if s.inProcess || (s.gateway != nil && s.gateway.inProcess) {
+		s.local = bufconn.Listen(inProcessBuffer)
+		s.serve(func() error { return s.grpc.Serve(s.local) })
+	}
+
+	// HTTP gateway
+	s.handler = http.NotFoundHandler()
+	if s.gateway != nil {
+		if s.handler, err = s.setupGateway(lis); err != nil {
+			_ = lis.Close()
+			s.closeLocal()
+			return errors.Wrap(err, "failed to setup HTTP gateway")
+		}
+	}
+
+	// requests not using TLS are handled using HTTP/2 with prior knowledge
+	// (h2c); required by gRPC clients
+	h2s := new(http2.Server)
+	if s.limits != nil && s.limits.Requests > 0 {
+		h2s.MaxConcurrentStreams = s.limits.Requests
+	}
+	var handler http.Handler = http.HandlerFunc(s.route)
+	if s.tls == nil {
+		handler = h2c.NewHandler(handler, h2s)
+	}
+	s.http = &http.Server{
+		Handler:           handler,
+		TLSConfig:         s.tls,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err = http2.ConfigureServer(s.http, h2s); err != nil {
+		_ = lis.Close()
+		s.closeLocal()
+		s.http, s.listener = nil, nil
+		return errors.Wrap(err, "invalid HTTP/2 settings")
+	}
+	if s.tls != nil {
+		// certificates are provided by the TLS configuration
+		s.serve(func() error { return s.http.ServeTLS(lis, "", "") })
+	} else {
+		s.serve(func() error { return s.http.Serve(lis) })
+	}
+	return nil
+}
+
+// Stop the server; when `graceful` is set, in-flight requests are given
+// some time to complete. Any error returned while serving requests is
+// reported. A stopped server can't be started again.
+func (s *Server) Stop(graceful bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.http == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if graceful {
+		// stop accepting new connections and requests; HTTP/2 clients
+		// are notified to use a new connection
+		_ = s.http.Shutdown(ctx)
+		s.drain(ctx)
+	}
+	_ = s.http.Close()
+	s.grpc.Stop()
+	if s.gwConn != nil {
+		_ = s.gwConn.Close()
+		s.gwConn = nil
+	}
+	s.closeLocal()
+	s.wg.Wait()
+	s.http, s.listener = nil, nil
+	s.errMu.Lock()
+	err := s.err
+	s.err = nil
+	s.errMu.Unlock()
+	return err
+}
+
+// Addr returns the network address the server is listening on; `nil` if
+// the server is not started.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// InProcessConn returns a client connection using the in-process channel.
+// The channel must be enabled using `WithInProcessChannel`, and the server
+// started, before calling this method.
+func (s *Server) InProcessConn(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if s.local == nil {
+		return nil, errors.New("in-process channel is not available")
+	}
+	local := s.local
+	opts = append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return local.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+	return grpc.NewClient("passthrough:///in-process", opts...)
+}
+
+// route requests to the gRPC server or the HTTP gateway.
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	s.inflight.Add(1)
+	defer s.inflight.Add(-1)
+	if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		s.grpc.ServeHTTP(w, r)
+		return
+	}
+	s.handler.ServeHTTP(w, r)
+}
+
+// listen returns the network listener used by the server.
+func (s *Server) listen() (lis net.Listener, err error) {
+	switch {
+	case s.custom != nil:
+		lis = s.custom
+	case s.socket != "":
+		lis, err = net.Listen("unix", s.socket)
+	default:
+		var addr string
+		if addr, err = Address(s.netInt, s.port); err == nil {
+			lis, err = net.Listen("tcp", addr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.limits != nil && s.limits.Connections > 0 {
+		lis = netutil.LimitListener(lis, int(s.limits.Connections))
+	}
+	s.listener = lis
+	return lis, nil
+}
+
+// setupGateway connects the HTTP gateway to the server and returns the
+// handler for HTTP requests.
+func (s *Server) setupGateway(lis net.Listener) (http.Handler, error) {
+	var err error
+	if s.gateway.inProcess {
+		s.gwConn, err = s.InProcessConn(append(markForwarded(), s.gateway.dialOpts...)...)
+	} else {
+		s.gwConn, err = s.dial(lis.Addr())
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, sp := range s.providers {
+		if hp, ok := sp.(HTTPServiceProvider); ok {
+			if err = s.gateway.register(hp.GatewaySetup(), s.gwConn); err != nil {
+				_ = s.gwConn.Close()
+				s.gwConn = nil
+				return nil, err
+			}
+		}
+	}
+	return s.gateway.Handler(), nil
+}
+
+// dial returns a client connection to the server network listener.
+func (s *Server) dial(addr net.Addr) (*grpc.ClientConn, error) {
+	target := addr.String()
+	switch addr.Network() {
+	case "unix":
+		target = "unix://" + target
+	case "tcp":
+		if _, port, err := net.SplitHostPort(target); err == nil {
+			target = net.JoinHostPort("localhost", port)
+		}
+	default:
+		return nil, errors.Errorf("unsupported network: %s", addr.Network())
+	}
+	creds := insecure.NewCredentials()
+	if s.tls != nil {
+		switch {
+		case s.gateway.clientCred != nil:
+			creds = s.gateway.clientCred
+		case s.gateway.clientTLS != nil:
+			creds = credentials.NewTLS(s.gateway.clientTLS)
+		default:
+			return nil, errors.New("TLS settings are required by the gateway client")
+		}
+	}
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, s.gateway.dialOpts...)
+	return grpc.NewClient(target, opts...)
+}
+
+// serve runs `fn` in the background, retaining the first error returned.
+func (s *Server) serve(fn func() error) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := fn(); err != nil && !isClosed(err) {
+			s.errMu.Lock()
+			if s.err == nil {
+				s.err = err
+			}
+			s.errMu.Unlock()
+		}
+	}()
+}
+
+// drain waits for in-flight requests to complete.
+func (s *Server) drain(ctx context.Context) {
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	for s.inflight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+func (s *Server) closeLocal() {
+	if s.local != nil {
+		_ = s.local.Close()
+		s.local = nil
+	}
+}
+
+// isClosed returns `true` for the errors returned when a server is
+// stopped.
+func isClosed(err error) bool {
+	return errors.Is(err, http.ErrServerClosed) ||
+		errors.Is(err, grpc.ErrServerStopped) ||
+		errors.Is(err, net.ErrClosed)
+}
+
+// Address returns the TCP address to listen on for the provided network
+// interface and port; `netInt` can be "local", "all" or a specific
+// interface name.
+func Address(netInt string, port int) (string, error) {
+	switch netInt {
+	case "", NetworkInterfaceAll:
+		return net.JoinHostPort("", strconv.Itoa(port)), nil
+	case NetworkInterfaceLocal:
+		return net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), nil
+	}
+	ni, err := net.InterfaceByName(netInt)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid network interface: %s", netInt)
+	}
+	addrs, err := ni.Addrs()
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		if ip, ok := addr.(*net.IPNet); ok && ip.IP.To4() != nil {
+			return net.JoinHostPort(ip.IP.String(), strconv.Itoa(port)), nil
+		}
+	}
+	return "", errors.Errorf("no IPv4 address available on network interface: %s", netInt)
+}
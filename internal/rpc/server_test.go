@@ -0,0 +1,181 @@
+package rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	protov1 "github.com/bcessa/echo-service/proto/sample/v1"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestServerRouting(t *testing.T) {
+	cert, pool := selfSigned(t)
+	serverTLS := &tls.Config{Certificates: []tls.Certificate{cert}}
+	clientTLS := &tls.Config{RootCAs: pool, ServerName: "localhost"}
+
+	tests := []struct {
+		name    string
+		tls     bool
+		gateway []GatewayOption
+	}{
+		{name: "h2c"},
+		{name: "h2c in-process gateway", gateway: []GatewayOption{WithInProcessClient()}},
+		{name: "TLS", tls: true, gateway: []GatewayOption{WithClientTLS(clientTLS)}},
+		{name: "TLS in-process gateway", tls: true, gateway: []GatewayOption{WithInProcessClient()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw, err := NewGateway(tt.gateway...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := []ServerOption{
+				WithPort(0),
+				WithServiceProvider(new(service)),
+				WithHTTPGateway(gw),
+			}
+			creds := insecure.NewCredentials()
+			if tt.tls {
+				opts = append(opts, WithTLS(serverTLS.Clone()))
+				creds = credentials.NewTLS(clientTLS)
+			}
+			addr := start(t, opts...)
+
+			// gRPC requests
+			conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = conn.Close() }()
+			res, err := protov1.NewServiceAPIClient(conn).Ping(context.Background(), &emptypb.Empty{})
+			if err != nil || !res.Ok {
+				t.Fatalf("gRPC request failed: %v", err)
+			}
+
+			// HTTP requests, using both HTTP/1.1 and HTTP/2
+			scheme := "http"
+			h1 := &http.Transport{}
+			h2 := &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+					return new(net.Dialer).DialContext(ctx, network, addr)
+				},
+			}
+			if tt.tls {
+				scheme = "https"
+				h1 = &http.Transport{TLSClientConfig: clientTLS.Clone()}
+				h2 = &http2.Transport{TLSClientConfig: clientTLS.Clone()}
+			}
+			defer h1.CloseIdleConnections()
+			defer h2.CloseIdleConnections()
+			for major, rt := range map[int]http.RoundTripper{1: h1, 2: h2} {
+				res, err := (&http.Client{Transport: rt}).Get(scheme + "://" + addr + "/v1/ping")
+				if err != nil {
+					t.Fatalf("HTTP/%d request failed: %s", major, err)
+				}
+				body, _ := io.ReadAll(res.Body)
+				_ = res.Body.Close()
+				if res.ProtoMajor != major {
+					t.Errorf("HTTP/%d request served using %s", major, res.Proto)
+				}
+				if res.StatusCode != http.StatusOK || !strings.Contains(string(body), `"ok":true`) {
+					t.Errorf("HTTP/%d request: unexpected response %d: %s", major, res.StatusCode, body)
+				}
+			}
+		})
+	}
+
+	t.Run("without gateway", func(t *testing.T) {
+		addr := start(t, WithPort(0), WithServiceProvider(new(service)))
+		res, err := http.Get("http://" + addr + "/v1/ping")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, res.StatusCode)
+		}
+	})
+}
+
+// start a server using the provided options and return its address; the
+// server is stopped once the test completes.
+func start(t *testing.T, opts ...ServerOption) string {
+	t.Helper()
+	srv, err := NewServer(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := srv.Stop(true); err != nil {
+			t.Error(err)
+		}
+	})
+	_, port, _ := net.SplitHostPort(srv.Addr().String())
+	return net.JoinHostPort("localhost", port)
+}
+
+// service used to test request routing.
+type service struct {
+	protov1.UnimplementedServiceAPIServer
+}
+
+func (s *service) Ping(_ context.Context, _ *emptypb.Empty) (*protov1.PingResponse, error) {
+	return &protov1.PingResponse{Ok: true}, nil
+}
+
+func (s *service) ServerSetup(server *grpc.Server) {
+	protov1.RegisterServiceAPIServer(server, s)
+}
+
+func (s *service) GatewaySetup() GatewayRegisterFunc {
+	return protov1.RegisterServiceAPIHandler
+}
+
+// selfSigned returns a certificate for "localhost" and a pool to verify it.
+func selfSigned(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
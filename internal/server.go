@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"github.com/bcessa/echo-service/internal/dx"
+	dxHandler "github.com/bcessa/echo-service/internal/dx/modules/handler"
+	dxLog "github.com/bcessa/echo-service/internal/dx/modules/log"
+	dxMW "github.com/bcessa/echo-service/internal/dx/modules/middleware"
+	dxOtel "github.com/bcessa/echo-service/internal/dx/modules/otel"
+	dxRpc "github.com/bcessa/echo-service/internal/dx/modules/rpc"
+	dxTLS "github.com/bcessa/echo-service/internal/dx/modules/tls"
+	"github.com/bcessa/echo-service/internal/rpc"
+	xlog "go.bryk.io/pkg/log"
+)
+
+// ServerModules returns the modules required by the server, using the
+// provided "log" and "handler" modules; they are shared with the rest of
+// the application.
+func ServerModules(logs *dxLog.Module, handler *dxHandler.Module) []dx.Module {
+	return []dx.Module{
+		logs,
+		new(dxRpc.Module),
+		new(dxTLS.Module),
+		new(dxMW.Module),
+		new(dxOtel.Module),
+		handler,
+	}
+}
+
+// StartServer starts the modules returned by `ServerModules` in dependency
+// order; the settings must be loaded on `reg` beforehand. The build details,
+// metrics and log levels endpoints are registered on the HTTP gateway, and
+// `logger` returns the logger used by each component. Once started, log
+// messages are exported using the providers of the "otel" module.
+func StartServer(reg *dx.Registry, logger func(component string) xlog.Logger) error {
+	logMod, _ := reg.Get("log").(*dxLog.Module)
+	tlsMod, _ := reg.Get("tls").(*dxTLS.Module)
+	mwMod, _ := reg.Get("middleware").(*dxMW.Module)
+	otelMod, _ := reg.Get("otel").(*dxOtel.Module)
+	rpcMod, _ := reg.Get("rpc").(*dxRpc.Module)
+
+	// component loggers
+	tlsMod.Logger = logger("tls")
+	mwMod.Logger = logger("http")
+	otelMod.Logger = logger("otel")
+
+	// register build information on the rpc server; the service
+	// handler is provided by the "handler" module
+	rpcMod.Extend(rpc.WithHTTPGatewayOptions(rpc.WithGatewayMiddleware(BuildDetails().Middleware())))
+	if logMod.AdminPath != "" {
+		// requests are rejected unless authenticated by the gateway middleware
+		if (mwMod.JWT == nil || !mwMod.JWT.Enabled) && (mwMod.APIKey == nil || !mwMod.APIKey.Enabled) {
+			mwMod.Logger.Warning("HTTP authentication is not enabled; log levels can't be adjusted")
+		}
+		rpcMod.Extend(rpc.WithHTTPGatewayOptions(rpc.WithGatewayMiddleware(logMod.AdminHandler())))
+	}
+
+	// expose metrics on the gateway, if enabled
+	rpcMod.Extend(rpc.WithHTTPGatewayOptions(rpc.WithGatewayMiddleware(otelMod.MetricsHandler())))
+
+	// modules already started are stopped if any of them fails
+	if err := reg.Start(); err != nil {
+		return err
+	}
+	logMod.Export(otelMod.LoggerProvider())
+	return nil
+}
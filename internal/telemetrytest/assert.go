@@ -0,0 +1,199 @@
+package telemetrytest
+
+import (
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelLog "go.opentelemetry.io/otel/log"
+	sdkLog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Span provides assertions on a recorded span. All assertions fail the
+// test immediately and return the span to allow chaining them.
+type Span struct {
+	sdkTrace.ReadOnlySpan
+	t testing.TB
+}
+
+// HasAttribute asserts the span includes the attribute `key` with the
+// provided value; values are compared using their string representation.
+func (s *Span) HasAttribute(key string, value any) *Span {
+	s.t.Helper()
+	assertAttribute(s.t, fmt.Sprintf("span %q", s.Name()), s.Attributes(), key, value)
+	return s
+}
+
+// HasStatus asserts the span status code.
+func (s *Span) HasStatus(code codes.Code) *Span {
+	s.t.Helper()
+	if got := s.Status().Code; got != code {
+		s.t.Fatalf("span %q: expected status %s, got %s", s.Name(), code, got)
+	}
+	return s
+}
+
+// HasEvent asserts the span includes an event with the provided name,
+// and returns the last one.
+func (s *Span) HasEvent(name string) *Event {
+	s.t.Helper()
+	events := s.Events()
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Name == name {
+			return &Event{Event: events[i], span: s.Name(), t: s.t}
+		}
+	}
+	s.t.Fatalf("span %q: no event with name %q", s.Name(), name)
+	return nil
+}
+
+// ChildOf asserts the span is a direct child of `parent`.
+func (s *Span) ChildOf(parent *Span) *Span {
+	s.t.Helper()
+	if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+		s.t.Fatalf("span %q: expected parent %q", s.Name(), parent.Name())
+	}
+	if s.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+		s.t.Fatalf("span %q: expected trace %s", s.Name(), parent.SpanContext().TraceID())
+	}
+	return s
+}
+
+// Event provides assertions on a span event.
+type Event struct {
+	sdkTrace.Event
+	span string
+	t    testing.TB
+}
+
+// HasAttribute asserts the event includes the attribute `key` with the
+// provided value; values are compared using their string representation.
+func (e *Event) HasAttribute(key string, value any) *Event {
+	e.t.Helper()
+	assertAttribute(e.t, fmt.Sprintf("span %q, event %q", e.span, e.Name), e.Attributes, key, value)
+	return e
+}
+
+// Metric provides assertions on a recorded metric.
+type Metric struct {
+	metricdata.Metrics
+	t testing.TB
+}
+
+// Count returns the sum of all the data points including the provided
+// attributes; for histograms, the number of values recorded is used.
+func (m *Metric) Count(attrs ...attribute.KeyValue) float64 {
+	m.t.Helper()
+	total := 0.0
+	switch data := m.Data.(type) {
+	case metricdata.Sum[int64]:
+		for _, dp := range data.DataPoints {
+			if hasAll(dp.Attributes, attrs) {
+				total += float64(dp.Value)
+			}
+		}
+	case metricdata.Sum[float64]:
+		for _, dp := range data.DataPoints {
+			if hasAll(dp.Attributes, attrs) {
+				total += dp.Value
+			}
+		}
+	case metricdata.Histogram[int64]:
+		for _, dp := range data.DataPoints {
+			if hasAll(dp.Attributes, attrs) {
+				total += float64(dp.Count)
+			}
+		}
+	case metricdata.Histogram[float64]:
+		for _, dp := range data.DataPoints {
+			if hasAll(dp.Attributes, attrs) {
+				total += float64(dp.Count)
+			}
+		}
+	default:
+		m.t.Fatalf("metric %q: unsupported type %T", m.Name, m.Data)
+	}
+	return total
+}
+
+// HasCount asserts the value returned by `Count` for the provided
+// attributes.
+func (m *Metric) HasCount(want float64, attrs ...attribute.KeyValue) *Metric {
+	m.t.Helper()
+	if got := m.Count(attrs...); got != want {
+		m.t.Fatalf("metric %q: expected count %v for %v, got %v", m.Name, want, attrs, got)
+	}
+	return m
+}
+
+// Log provides assertions on a captured log record.
+type Log struct {
+	sdkLog.Record
+	t testing.TB
+}
+
+// HasSeverity asserts the severity text of the record, for example
+// "warning".
+func (l *Log) HasSeverity(text string) *Log {
+	l.t.Helper()
+	if got := l.SeverityText(); got != text {
+		l.t.Fatalf("log %q: expected severity %s, got %s", l.Body().AsString(), text, got)
+	}
+	return l
+}
+
+// HasAttribute asserts the record includes the attribute `key` with the
+// provided value; values are compared using their string representation.
+func (l *Log) HasAttribute(key string, value any) *Log {
+	l.t.Helper()
+	found := false
+	l.WalkAttributes(func(kv otelLog.KeyValue) bool {
+		if kv.Key == key {
+			found = true
+			if got := kv.Value.String(); got != fmt.Sprint(value) {
+				l.t.Fatalf("log %q: expected %s=%v, got %s", l.Body().AsString(), key, value, got)
+			}
+			return false
+		}
+		return true
+	})
+	if !found {
+		l.t.Fatalf("log %q: no attribute %s", l.Body().AsString(), key)
+	}
+	return l
+}
+
+// InSpan asserts the record was produced while handling `span`.
+func (l *Log) InSpan(span *Span) *Log {
+	l.t.Helper()
+	if l.SpanID() != span.SpanContext().SpanID() || l.TraceID() != span.SpanContext().TraceID() {
+		l.t.Fatalf("log %q: expected to be produced on span %q", l.Body().AsString(), span.Name())
+	}
+	return l
+}
+
+func assertAttribute(t testing.TB, origin string, attrs []attribute.KeyValue, key string, value any) {
+	t.Helper()
+	for _, kv := range attrs {
+		if string(kv.Key) != key {
+			continue
+		}
+		if got := kv.Value.Emit(); got != fmt.Sprint(value) {
+			t.Fatalf("%s: expected %s=%v, got %s", origin, key, value, got)
+		}
+		return
+	}
+	t.Fatalf("%s: no attribute %s", origin, key)
+}
+
+func hasAll(set attribute.Set, attrs []attribute.KeyValue) bool {
+	for _, kv := range attrs {
+		if v, ok := set.Value(kv.Key); !ok || v != kv.Value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+/*
+Package telemetrytest provides an in-memory telemetry recorder for tests.
+
+The recorder captures the spans, metrics and log records produced while the
+otel module is running, and provides helpers to assert on span names,
+attributes, events, status and parent relationships. When using the test
+harness, the recorder is attached using the `harness.WithTelemetry` option.
+
+	rec := telemetrytest.New()
+	h := harness.New(t, nil, harness.WithTelemetry(rec))
+	_, _ = h.Client.Slow(context.Background(), &emptypb.Empty{})
+
+	server := rec.Span(t, "sample.v1.ServiceAPI/Slow")
+	rec.Span(t, "slow handler").
+		ChildOf(server).
+		HasEvent("waiting for slow operation")
+	rec.Metric(t, "rpc.server.requests").
+		HasCount(1, attribute.String("rpc.grpc.status_code", "OK"))
+	rec.Log(t, "waiting for slow operation").HasSeverity("debug")
+
+All assertions fail the test immediately. Attribute values are compared using
+their string representation, so `20` and `int64(20)` are equivalent.
+*/
+package telemetrytest
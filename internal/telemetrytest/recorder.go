@@ -0,0 +1,174 @@
+package telemetrytest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	dxOtel "github.com/bcessa/echo-service/internal/dx/modules/otel"
+	otelSdk "go.bryk.io/pkg/otel/sdk"
+	sdkLog "go.opentelemetry.io/otel/sdk/log"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Recorder captures the spans, metrics and logs produced by the service,
+// keeping them in memory to be inspected by tests.
+type Recorder struct {
+	spans   *tracetest.SpanRecorder
+	metrics *sdkMetric.ManualReader
+	logs    []sdkLog.Record
+	mu      sync.Mutex
+}
+
+// New returns an empty telemetry recorder.
+func New() *Recorder {
+	return &Recorder{spans: tracetest.NewSpanRecorder()}
+}
+
+// Attach the recorder to the provided otel module. The module must be
+// enabled, and the recorder must be attached again every time the module
+// is restarted.
+func (r *Recorder) Attach(m *dxOtel.Module) {
+	m.Extend(r.Options()...)
+	m.ExtendLogs(r.LogProcessor())
+}
+
+// Options returns the settings required to capture spans and metrics on
+// a telemetry operator. A metric reader can't be shared by operators, so
+// only metrics from the last operator set up are available.
+func (r *Recorder) Options() []otelSdk.Option {
+	r.mu.Lock()
+	r.metrics = sdkMetric.NewManualReader()
+	r.mu.Unlock()
+	return []otelSdk.Option{
+		otelSdk.WithSpanProcessor(r.spans),
+		otelSdk.WithMetricReader(r.metrics),
+	}
+}
+
+// LogProcessor returns a processor capturing log records.
+func (r *Recorder) LogProcessor() sdkLog.Processor {
+	return (*logProcessor)(r)
+}
+
+// Spans returns all the completed spans with the provided name, in the
+// order they ended; if `name` is empty all completed spans are returned.
+func (r *Recorder) Spans(name string) []sdkTrace.ReadOnlySpan {
+	list := []sdkTrace.ReadOnlySpan{}
+	for _, s := range r.spans.Ended() {
+		if name == "" || s.Name() == name {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// Span returns the last completed span with the provided name; the test
+// fails immediately if no such span was recorded.
+func (r *Recorder) Span(t testing.TB, name string) *Span {
+	t.Helper()
+	list := r.Spans(name)
+	if len(list) == 0 {
+		t.Fatalf("no span recorded with name %q; recorded: %v", name, r.spanNames())
+	}
+	return &Span{ReadOnlySpan: list[len(list)-1], t: t}
+}
+
+// Metrics collects the current value of all the metrics recorded.
+func (r *Recorder) Metrics(t testing.TB) metricdata.ResourceMetrics {
+	t.Helper()
+	r.mu.Lock()
+	reader := r.metrics
+	r.mu.Unlock()
+	rm := metricdata.ResourceMetrics{}
+	if reader == nil {
+		t.Fatal("recorder is not attached")
+	}
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect metrics: %s", err)
+	}
+	return rm
+}
+
+// Metric returns the current value of the metric with the provided name;
+// the test fails immediately if no such metric was recorded.
+func (r *Recorder) Metric(t testing.TB, name string) *Metric {
+	t.Helper()
+	names := []string{}
+	for _, sm := range r.Metrics(t).ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return &Metric{Metrics: m, t: t}
+			}
+			names = append(names, m.Name)
+		}
+	}
+	t.Fatalf("no metric recorded with name %q; recorded: %v", name, names)
+	return nil
+}
+
+// Logs returns all the log records captured, in the order they were
+// produced; if `body` is not empty only matching records are returned.
+func (r *Recorder) Logs(body string) []sdkLog.Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := []sdkLog.Record{}
+	for _, rec := range r.logs {
+		if body == "" || rec.Body().AsString() == body {
+			list = append(list, rec.Clone())
+		}
+	}
+	return list
+}
+
+// Log returns the last log record with the provided body; the test fails
+// immediately if no such record was captured.
+func (r *Recorder) Log(t testing.TB, body string) *Log {
+	t.Helper()
+	list := r.Logs(body)
+	if len(list) == 0 {
+		t.Fatalf("no log recorded with body %q", body)
+	}
+	return &Log{Record: list[len(list)-1], t: t}
+}
+
+// Reset discards all the spans and logs captured. Metrics are cumulative
+// and can't be reset.
+func (r *Recorder) Reset() {
+	r.spans.Reset()
+	r.mu.Lock()
+	r.logs = nil
+	r.mu.Unlock()
+}
+
+func (r *Recorder) spanNames() []string {
+	names := []string{}
+	for _, s := range r.spans.Ended() {
+		if !slices.Contains(names, s.Name()) {
+			names = append(names, s.Name())
+		}
+	}
+	return names
+}
+
+// logProcessor captures every log record emitted.
+type logProcessor Recorder
+
+func (lp *logProcessor) OnEmit(_ context.Context, rec *sdkLog.Record) error {
+	lp.mu.Lock()
+	lp.logs = append(lp.logs, rec.Clone())
+	lp.mu.Unlock()
+	return nil
+}
+
+func (lp *logProcessor) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func (lp *logProcessor) ForceFlush(_ context.Context) error {
+	return nil
+}
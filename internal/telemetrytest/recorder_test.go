@@ -0,0 +1,89 @@
+package telemetrytest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bcessa/echo-service/handler"
+	"github.com/bcessa/echo-service/internal/harness"
+	"github.com/bcessa/echo-service/internal/telemetrytest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := telemetrytest.New()
+	h := harness.New(t, map[string]any{
+		"rpc":        map[string]any{"metrics": map[string]any{"enabled": true}},
+		"middleware": map[string]any{"metrics": map[string]any{"enabled": true}},
+	}, harness.WithTelemetry(rec), harness.WithHandlerOptions(
+		handler.WithLatency(handler.Latency{Distribution: handler.LatencyFixed, Mean: 5 * time.Millisecond}),
+		handler.WithErrorRate(0, 1),
+	))
+	ctx := context.Background()
+
+	t.Run("slow", func(t *testing.T) {
+		rec.Reset()
+		if _, err := h.Client.Slow(ctx, &emptypb.Empty{}); err != nil {
+			t.Fatal(err)
+		}
+		server := rec.Span(t, "sample.v1.ServiceAPI/Slow")
+		span := rec.Span(t, "slow handler").ChildOf(server).HasStatus(codes.Unset)
+		span.HasEvent("waiting for slow operation").HasAttribute("app.delay", 5)
+		rec.Log(t, "waiting for slow operation").
+			HasSeverity("debug").
+			HasAttribute("app.delay", 5).
+			InSpan(span)
+	})
+
+	t.Run("faulty", func(t *testing.T) {
+		rec.Reset()
+		if _, err := h.Client.Faulty(ctx, &emptypb.Empty{}); err == nil {
+			t.Fatal("expected an error")
+		}
+		server := rec.Span(t, "sample.v1.ServiceAPI/Faulty").HasStatus(codes.Error)
+		span := rec.Span(t, "faulty handler").ChildOf(server).HasStatus(codes.Error)
+		event := span.HasEvent("bad luck")
+		found := false
+		for _, kv := range event.Attributes {
+			found = found || kv.Key == "random.value"
+		}
+		if !found {
+			t.Error("no random value on the event")
+		}
+		rec.Log(t, "bad luck").HasSeverity("debug").InSpan(span)
+	})
+
+	t.Run("rpc metrics", func(t *testing.T) {
+		slow := attribute.String("rpc.method", "/sample.v1.ServiceAPI/Slow")
+		faulty := attribute.String("rpc.method", "/sample.v1.ServiceAPI/Faulty")
+		rec.Metric(t, "rpc.server.requests").
+			HasCount(1, slow, attribute.String("rpc.grpc.status_code", "OK")).
+			HasCount(1, faulty, attribute.String("rpc.grpc.status_code", "Internal"))
+		rec.Metric(t, "rpc.server.request.duration").
+			HasCount(1, slow).
+			HasCount(1, faulty)
+	})
+
+	t.Run("http metrics", func(t *testing.T) {
+		for _, path := range []string{"/v1/echo/slow", "/v1/echo/faulty", "/v1/echo/faulty"} {
+			res, err := h.HTTP.Post(h.URL(path), "application/json", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Body.Close()
+		}
+		post := attribute.String("http.request.method", http.MethodPost)
+		slow := attribute.String("http.route", "/v1/echo/slow")
+		faulty := attribute.String("http.route", "/v1/echo/faulty")
+		rec.Metric(t, "http.server.requests").
+			HasCount(1, post, slow, attribute.Int("http.response.status_code", http.StatusOK)).
+			HasCount(2, post, faulty, attribute.Int("http.response.status_code", http.StatusInternalServerError))
+		rec.Metric(t, "http.server.request.duration").
+			HasCount(1, slow).
+			HasCount(2, faulty)
+	})
+}